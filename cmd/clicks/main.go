@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	"io"
+	baselog "log"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"url-shortner/internel/config"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/lib/clickio"
+	"url-shortner/internel/lib/logger/scriptLogger"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/privacy"
+	"url-shortner/internel/lib/shorturl"
+	"url-shortner/internel/storage/sqlite"
+)
+
+const usage = `usage:
+  clicks export -format csv|ndjson|parquet [-out clicks.csv] [-alias promo [-domain host] | -workspace 1]
+                [-from 2026-01-01] [-to 2026-01-31] [-tz Europe/Berlin] [-bots=false] [-filter country=DE ...]
+  clicks erase -ip 203.0.113.7
+  clicks anonymize`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	err := godotenv.Load()
+	if err != nil {
+		baselog.Fatal("Error loading .env file")
+	}
+	cfg := config.MustLoad()
+	log := scriptLogger.SetupLogger(cfg.Env)
+
+	anonymizer, err := privacy.New(cfg.Privacy.IPMode, cfg.Privacy.IPHashSecret)
+	if err != nil {
+		log.Error("failed to init ip anonymizer", sl.Err(err))
+		os.Exit(1)
+	}
+
+	storage, err := sqlite.New(cfg.StoragePath)
+	if err != nil {
+		log.Error("failed to initialize storage", sl.Err(err))
+		os.Exit(1)
+	}
+	defer storage.CloseConnection()
+
+	switch os.Args[1] {
+	case "export":
+		err = runExport(log, storage, os.Args[2:])
+	case "erase":
+		err = runErase(log, storage, anonymizer, os.Args[2:])
+	case "anonymize":
+		err = runAnonymize(log, storage, anonymizer)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Error("failed to "+os.Args[1]+" clicks", sl.Err(err))
+		os.Exit(1)
+	}
+}
+
+func runExport(log *slog.Logger, storage *sqlite.Storage, args []string) error {
+	values := url.Values{}
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", clickio.FormatCSV, "output format")
+	out := flags.String("out", "", "file to write, stdout when empty")
+	alias := flags.String("alias", "", "export the clicks of this link")
+	host := flags.String("domain", "", "custom domain of the link")
+	workspaceId := flags.Int64("workspace", 0, "export the clicks of this workspace")
+	for _, name := range []string{"from", "to", "tz", "bots"} {
+		name := name
+		flags.Func(name, "see the export endpoint", func(value string) error {
+			values.Set(name, value)
+			return nil
+		})
+	}
+	flags.Func("filter", "dimension=value, can be repeated", func(value string) error {
+		dimension, v, ok := strings.Cut(value, "=")
+		if !ok {
+			return errors.New("filter must be dimension=value")
+		}
+		values.Set(dimension, v)
+		return nil
+	})
+	_ = flags.Parse(args)
+
+	filter, err := clickio.ParseFilter(values)
+	if err != nil {
+		return err
+	}
+	filter.WorkspaceId = *workspaceId
+
+	if *alias != "" {
+		var linkDomain domain.Domain
+		if *host != "" {
+			linkDomain, err = storage.GetDomainByHost(shorturl.Host(*host))
+			if err != nil {
+				return fmt.Errorf("domain %q: %w", *host, err)
+			}
+		}
+		link, err := storage.GetURL(linkDomain.ID, *alias)
+		if err != nil {
+			return fmt.Errorf("link %q: %w", *alias, err)
+		}
+		filter.UrlId = link.Id
+	}
+
+	var output io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		output = f
+	}
+
+	writer, err := clickio.NewWriter(*format, output)
+	if err != nil {
+		return err
+	}
+
+	count, err := clickio.Export(context.Background(), storage, writer, clickio.ExportOptions{Filter: filter})
+	if err != nil {
+		return err
+	}
+
+	// logs go to stdout too, so they would end up in the exported data
+	if *out != "" {
+		log.Info("Clicks exported", slog.Int("count", count))
+	}
+
+	return nil
+}
+
+// runErase deletes the clicks of an IP, those stored raw and, in hash mode,
+// those stored with its hash. Truncated IPs are shared by a whole network
+// and can't be told apart, so they are left.
+func runErase(log *slog.Logger, storage *sqlite.Storage, anonymizer *privacy.Anonymizer, args []string) error {
+	flags := flag.NewFlagSet("erase", flag.ExitOnError)
+	ip := flags.String("ip", "", "visitor ip whose clicks are deleted")
+	_ = flags.Parse(args)
+
+	if net.ParseIP(*ip) == nil {
+		return errors.New("-ip must be an ip address")
+	}
+
+	ips := []string{*ip}
+	if anonymizer.Mode() == privacy.IPHash {
+		ips = append(ips, anonymizer.IP(*ip))
+	}
+
+	deleted, err := storage.DeleteRedirectInfoByIP(ips...)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Clicks erased", slog.Int64("count", deleted))
+	if anonymizer.Mode() == privacy.IPTruncate {
+		log.Info("Clicks stored with truncated ips were kept, they can't be attributed to a single ip")
+	}
+
+	return nil
+}
+
+// runAnonymize applies the configured ip mode to clicks stored before it
+// was set. Values that aren't IPs, like hashes, are kept.
+func runAnonymize(log *slog.Logger, storage *sqlite.Storage, anonymizer *privacy.Anonymizer) error {
+	if anonymizer.Mode() == privacy.IPRaw {
+		return errors.New("ip mode is raw, set privacy.ip_mode to truncate or hash")
+	}
+
+	changed, err := storage.AnonymizeRedirectInfoIPs(func(ip string) string {
+		if net.ParseIP(ip) == nil {
+			return ip
+		}
+		return anonymizer.IP(ip)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Info("Clicks anonymized", slog.Int64("count", changed))
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	"io"
+	baselog "log"
+	"log/slog"
+	"os"
+	"url-shortner/internel/config"
+	"url-shortner/internel/lib/linkio"
+	"url-shortner/internel/lib/logger/scriptLogger"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/urlpolicy"
+	"url-shortner/internel/storage/sqlite"
+)
+
+const usage = `usage:
+  links import -format csv|jsonl|yourls|bitly [-file links.csv] [-conflict skip|overwrite|rename] [-owner username] [-dry-run] [-report report.json]
+  links export -format csv|jsonl|yourls|bitly [-out links.csv]`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	err := godotenv.Load()
+	if err != nil {
+		baselog.Fatal("Error loading .env file")
+	}
+	cfg := config.MustLoad()
+	log := scriptLogger.SetupLogger(cfg.Env)
+
+	storage, err := sqlite.New(cfg.StoragePath)
+	if err != nil {
+		log.Error("failed to initialize storage", sl.Err(err))
+		os.Exit(1)
+	}
+	defer storage.CloseConnection()
+
+	switch os.Args[1] {
+	case "import":
+		err = runImport(log, storage, cfg, os.Args[2:])
+	case "export":
+		err = runExport(log, storage, cfg.BaseURL, os.Args[2:])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Error("failed to "+os.Args[1]+" links", sl.Err(err))
+		os.Exit(1)
+	}
+}
+
+func runImport(log *slog.Logger, storage *sqlite.Storage, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", linkio.FormatCSV, "input format")
+	file := flags.String("file", "", "file to import, stdin when empty")
+	conflict := flags.String("conflict", linkio.ConflictSkip, "what to do with existing aliases")
+	owner := flags.String("owner", "", "username owning links without a known owner")
+	dryRun := flags.Bool("dry-run", false, "report what would be imported without saving")
+	reportPath := flags.String("report", "", "write the full JSON report to this file")
+	_ = flags.Parse(args)
+
+	var input io.Reader = os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	var defaultOwner int64
+	if *owner != "" {
+		u, err := storage.GetUser(*owner)
+		if err != nil {
+			return fmt.Errorf("owner %q: %w", *owner, err)
+		}
+		defaultOwner = u.ID
+	}
+
+	urlPolicy, err := urlpolicy.New(urlpolicy.Options{
+		Schemes:      cfg.URLPolicy.Schemes,
+		AllowList:    cfg.URLPolicy.AllowList,
+		DenyList:     cfg.URLPolicy.DenyList,
+		AllowPrivate: cfg.URLPolicy.AllowPrivate,
+	})
+	if err != nil {
+		return err
+	}
+
+	reader, err := linkio.NewReader(*format, input)
+	if err != nil {
+		return err
+	}
+
+	report, err := linkio.Import(storage, reader, linkio.ImportOptions{
+		Conflict:     *conflict,
+		DryRun:       *dryRun,
+		DefaultOwner: defaultOwner,
+		URLPolicy:    urlPolicy,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		if row.Action != linkio.ActionCreated || row.Message != "" {
+			fmt.Printf("row %d\t%s\t%s\t%s %s\n", row.Row, row.Action, row.Alias, row.NewAlias, row.Message)
+		}
+	}
+
+	if *reportPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*reportPath, data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	log.Info("Links imported",
+		slog.Bool("dry_run", report.DryRun),
+		slog.Int("total", report.Total),
+		slog.Int("created", report.Created),
+		slog.Int("overwritten", report.Overwritten),
+		slog.Int("renamed", report.Renamed),
+		slog.Int("skipped", report.Skipped),
+		slog.Int("failed", report.Failed),
+	)
+
+	return nil
+}
+
+func runExport(log *slog.Logger, storage *sqlite.Storage, baseURL string, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", linkio.FormatCSV, "output format")
+	out := flags.String("out", "", "file to write, stdout when empty")
+	_ = flags.Parse(args)
+
+	var output io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		output = f
+	}
+
+	writer, err := linkio.NewWriter(*format, output, baseURL)
+	if err != nil {
+		return err
+	}
+
+	count, err := linkio.Export(storage, writer)
+	if err != nil {
+		return err
+	}
+
+	// logs go to stdout too, so they would end up in the exported data
+	if *out != "" {
+		log.Info("Links exported", slog.Int("count", count))
+	}
+
+	return nil
+}
@@ -31,7 +31,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	_, err = storage.Query("INSERT INTO users(username, password) VALUES (?, ?)",
+	_, err = storage.Query("INSERT INTO users(username, password, is_admin) VALUES (?, ?, 1)",
 		os.Getenv("APP_USER"), hashPassword)
 	if err != nil {
 		log.Error("failed to create default user: %v", err)
@@ -2,18 +2,31 @@ package main
 
 import (
 	"context"
+	"errors"
 	"github.com/joho/godotenv"
 	baselog "log"
 	"log/slog"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 	"url-shortner/internel/config"
+	"url-shortner/internel/lib/alias"
 	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/auth/linkpass"
+	"url-shortner/internel/lib/bots"
+	"url-shortner/internel/lib/clickstream"
+	"url-shortner/internel/lib/geo"
+	"url-shortner/internel/lib/health"
 	"url-shortner/internel/lib/logger/handlers/slogpretty"
 	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/metrics"
+	"url-shortner/internel/lib/privacy"
+	"url-shortner/internel/lib/urlpolicy"
+	"url-shortner/internel/lib/visitor"
+	"url-shortner/internel/lib/webhooks"
 	"url-shortner/internel/routes"
 	"url-shortner/internel/storage/sqlite"
 )
@@ -43,9 +56,121 @@ func main() {
 	sqlite.DB = storage.Db
 	defer storage.CloseConnection()
 
+	aliases, err := alias.New(alias.Options{
+		Strategy:          cfg.Alias.Strategy,
+		Length:            cfg.Alias.Length,
+		Alphabet:          cfg.Alias.Alphabet,
+		ExcludeLookalikes: cfg.Alias.ExcludeLookalikes,
+		MaxAttempts:       cfg.Alias.MaxAttempts,
+		GrowAfter:         cfg.Alias.GrowAfter,
+	}, storage)
+	if err != nil {
+		log.Error("failed to init alias generator", sl.Err(err))
+		os.Exit(1)
+	}
+
+	policy, err := alias.NewPolicy(alias.PolicyOptions{
+		Charset:       cfg.Alias.Policy.Charset,
+		MinLength:     cfg.Alias.Policy.MinLength,
+		MaxLength:     cfg.Alias.Policy.MaxLength,
+		CaseSensitive: cfg.Alias.Policy.CaseSensitive,
+		Reserved:      cfg.Alias.Policy.Reserved,
+		ProfanityList: cfg.Alias.Policy.ProfanityList,
+	})
+	if err != nil {
+		log.Error("failed to init alias policy", sl.Err(err))
+		os.Exit(1)
+	}
+	aliases.UsePolicy(policy)
+
+	cookies, err := linkpass.New(cfg.LinkPassword.CookieSecret, cfg.LinkPassword.CookieTTL)
+	if err != nil {
+		log.Error("failed to init link password cookies", sl.Err(err))
+		os.Exit(1)
+	}
+
+	var geoResolver geo.Resolver = geo.Noop{}
+	var geoCache *geo.Cache
+	if cfg.Geo.Database != "" {
+		mmdb, err := geo.OpenMMDB(cfg.Geo.Database)
+		if err != nil {
+			log.Error("failed to open geoip database", sl.Err(err))
+			os.Exit(1)
+		}
+		defer mmdb.Close()
+		geoCache = geo.NewCache(mmdb, cfg.Geo.CacheSize)
+		geoResolver = geoCache
+	}
+
+	urlPolicy, err := urlpolicy.New(urlpolicy.Options{
+		Schemes:      cfg.URLPolicy.Schemes,
+		AllowList:    cfg.URLPolicy.AllowList,
+		DenyList:     cfg.URLPolicy.DenyList,
+		AllowPrivate: cfg.URLPolicy.AllowPrivate,
+	})
+	if err != nil {
+		log.Error("failed to init url policy", sl.Err(err))
+		os.Exit(1)
+	}
+	go watchURLPolicy(log, urlPolicy, storage, cfg.URLPolicy.ReloadInterval)
+
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	defer stopHealth()
+	if cfg.Health.Interval > 0 {
+		checker := health.New(health.Options{
+			Policy:       urlPolicy,
+			Timeout:      cfg.Health.Timeout,
+			Concurrency:  cfg.Health.Concurrency,
+			HostDelay:    cfg.Health.HostDelay,
+			MaxRedirects: cfg.Health.MaxRedirects,
+		})
+		go checkHealth(healthCtx, log, checker, storage, cfg.Health.Interval)
+	}
+
+	botDetector, err := bots.New(cfg.Clicks.BotSignatures)
+	if err != nil {
+		log.Error("failed to load bot signatures", sl.Err(err))
+		os.Exit(1)
+	}
+
+	visitors, err := visitor.New(cfg.Clicks.VisitorSecret)
+	if err != nil {
+		log.Error("failed to init visitor hashes", sl.Err(err))
+		os.Exit(1)
+	}
+
+	ipAnonymizer, err := privacy.New(cfg.Privacy.IPMode, cfg.Privacy.IPHashSecret)
+	if err != nil {
+		log.Error("failed to init ip anonymizer", sl.Err(err))
+		os.Exit(1)
+	}
+
+	clicks := clickstream.New(clickstream.Options{
+		Buffer:       cfg.Live.Buffer,
+		Heartbeat:    cfg.Live.Heartbeat,
+		WriteTimeout: cfg.Live.WriteTimeout,
+	})
+
+	hooks := webhooks.New(log, storage, webhooks.Options{
+		Dialer:       urlPolicy,
+		MaxAttempts:  cfg.Webhooks.MaxAttempts,
+		Backoff:      cfg.Webhooks.Backoff,
+		MaxBackoff:   cfg.Webhooks.MaxBackoff,
+		Timeout:      cfg.Webhooks.Timeout,
+		Concurrency:  cfg.Webhooks.Concurrency,
+		PollInterval: cfg.Webhooks.PollInterval,
+		BaseURL:      cfg.BaseURL,
+	})
+	hooksCtx, stopHooks := context.WithCancel(context.Background())
+	defer stopHooks()
+	go hooks.Run(hooksCtx)
+
+	reg := metrics.NewRegistry()
+	registerMetrics(log, reg, storage, aliases, geoCache, clicks)
+
 	// init router: chi, "chi render"
 	jwt.Init()
-	router := routes.New(log, storage)
+	router := routes.New(log, cfg, storage, aliases, policy, cookies, geoResolver, urlPolicy, botDetector, visitors, ipAnonymizer, clicks, hooks, reg)
 
 	// run server
 	log.Info("starting server", slog.String("address", cfg.Address))
@@ -60,6 +185,8 @@ func main() {
 		WriteTimeout: cfg.HTTPServer.Timeout,
 		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
 	}
+	// live streams don't end on their own
+	srv.RegisterOnShutdown(clicks.Close)
 
 	go func() {
 		if err := srv.ListenAndServe(); err != nil {
@@ -69,12 +196,39 @@ func main() {
 
 	log.Info("server started")
 
+	// metrics get their own listener, so they can be kept off the public one
+	var metricsSrv *http.Server
+	if cfg.Metrics.Address != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", reg.Handler())
+		metricsSrv = &http.Server{
+			Addr:         cfg.Metrics.Address,
+			Handler:      metricsMux,
+			ReadTimeout:  cfg.HTTPServer.Timeout,
+			WriteTimeout: cfg.HTTPServer.Timeout,
+		}
+
+		go func() {
+			if err := metricsSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error("failed to start metrics server", sl.Err(err))
+			}
+		}()
+
+		log.Info("serving metrics", slog.String("address", cfg.Metrics.Address))
+	}
+
 	<-done
 	log.Info("stopping server")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			log.Error("failed to stop metrics server", sl.Err(err))
+		}
+	}
+
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Error("failed to stop server", sl.Err(err))
 
@@ -84,6 +238,126 @@ func main() {
 	log.Info("server stopped")
 }
 
+// watchURLPolicy checks all links against the url policy, then reloads its
+// lists every interval and checks the links again when they changed.
+func watchURLPolicy(log *slog.Logger, urlPolicy *urlpolicy.Policy, store urlpolicy.Store, interval time.Duration) {
+	recheck := func() {
+		disabled, enabled, err := urlpolicy.Recheck(store, urlPolicy)
+		if err != nil {
+			log.Error("failed to recheck links", sl.Err(err))
+			return
+		}
+		if disabled > 0 || enabled > 0 {
+			log.Info("links rechecked", slog.Int("disabled", disabled), slog.Int("enabled", enabled))
+		}
+	}
+
+	recheck()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		changed, err := urlPolicy.Reload()
+		if err != nil {
+			log.Error("failed to reload url policy", sl.Err(err))
+			continue
+		}
+		if changed {
+			log.Info("url policy lists reloaded")
+			recheck()
+		}
+	}
+}
+
+// registerMetrics adds the storage, connection pool, cache and queue metrics
+// to reg, request metrics are added by the router. geoCache is nil when
+// clicks are not located.
+func registerMetrics(log *slog.Logger, reg *metrics.Registry, storage *sqlite.Storage, aliases *alias.Allocator, geoCache *geo.Cache, clicks *clickstream.Hub) {
+	operations := reg.Histogram("shortener_storage_operation_duration_seconds",
+		"How long storage operations took by op.", nil, "op")
+	storage.Observe(func(op string, took time.Duration) {
+		operations.Observe(took.Seconds(), op)
+	})
+
+	reg.GaugeFunc("shortener_db_open_connections", "Open database connections.", func() float64 {
+		return float64(storage.Db.Stats().OpenConnections)
+	})
+	reg.GaugeFunc("shortener_db_in_use_connections", "Database connections in use.", func() float64 {
+		return float64(storage.Db.Stats().InUse)
+	})
+	reg.GaugeFunc("shortener_db_idle_connections", "Idle database connections.", func() float64 {
+		return float64(storage.Db.Stats().Idle)
+	})
+	reg.GaugeFunc("shortener_db_max_open_connections", "Limit of open database connections, 0 for none.", func() float64 {
+		return float64(storage.Db.Stats().MaxOpenConnections)
+	})
+	reg.CounterFunc("shortener_db_wait_total", "Times a query waited for a database connection.", func() float64 {
+		return float64(storage.Db.Stats().WaitCount)
+	})
+	reg.CounterFunc("shortener_db_wait_duration_seconds_total", "Time spent waiting for database connections.", func() float64 {
+		return storage.Db.Stats().WaitDuration.Seconds()
+	})
+
+	reg.CounterFunc("shortener_alias_attempts_total", "Generated aliases tried.", func() float64 {
+		return float64(aliases.Stats().Attempts)
+	})
+	reg.CounterFunc("shortener_alias_collisions_total", "Generated aliases that were already taken.", func() float64 {
+		return float64(aliases.Stats().Collisions)
+	})
+
+	if geoCache != nil {
+		reg.GaugeFunc("shortener_geo_cache_entries", "Cached geoip lookups.", func() float64 {
+			return float64(geoCache.Len())
+		})
+	}
+
+	reg.GaugeFunc("shortener_live_subscribers", "Open live click streams.", func() float64 {
+		return float64(clicks.Subscribers())
+	})
+	reg.GaugeFunc("shortener_webhook_deliveries_pending", "Webhook deliveries waiting to be sent or retried.", func() float64 {
+		pending, err := storage.CountPendingWebhookDeliveries()
+		if err != nil {
+			log.Error("failed to count pending webhook deliveries", sl.Err(err))
+			return math.NaN()
+		}
+		return float64(pending)
+	})
+}
+
+// checkHealth checks the destinations of all links every interval until ctx
+// is done.
+func checkHealth(ctx context.Context, log *slog.Logger, checker *health.Checker, store health.Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		checked, failed, err := checker.CheckAll(ctx, store)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Error("failed to check link health", sl.Err(err))
+		} else {
+			log.Info("link health checked",
+				slog.Int("checked", checked),
+				slog.Int("failed", failed),
+				slog.Duration("took", time.Since(start)),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 func setupLogger(env string) *slog.Logger {
 	var log *slog.Logger
 	switch env {
@@ -9,9 +9,23 @@ import (
 )
 
 type Config struct {
-	Env         string `yaml:"env" env-default:"development"`
-	StoragePath string `yaml:"storage_path" env-required:"true"`
-	HTTPServer  `yaml:"http_server"`
+	Env          string `yaml:"env" env-default:"development"`
+	StoragePath  string `yaml:"storage_path" env-required:"true"`
+	BaseURL      string `yaml:"base_url" env:"BASE_URL" env-default:"http://localhost:8080"`
+	HTTPServer   `yaml:"http_server"`
+	Alias        Alias        `yaml:"alias"`
+	Bulk         Bulk         `yaml:"bulk"`
+	Preview      Preview      `yaml:"preview"`
+	Redirect     Redirect     `yaml:"redirect"`
+	LinkPassword LinkPassword `yaml:"link_password"`
+	Geo          Geo          `yaml:"geo"`
+	URLPolicy    URLPolicy    `yaml:"url_policy"`
+	Health       Health       `yaml:"health"`
+	Clicks       Clicks       `yaml:"clicks"`
+	Privacy      Privacy      `yaml:"privacy"`
+	Live         Live         `yaml:"live"`
+	Webhooks     Webhooks     `yaml:"webhooks"`
+	Metrics      Metrics      `yaml:"metrics"`
 }
 
 type HTTPServer struct {
@@ -20,6 +34,146 @@ type HTTPServer struct {
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
 }
 
+type Alias struct {
+	// Strategy is one of random, counter or sqids.
+	Strategy          string      `yaml:"strategy" env-default:"random"`
+	Length            int         `yaml:"length" env-default:"6"`
+	Alphabet          string      `yaml:"alphabet" env-default:"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"`
+	ExcludeLookalikes bool        `yaml:"exclude_lookalikes" env-default:"false"`
+	MaxAttempts       int         `yaml:"max_attempts" env-default:"10"`
+	GrowAfter         int         `yaml:"grow_after" env-default:"3"`
+	Policy            AliasPolicy `yaml:"policy"`
+}
+
+type AliasPolicy struct {
+	Charset   string `yaml:"charset" env-default:"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"`
+	MinLength int    `yaml:"min_length" env-default:"3"`
+	MaxLength int    `yaml:"max_length" env-default:"64"`
+	// CaseSensitive false stores aliases lowercased and matches them
+	// regardless of case when redirecting.
+	CaseSensitive bool `yaml:"case_sensitive" env-default:"true"`
+	// Reserved words are added to the top level routes, which are always reserved.
+	Reserved      []string `yaml:"reserved"`
+	ProfanityList string   `yaml:"profanity_list"`
+}
+
+type Bulk struct {
+	// MaxItems is the largest batch POST /url/bulk accepts.
+	MaxItems int `yaml:"max_items" env-default:"500"`
+}
+
+type Preview struct {
+	// Countdown is how many seconds the interstitial page of a link waits
+	// before continuing, 0 waits for the continue button.
+	Countdown int `yaml:"countdown" env-default:"5"`
+}
+
+type Redirect struct {
+	// Type is the status code of redirects for links without their own,
+	// one of 301, 302, 307 and 308.
+	Type int `yaml:"type" env-default:"302"`
+	// CacheMaxAge lets browsers and proxies reuse redirects of links that
+	// send every visitor to the same place. Clicks answered from a cache are
+	// not recorded, 0 makes every click reach the service.
+	CacheMaxAge time.Duration `yaml:"cache_max_age" env-default:"0s"`
+}
+
+type LinkPassword struct {
+	// CookieSecret signs the cookies of unlocked links, a random secret is
+	// used when it is empty.
+	CookieSecret string        `yaml:"cookie_secret" env:"LINK_COOKIE_SECRET"`
+	CookieTTL    time.Duration `yaml:"cookie_ttl" env-default:"1h"`
+	// MaxAttempts wrong passwords lock a link for the rest of Window.
+	MaxAttempts int           `yaml:"max_attempts" env-default:"5"`
+	Window      time.Duration `yaml:"window" env-default:"15m"`
+}
+
+type Geo struct {
+	// Database is a MaxMind format .mmdb file, clicks get no location
+	// when it is empty.
+	Database  string `yaml:"database" env:"GEOIP_DATABASE"`
+	CacheSize int    `yaml:"cache_size" env-default:"10000"`
+}
+
+type URLPolicy struct {
+	// Schemes destinations may use.
+	Schemes []string `yaml:"schemes" env-default:"http,https"`
+	// AllowList and DenyList are files of domains, one per line or in hosts
+	// file format. Allowed domains are exempt from the other checks.
+	AllowList    string `yaml:"allow_list"`
+	DenyList     string `yaml:"deny_list"`
+	AllowPrivate bool   `yaml:"allow_private" env-default:"false"`
+	// ReloadInterval is how often the lists are checked for changes, links
+	// are checked again when they change.
+	ReloadInterval time.Duration `yaml:"reload_interval" env-default:"1m"`
+}
+
+type Health struct {
+	// Interval between two checks of all destinations, 0 turns checking off.
+	Interval    time.Duration `yaml:"interval" env-default:"1h"`
+	Timeout     time.Duration `yaml:"timeout" env-default:"10s"`
+	Concurrency int           `yaml:"concurrency" env-default:"4"`
+	// HostDelay is the pause between two requests to the same host.
+	HostDelay    time.Duration `yaml:"host_delay" env-default:"1s"`
+	MaxRedirects int           `yaml:"max_redirects" env-default:"5"`
+	// FailureThreshold failed checks in a row make a link broken.
+	FailureThreshold int `yaml:"failure_threshold" env-default:"3"`
+}
+
+type Clicks struct {
+	// BotSignatures is a file of user agent substrings, one per line, that
+	// mark bots in addition to the built in ones.
+	BotSignatures string `yaml:"bot_signatures"`
+	// VisitorSecret derives the daily salts of visitor hashes, a random one
+	// is used when it is empty, which resets unique counts on restart.
+	VisitorSecret string `yaml:"visitor_secret" env:"VISITOR_SECRET"`
+	// ExportWriteTimeout disconnects exports whose client stopped reading,
+	// exports are not bound by the timeout of the http server.
+	ExportWriteTimeout time.Duration `yaml:"export_write_timeout" env-default:"30s"`
+}
+
+type Privacy struct {
+	// IPMode is how visitor IPs are stored: raw, truncate or hash.
+	IPMode string `yaml:"ip_mode" env-default:"raw"`
+	// IPHashSecret keys the hashes of the hash mode, changing it keeps the
+	// clicks stored before from being found by IP.
+	IPHashSecret string `yaml:"ip_hash_secret" env:"IP_HASH_SECRET"`
+	// HonorDoNotTrack stores clicks of visitors sending DNT or Sec-GPC
+	// without their IP, visitor hash, user agent, referrer and language.
+	HonorDoNotTrack bool `yaml:"honor_do_not_track" env-default:"true"`
+}
+
+type Live struct {
+	// Buffer is how many clicks a live stream may fall behind before it is
+	// disconnected.
+	Buffer int `yaml:"buffer" env-default:"64"`
+	// Heartbeat is how often streams without clicks send a keep alive, so
+	// proxies don't close them.
+	Heartbeat time.Duration `yaml:"heartbeat" env-default:"15s"`
+	// WriteTimeout disconnects streams whose client stopped reading.
+	WriteTimeout time.Duration `yaml:"write_timeout" env-default:"10s"`
+}
+
+type Webhooks struct {
+	// MaxAttempts is how often a delivery is tried before it fails.
+	MaxAttempts int `yaml:"max_attempts" env-default:"8"`
+	// Backoff is the wait before the first retry, it doubles with every
+	// further one up to MaxBackoff.
+	Backoff    time.Duration `yaml:"backoff" env-default:"30s"`
+	MaxBackoff time.Duration `yaml:"max_backoff" env-default:"6h"`
+	Timeout    time.Duration `yaml:"timeout" env-default:"10s"`
+	// Concurrency is how many deliveries are sent at the same time.
+	Concurrency int `yaml:"concurrency" env-default:"4"`
+	// PollInterval is how often due retries are looked for.
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"10s"`
+}
+
+type Metrics struct {
+	// Address is where /metrics is served in the Prometheus format, apart
+	// from the API so it can stay private. Empty turns metrics off.
+	Address string `yaml:"address" env:"METRICS_ADDRESS"`
+}
+
 func MustLoad() *Config {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
@@ -36,5 +190,11 @@ func MustLoad() *Config {
 		log.Fatalf("cannot read config: %v", err)
 	}
 
+	switch cfg.Redirect.Type {
+	case 301, 302, 307, 308:
+	default:
+		log.Fatalf("redirect type must be 301, 302, 307 or 308, got %d", cfg.Redirect.Type)
+	}
+
 	return &cfg
 }
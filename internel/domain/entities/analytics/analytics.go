@@ -0,0 +1,79 @@
+package analytics
+
+import "time"
+
+// FilterDimensions are what clicks can be filtered by. The qr filter takes
+// "1" for visits from QR codes and "0" for the others.
+var FilterDimensions = []string{
+	"country", "browser", "os", "platform", "referrer", "referrer_path", "language", "qr",
+	"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content",
+}
+
+// Query selects the clicks of an analytics report.
+type Query struct {
+	Interval string
+	Location *time.Location
+	// Bounds are the starts of the buckets in Location followed by the end
+	// of the last one.
+	Bounds      []time.Time
+	Top         int
+	IncludeBots bool
+	// Filters keep the clicks with the given value for each dimension, see
+	// FilterDimensions.
+	Filters map[string]string
+}
+
+// From returns the start of the first bucket.
+func (q Query) From() time.Time {
+	return q.Bounds[0]
+}
+
+// To returns the end of the last bucket.
+func (q Query) To() time.Time {
+	return q.Bounds[len(q.Bounds)-1]
+}
+
+// Analytics are the clicks in a time range, bucketed by Interval and broken
+// down by where they came from and the visitor's language. Unique clicks count each visitor once per
+// day.
+type Analytics struct {
+	Interval     string      `json:"interval"`
+	Timezone     string      `json:"timezone"`
+	From         time.Time   `json:"from"`
+	To           time.Time   `json:"to"`
+	Clicks       int64       `json:"clicks"`
+	UniqueClicks int64       `json:"unique_clicks"`
+	QRClicks     int64       `json:"qr_clicks"`
+	Series       []Bucket    `json:"series"`
+	Countries    []Breakdown `json:"countries"`
+	Browsers     []Breakdown `json:"browsers"`
+	OS           []Breakdown `json:"os"`
+	Platforms    []Breakdown `json:"platforms"`
+	Referrers    []Breakdown `json:"referrers"`
+	// ReferrerPaths are the paths of referring pages, filter by referrer to
+	// get those of a single host.
+	ReferrerPaths []Breakdown `json:"referrer_paths"`
+	Languages     []Breakdown `json:"languages"`
+	UTMSources    []Breakdown `json:"utm_sources"`
+	UTMMediums    []Breakdown `json:"utm_mediums"`
+	UTMCampaigns  []Breakdown `json:"utm_campaigns"`
+	UTMTerms      []Breakdown `json:"utm_terms"`
+	UTMContents   []Breakdown `json:"utm_contents"`
+}
+
+// Bucket are the clicks of an interval starting at Start.
+type Bucket struct {
+	Start        time.Time `json:"start"`
+	Clicks       int64     `json:"clicks"`
+	UniqueClicks int64     `json:"unique_clicks"`
+}
+
+// Breakdown are the clicks with the same Value, an empty one stands for
+// clicks where it is unknown, e.g. direct visits for referrers.
+type Breakdown struct {
+	Value string `json:"value"`
+	// Name is set for countries, whose Value is the country code.
+	Name         string `json:"name,omitempty"`
+	Clicks       int64  `json:"clicks"`
+	UniqueClicks int64  `json:"unique_clicks"`
+}
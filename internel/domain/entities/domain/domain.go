@@ -0,0 +1,14 @@
+package domain
+
+type Domain struct {
+	ID          int64  `json:"id"`
+	Host        string `json:"host"`
+	UserId      int64  `json:"user_id"`
+	WorkspaceId int64  `json:"workspace_id,omitempty"`
+	FallbackUrl string `json:"fallback_url,omitempty"`
+	Created     string `json:"created,omitempty"`
+	// VerifyToken has to be published in a TXT record of the host before
+	// the domain serves links, see lib/domainverify.
+	VerifyToken string `json:"verify_token,omitempty"`
+	Verified    bool   `json:"verified"`
+}
@@ -1,8 +1,17 @@
 package redirectInfo
 
+import (
+	"time"
+	"url-shortner/internel/domain/entities/urlInfo"
+)
+
 type RedirectInfo struct {
-	Id          int64  `json:"id,omitempty"`
-	Ip          string `json:"ip"`
+	Id    int64  `json:"id,omitempty"`
+	UrlId int64  `json:"-"`
+	Alias string `json:"alias,omitempty"`
+	// Ip is stored as configured by the privacy mode, it is empty for
+	// visitors that opted out of tracking and hidden from non-admins.
+	Ip          string `json:"ip,omitempty"`
 	Os          string `json:"os"`
 	Platform    string `json:"platform"`
 	Browser     string `json:"browser"`
@@ -10,4 +19,35 @@ type RedirectInfo struct {
 	Country     string `json:"country,omitempty"`
 	City        string `json:"city,omitempty"`
 	CountryCode string `json:"countryCode,omitempty"`
+	// RuleId is the targeting rule that chose the destination, 0 for the
+	// link's default url.
+	RuleId int64 `json:"rule_id,omitempty"`
+	// VariantId is the variant of a split link the click was sent to.
+	VariantId int64 `json:"variant_id,omitempty"`
+	IsBot     bool  `json:"is_bot"`
+	// ReferrerHost is the host of the referring page, empty for direct visits.
+	ReferrerHost string `json:"referrer_host,omitempty"`
+	ReferrerPath string `json:"referrer_path,omitempty"`
+	// Language is the primary subtag of the visitor's preferred language.
+	Language string `json:"language,omitempty"`
+	// ViaQR is set for visits from the QR code of the link.
+	ViaQR bool `json:"via_qr"`
+	// UTM are the UTM parameters of the short link the visitor opened.
+	UTM urlInfo.UTM `json:"utm"`
+	// VisitorHash identifies the visitor for the day of the click only.
+	VisitorHash string `json:"-"`
+}
+
+// Filter selects clicks, its zero value selects all of them.
+type Filter struct {
+	UrlId       int64
+	WorkspaceId int64
+	// From and To bound the time of the clicks, To is exclusive.
+	From time.Time
+	To   time.Time
+	// ExcludeBots leaves out clicks of bots.
+	ExcludeBots bool
+	// Dimensions keep the clicks with the given value for each dimension,
+	// see analytics.FilterDimensions.
+	Dimensions map[string]string
 }
@@ -0,0 +1,15 @@
+package rule
+
+// Rule sends visitors matching all of its set conditions to URL instead of
+// the link's default url. Rules of a link are tried in order.
+type Rule struct {
+	Id      int64  `json:"id,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Device  string `json:"device,omitempty"`
+	Browser string `json:"browser,omitempty"`
+	// Countries are ISO 3166-1 alpha-2 codes, the rule matches any of them.
+	Countries []string `json:"countries,omitempty"`
+	// Continents are the two letter codes AF, AN, AS, EU, NA, OC and SA.
+	Continents []string `json:"continents,omitempty"`
+	URL        string   `json:"url" validate:"required,url"`
+}
@@ -0,0 +1,15 @@
+package urlInfo
+
+// Health is the outcome of the last check of a link's destination.
+type Health struct {
+	// Status is the status code of the last response, 0 when the request
+	// failed.
+	Status    int   `json:"status"`
+	LatencyMs int64 `json:"latency_ms"`
+	// RedirectChain are the urls the destination redirected through.
+	RedirectChain []string `json:"redirect_chain,omitempty"`
+	Error         string   `json:"error,omitempty"`
+	// Failures counts the failed checks since the last successful one.
+	Failures  int    `json:"failures"`
+	CheckedAt string `json:"checked_at"`
+}
@@ -0,0 +1,42 @@
+package urlInfo
+
+// Stats are the clicks of a single link. Unique clicks count each visitor
+// once per day.
+type Stats struct {
+	Alias        string `json:"alias"`
+	Clicks       int64  `json:"clicks"`
+	UniqueClicks int64  `json:"unique_clicks"`
+	// BotClicks are counted whether or not bots are included in the stats.
+	BotClicks int64          `json:"bot_clicks"`
+	Rules     []RuleStats    `json:"rules"`
+	Variants  []VariantStats `json:"variants"`
+	Countries []CountryStats `json:"countries"`
+}
+
+// RuleStats are the clicks whose destination was chosen by a rule, RuleId 0
+// stands for the link's default url.
+type RuleStats struct {
+	RuleId int64 `json:"rule_id"`
+	// Url is empty for rules that were removed since.
+	Url          string `json:"url"`
+	Clicks       int64  `json:"clicks"`
+	UniqueClicks int64  `json:"unique_clicks"`
+}
+
+// VariantStats are the clicks sent to a variant of a split link.
+type VariantStats struct {
+	VariantId int64 `json:"variant_id"`
+	// Url is empty for variants that were removed since.
+	Url          string `json:"url"`
+	Clicks       int64  `json:"clicks"`
+	UniqueClicks int64  `json:"unique_clicks"`
+}
+
+// CountryStats are the clicks from a country, an empty code stands for
+// clicks without a known location.
+type CountryStats struct {
+	CountryCode  string `json:"country_code"`
+	Country      string `json:"country"`
+	Clicks       int64  `json:"clicks"`
+	UniqueClicks int64  `json:"unique_clicks"`
+}
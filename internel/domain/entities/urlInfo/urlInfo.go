@@ -1,9 +1,52 @@
 package urlInfo
 
-import "url-shortner/internel/domain/entities/user"
+import (
+	"url-shortner/internel/domain/entities/rule"
+	"url-shortner/internel/domain/entities/user"
+	"url-shortner/internel/domain/entities/variant"
+)
 
 type UrlInfo struct {
-	Alias string    `json:"alias"`
-	Url   string    `json:"url"`
-	User  user.User `json:"user"`
+	Id          int64     `json:"id,omitempty"`
+	Alias       string    `json:"alias"`
+	Url         string    `json:"url"`
+	User        user.User `json:"user"`
+	WorkspaceId int64     `json:"workspace_id,omitempty"`
+	DomainId    int64     `json:"-"`
+	Domain      string    `json:"domain,omitempty"`
+	ShortUrl    string    `json:"short_url,omitempty"`
+	Created     string    `json:"created_at,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	// Interstitial links show the preview page before redirecting.
+	Interstitial bool `json:"interstitial,omitempty"`
+	// PasswordHash is the bcrypt hash of the link's password, empty for
+	// links anyone can open.
+	PasswordHash string `json:"-"`
+	// Rules pick another destination depending on the visitor.
+	Rules []rule.Rule `json:"rules,omitempty"`
+	// Variants split visitors no rule matched between weighted destinations.
+	Variants []variant.Variant `json:"variants,omitempty"`
+	// StickyVariants send returning visitors to the variant they saw before.
+	StickyVariants bool `json:"sticky_variants,omitempty"`
+	// QueryMode decides what happens to the query string of the short link,
+	// see the destination package.
+	QueryMode string `json:"query_mode,omitempty"`
+	// UTM parameters are added to the destination when redirecting.
+	UTM *UTM `json:"utm,omitempty"`
+	// RedirectType is the status code of the redirect, one of 301, 302, 307
+	// and 308, 0 uses the default of the service.
+	RedirectType int `json:"redirect_type,omitempty"`
+	// DisabledReason is why the link stopped redirecting, empty for links
+	// that work.
+	DisabledReason string `json:"disabled_reason,omitempty"`
+	// Health is only set in the list of broken links.
+	Health *Health `json:"health,omitempty"`
+}
+
+type UTM struct {
+	Source   string `json:"source,omitempty" validate:"max=200"`
+	Medium   string `json:"medium,omitempty" validate:"max=200"`
+	Campaign string `json:"campaign,omitempty" validate:"max=200"`
+	Term     string `json:"term,omitempty" validate:"max=200"`
+	Content  string `json:"content,omitempty" validate:"max=200"`
 }
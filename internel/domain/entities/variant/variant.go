@@ -0,0 +1,9 @@
+package variant
+
+// Variant is one of the destinations a link splits its visitors between,
+// chosen with a probability proportional to Weight.
+type Variant struct {
+	Id     int64  `json:"id,omitempty"`
+	URL    string `json:"url" validate:"required,url"`
+	Weight int    `json:"weight" validate:"min=1,max=1000"`
+}
@@ -0,0 +1,83 @@
+package webhook
+
+// Event names what happened, webhooks subscribe to a list of them.
+type Event string
+
+const (
+	EventLinkCreated Event = "link.created"
+	EventLinkUpdated Event = "link.updated"
+	EventLinkDeleted Event = "link.deleted"
+	EventLinkClicked Event = "link.clicked"
+	// EventLinkExpired is reserved for links with an expiry date, which
+	// links don't have yet, so webhooks can't subscribe to it.
+	EventLinkExpired Event = "link.expired"
+	// EventPing is only sent by the test endpoint, it can't be subscribed to.
+	EventPing Event = "ping"
+)
+
+// Events are the events webhooks can subscribe to.
+var Events = []Event{EventLinkCreated, EventLinkUpdated, EventLinkDeleted, EventLinkClicked}
+
+// Valid reports whether webhooks can subscribe to e.
+func (e Event) Valid() bool {
+	for _, event := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook gets the events of the personal links of its user, or of the links
+// of its workspace.
+type Webhook struct {
+	ID          int64  `json:"id"`
+	UserId      int64  `json:"user_id"`
+	WorkspaceId int64  `json:"workspace_id,omitempty"`
+	URL         string `json:"url"`
+	// Secret signs the deliveries, it is only shown when the webhook is
+	// created.
+	Secret  string  `json:"secret,omitempty"`
+	Events  []Event `json:"events"`
+	Created string  `json:"created,omitempty"`
+}
+
+// Owner is whose links an event is about, webhooks of the workspace get the
+// events of workspace links and those of the user the events of personal ones.
+type Owner struct {
+	UserId      int64
+	WorkspaceId int64
+}
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	// StatusFailed deliveries ran out of attempts.
+	StatusFailed Status = "failed"
+)
+
+// Delivery is an event sent, or to be sent, to a webhook.
+type Delivery struct {
+	ID        int64  `json:"id"`
+	WebhookId int64  `json:"webhook_id"`
+	Event     Event  `json:"event"`
+	Payload   string `json:"payload"`
+	Status    Status `json:"status"`
+	Attempts  int    `json:"attempts"`
+	// NextAttempt is when a pending delivery is tried again.
+	NextAttempt string `json:"next_attempt,omitempty"`
+	// StatusCode and Error are the outcome of the last attempt.
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Created    string `json:"created,omitempty"`
+	Delivered  string `json:"delivered,omitempty"`
+}
+
+// Pending is a delivery that is due, with the url and secret of its webhook.
+type Pending struct {
+	Delivery
+	URL    string
+	Secret string
+}
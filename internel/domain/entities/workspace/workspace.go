@@ -0,0 +1,70 @@
+package workspace
+
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleAdmin  Role = "admin"
+	RoleEditor Role = "editor"
+	RoleViewer Role = "viewer"
+)
+
+var roleRank = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+// Valid reports whether r is one of the known roles.
+func (r Role) Valid() bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+// AtLeast reports whether r grants everything that min grants.
+func (r Role) AtLeast(min Role) bool {
+	return r.Valid() && roleRank[r] >= roleRank[min]
+}
+
+type Workspace struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Role    Role   `json:"role,omitempty"`
+	Created string `json:"created,omitempty"`
+}
+
+type Member struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Role     Role   `json:"role"`
+	Created  string `json:"created,omitempty"`
+}
+
+type Invitation struct {
+	ID          int64  `json:"id"`
+	WorkspaceID int64  `json:"workspace_id"`
+	UserID      int64  `json:"user_id"`
+	Role        Role   `json:"role"`
+	Token       string `json:"token,omitempty"`
+	InvitedBy   int64  `json:"invited_by"`
+	Created     string `json:"created,omitempty"`
+	Expires     string `json:"expires"`
+}
+
+// Stats count unique clicks once per visitor and day.
+type Stats struct {
+	WorkspaceID  int64       `json:"workspace_id"`
+	Links        int64       `json:"links"`
+	Clicks       int64       `json:"clicks"`
+	UniqueClicks int64       `json:"unique_clicks"`
+	Members      int64       `json:"members"`
+	TopLinks     []LinkStats `json:"top_links"`
+}
+
+type LinkStats struct {
+	Alias        string `json:"alias"`
+	Url          string `json:"url"`
+	Clicks       int64  `json:"clicks"`
+	UniqueClicks int64  `json:"unique_clicks"`
+}
@@ -0,0 +1,52 @@
+package linkExport
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/linkio"
+	"url-shortner/internel/lib/logger/sl"
+)
+
+// New streams every link in the ?format= given as a file download.
+func New(log *slog.Logger, source linkio.Source, baseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.linkExport.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = linkio.FormatCSV
+		}
+
+		writer, err := linkio.NewWriter(format, w, baseURL)
+		if errors.Is(err, linkio.ErrUnknownFormat) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		extension := format
+		if format == linkio.FormatYOURLS || format == linkio.FormatBitly {
+			extension = format + ".csv"
+		}
+		w.Header().Set("Content-Type", linkio.ContentType(format))
+		w.Header().Set("Content-Disposition", `attachment; filename="links.`+extension+`"`)
+
+		// the status is sent with the first row, so later errors can only be logged
+		count, err := linkio.Export(source, writer)
+		if err != nil {
+			log.Error("failed to export links", sl.Err(err))
+			return
+		}
+
+		log.Info("links exported", slog.Int("count", count))
+	}
+}
@@ -0,0 +1,106 @@
+package linkImport
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/linkio"
+	"url-shortner/internel/lib/logger/sl"
+)
+
+type Response struct {
+	response.Response
+	Report linkio.Report `json:"report"`
+}
+
+// New imports links from the request body, or its "file" form field, in the
+// ?format= given. Records without a known owner are assigned to the admin
+// running the import.
+func New(log *slog.Logger, store linkio.Store, urlPolicy linkio.URLPolicy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.linkImport.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		dryRun := false
+		if value := query.Get("dry_run"); value != "" {
+			var err error
+			dryRun, err = strconv.ParseBool(value)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error("dry_run must be a boolean"))
+				return
+			}
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		var body io.Reader = r.Body
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error("file field is required"))
+				return
+			}
+			defer file.Close()
+			body = file
+		}
+
+		format := query.Get("format")
+		if format == "" {
+			format = linkio.FormatCSV
+		}
+
+		reader, err := linkio.NewReader(format, body)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		report, err := linkio.Import(store, reader, linkio.ImportOptions{
+			Conflict:     query.Get("conflict"),
+			DryRun:       dryRun,
+			DefaultOwner: userId,
+			URLPolicy:    urlPolicy,
+		})
+		if errors.Is(err, linkio.ErrUnknownConflict) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(linkio.ErrUnknownConflict.Error()))
+			return
+		}
+		if err != nil {
+			log.Error("failed to import links", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to import links"))
+			return
+		}
+
+		log.Info("links imported", slog.Bool("dry_run", dryRun), slog.Int("total", report.Total),
+			slog.Int("failed", report.Failed))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Report:   report,
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package all
+
+import (
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+)
+
+type Response struct {
+	response.Response
+	Domains []domain.Domain `json:"domains"`
+}
+
+type DomainRepository interface {
+	GetUserDomains(userId int64) ([]domain.Domain, error)
+}
+
+func New(log *slog.Logger, repository DomainRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domain.all.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		domains, err := repository.GetUserDomains(userId)
+		if err != nil {
+			log.Error("Failed to get domains", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		responseOK(w, r, domains)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, domains []domain.Domain) {
+	render.JSON(w, r, Response{
+		Response: response.OK(),
+		Domains:  domains,
+	})
+}
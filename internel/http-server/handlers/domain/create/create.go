@@ -0,0 +1,152 @@
+package create
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/domainverify"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/random"
+	"url-shortner/internel/lib/shorturl"
+	"url-shortner/internel/storage"
+)
+
+type Request struct {
+	Host        string `json:"host" validate:"required,hostname_rfc1123"`
+	WorkspaceId int64  `json:"workspace_id,omitempty"`
+	FallbackUrl string `json:"fallback_url,omitempty" validate:"omitempty,url"`
+}
+
+const tokenSize = 32
+
+type Response struct {
+	response.Response
+	Domain domain.Domain `json:"domain"`
+	// Verification is the TXT record to publish before calling
+	// POST /domains/{id}/verify.
+	Verification domainverify.Record `json:"verification"`
+}
+
+type DomainSaver interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	SaveDomain(domain *domain.Domain) (int64, error)
+}
+
+// URLPolicy decides which destinations fallback urls may have.
+type URLPolicy interface {
+	Check(rawURL string) error
+}
+
+// New adds an unverified domain, it serves links once it was verified.
+func New(log *slog.Logger, saver DomainSaver, urlPolicy URLPolicy, baseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domain.create.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to render.JSON", sl.Err(err))
+			render.JSON(w, r, response.Error("failed parse JSON body"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		if err := domainverify.CheckHost(req.Host, baseURL); err != nil {
+			log.Info("reserved domain rejected", slog.String("host", req.Host))
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		if req.FallbackUrl != "" {
+			if err := urlPolicy.Check(req.FallbackUrl); err != nil {
+				log.Info("fallback url rejected by policy", slog.String("url", req.FallbackUrl), sl.Err(err))
+				render.JSON(w, r, response.Error("fallback url rejected: "+err.Error()))
+				return
+			}
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		token, err := random.NewToken(tokenSize)
+		if err != nil {
+			log.Error("failed to generate verify token", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		d := domain.Domain{
+			Host:        shorturl.Host(req.Host),
+			UserId:      userId,
+			WorkspaceId: req.WorkspaceId,
+			FallbackUrl: req.FallbackUrl,
+			VerifyToken: token,
+		}
+
+		err = access.CheckDomain(saver, d, userId)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to workspace"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to check workspace access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		d.ID, err = saver.SaveDomain(&d)
+		if errors.Is(err, storage.ErrDomainExists) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("domain already exists"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to save domain", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		log.Info("domain added", slog.Int64("id", d.ID), slog.String("host", d.Host))
+
+		responseOK(w, r, d)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, d domain.Domain) {
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, Response{
+		Response:     response.OK(),
+		Domain:       d,
+		Verification: domainverify.RecordFor(d.Host, d.VerifyToken),
+	})
+}
@@ -0,0 +1,102 @@
+package delete
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/storage"
+)
+
+type Response struct {
+	response.Response
+}
+
+type DomainDeleter interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	GetDomain(id int64) (domain.Domain, error)
+	DeleteDomain(id int64) error
+}
+
+func New(log *slog.Logger, deleter DomainDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domain.delete.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, response.Error("Invalid domain id"))
+			return
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		d, err := deleter.GetDomain(id)
+		if errors.Is(err, storage.ErrDomainNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("domain not found"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to get domain", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		err = access.CheckDomain(deleter, d, userId)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to domain"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to check domain access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		err = deleter.DeleteDomain(id)
+		if errors.Is(err, storage.ErrDomainInUse) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("domain still has links"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to delete domain", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		log.Info("domain deleted", slog.Int64("id", id))
+
+		responseOK(w, r)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, Response{
+		Response: response.OK(),
+	})
+}
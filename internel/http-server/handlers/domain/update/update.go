@@ -0,0 +1,131 @@
+package update
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/storage"
+)
+
+type Request struct {
+	FallbackUrl string `json:"fallback_url" validate:"omitempty,url"`
+}
+
+type Response struct {
+	response.Response
+}
+
+type DomainUpdater interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	GetDomain(id int64) (domain.Domain, error)
+	UpdateDomainFallback(id int64, fallbackUrl string) error
+}
+
+// URLPolicy decides which destinations fallback urls may have.
+type URLPolicy interface {
+	Check(rawURL string) error
+}
+
+func New(log *slog.Logger, updater DomainUpdater, urlPolicy URLPolicy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domain.update.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, response.Error("Invalid domain id"))
+			return
+		}
+
+		var req Request
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to render.JSON", sl.Err(err))
+			render.JSON(w, r, response.Error("failed parse JSON body"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		if req.FallbackUrl != "" {
+			if err := urlPolicy.Check(req.FallbackUrl); err != nil {
+				log.Info("fallback url rejected by policy", slog.String("url", req.FallbackUrl), sl.Err(err))
+				render.JSON(w, r, response.Error("fallback url rejected: "+err.Error()))
+				return
+			}
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		d, err := updater.GetDomain(id)
+		if errors.Is(err, storage.ErrDomainNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("domain not found"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to get domain", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		err = access.CheckDomain(updater, d, userId)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to domain"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to check domain access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		if err := updater.UpdateDomainFallback(id, req.FallbackUrl); err != nil {
+			log.Error("Failed to update domain", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		log.Info("domain updated", slog.Int64("id", id))
+
+		responseOK(w, r)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, Response{
+		Response: response.OK(),
+	})
+}
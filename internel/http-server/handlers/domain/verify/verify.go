@@ -0,0 +1,139 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/domainverify"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/storage"
+)
+
+type Response struct {
+	response.Response
+	Domain domain.Domain `json:"domain"`
+}
+
+type DomainVerifier interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	GetDomain(id int64) (domain.Domain, error)
+	VerifyDomain(id int64) error
+}
+
+// RecordChecker looks for the TXT record of a domain.
+type RecordChecker interface {
+	Verify(ctx context.Context, host, token string) error
+}
+
+// New activates a domain once its host publishes the TXT record of its
+// verify token.
+func New(log *slog.Logger, verifier DomainVerifier, records RecordChecker, baseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domain.verify.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, response.Error("Invalid domain id"))
+			return
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		d, err := verifier.GetDomain(id)
+		if errors.Is(err, storage.ErrDomainNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("domain not found"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to get domain", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		err = access.CheckDomain(verifier, d, userId)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to domain"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to check domain access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		if d.Verified {
+			responseOK(w, r, d)
+			return
+		}
+
+		// the base url may have changed since the domain was added
+		if err := domainverify.CheckHost(d.Host, baseURL); err != nil {
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		err = records.Verify(r.Context(), d.Host, d.VerifyToken)
+		if errors.Is(err, domainverify.ErrNotVerified) {
+			record := domainverify.RecordFor(d.Host, d.VerifyToken)
+			render.Status(r, http.StatusUnprocessableEntity)
+			render.JSON(w, r, response.Error("TXT record "+record.Name+" with value "+record.Value+" not found"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to look up verification record", sl.Err(err))
+			render.Status(r, http.StatusBadGateway)
+			render.JSON(w, r, response.Error("failed to look up verification record"))
+			return
+		}
+
+		err = verifier.VerifyDomain(id)
+		if errors.Is(err, storage.ErrDomainExists) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("domain is verified by someone else"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to verify domain", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		log.Info("domain verified", slog.Int64("id", id), slog.String("host", d.Host))
+
+		d.Verified = true
+		responseOK(w, r, d)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, d domain.Domain) {
+	render.JSON(w, r, Response{
+		Response: response.OK(),
+		Domain:   d,
+	})
+}
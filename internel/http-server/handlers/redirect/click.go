@@ -0,0 +1,94 @@
+package redirect
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"url-shortner/internel/domain/entities/redirectInfo"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/lib/shorturl"
+)
+
+// maxPathLength bounds the referrer paths stored with clicks.
+const maxPathLength = 255
+
+// clickQuery returns the query of the short link without the QR code marker
+// and whether it was there.
+func clickQuery(r *http.Request) (url.Values, bool) {
+	query := r.URL.Query()
+	viaQR := query.Has(shorturl.QRParam)
+	query.Del(shorturl.QRParam)
+
+	return query, viaQR
+}
+
+// referrer returns the host and path of the Referer of r, empty when there
+// is none. Query strings are left out as they may hold personal data.
+func referrer(r *http.Request) (string, string) {
+	u, err := url.Parse(r.Referer())
+	if err != nil || u.Host == "" {
+		return "", ""
+	}
+
+	path := u.EscapedPath()
+	if len(path) > maxPathLength {
+		path = path[:maxPathLength]
+	}
+
+	return strings.ToLower(u.Hostname()), path
+}
+
+// language returns the primary subtag of the language the visitor prefers
+// most in an Accept-Language header, e.g. "de" for "de-CH, en;q=0.8".
+func language(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+
+		primary, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+		if primary == "" || primary == "*" || len(primary) > 8 || q <= bestQ {
+			continue
+		}
+		best, bestQ = strings.ToLower(primary), q
+	}
+
+	return best
+}
+
+// clickUTM returns the UTM parameters a visitor arrived with.
+func clickUTM(query url.Values) urlInfo.UTM {
+	return urlInfo.UTM{
+		Source:   truncate(query.Get("utm_source"), 200),
+		Medium:   truncate(query.Get("utm_medium"), 200),
+		Campaign: truncate(query.Get("utm_campaign"), 200),
+		Term:     truncate(query.Get("utm_term"), 200),
+		Content:  truncate(query.Get("utm_content"), 200),
+	}
+}
+
+// untrack drops what could tell the visitor of click apart from others. The
+// click still counts, with its country, destination and campaign.
+func untrack(click *redirectInfo.RedirectInfo) {
+	click.Ip = ""
+	click.VisitorHash = ""
+	click.City = ""
+	click.Os, click.Platform, click.Browser = "", "", ""
+	click.ReferrerHost, click.ReferrerPath = "", ""
+	click.Language = ""
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+
+	return s
+}
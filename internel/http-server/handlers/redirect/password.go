@@ -0,0 +1,102 @@
+package redirect
+
+import (
+	_ "embed"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"html/template"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/lib/auth/hash"
+	"url-shortner/internel/lib/logger/sl"
+)
+
+// LinkUnlocker remembers which password protected links a visitor opened.
+type LinkUnlocker interface {
+	Unlocked(r *http.Request, link urlInfo.UrlInfo) bool
+	Unlock(w http.ResponseWriter, r *http.Request, link urlInfo.UrlInfo)
+}
+
+// Throttle limits wrong passwords per link.
+type Throttle interface {
+	Allow(key string) (bool, time.Duration)
+	Fail(key string)
+	Reset(key string)
+}
+
+//go:embed password.html
+var passwordHTML string
+
+var passwordTemplate = template.Must(template.New("password").Parse(passwordHTML))
+
+type passwordPage struct {
+	ShortURL string
+	Error    string
+}
+
+// NewUnlock checks the password posted from the password form and sends the
+// visitor back to the page they came from, now with the unlock cookie.
+func NewUnlock(log *slog.Logger, urlGetter URLGetter, aliases AliasNormalizer, unlocker LinkUnlocker, throttle Throttle) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.redirect.NewUnlock"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := aliases.Normalize(strings.TrimSuffix(chi.URLParam(r, "alias"), "+"))
+		link, ok := lookup(log, urlGetter, w, r, alias, false)
+		if !ok {
+			return
+		}
+
+		// collapsing leading slashes keeps the target on this host
+		target := "/" + strings.TrimLeft(r.URL.EscapedPath(), "/")
+
+		if link.PasswordHash == "" || unlocker.Unlocked(r, link) {
+			http.Redirect(w, r, target, http.StatusSeeOther)
+			return
+		}
+
+		key := strconv.FormatInt(link.Id, 10)
+		if ok, wait := throttle.Allow(key); !ok {
+			log.Warn("password attempts throttled", slog.String("alias", link.Alias))
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			renderPassword(log, w, r, link, http.StatusTooManyRequests,
+				fmt.Sprintf("Too many attempts, try again in %s.", wait.Round(time.Second)))
+			return
+		}
+
+		if !hash.CheckPasswordHash(r.PostFormValue("password"), link.PasswordHash) {
+			throttle.Fail(key)
+			log.Info("wrong link password", slog.String("alias", link.Alias))
+			renderPassword(log, w, r, link, http.StatusUnauthorized, "Wrong password.")
+			return
+		}
+
+		throttle.Reset(key)
+		unlocker.Unlock(w, r, link)
+
+		http.Redirect(w, r, target, http.StatusSeeOther)
+	}
+}
+
+// renderPassword writes the password form of link, it doesn't show anything
+// about where the link goes.
+func renderPassword(log *slog.Logger, w http.ResponseWriter, r *http.Request, link urlInfo.UrlInfo, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	err := passwordTemplate.Execute(w, passwordPage{ShortURL: r.Host + "/" + link.Alias, Error: message})
+	if err != nil {
+		log.Error("failed to render password form", sl.Err(err))
+	}
+}
@@ -0,0 +1,88 @@
+package redirect
+
+import (
+	_ "embed"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/mssola/useragent"
+	"html"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/lib/destination"
+	"url-shortner/internel/lib/logger/sl"
+)
+
+//go:embed preview.html
+var previewHTML string
+
+var previewTemplate = template.Must(template.New("preview").Parse(previewHTML))
+
+type previewPage struct {
+	ShortURL  string
+	URL       string
+	Host      string
+	Title     string
+	Owner     string
+	Countdown int
+	// Refresh is the content attribute of the meta refresh tag, it is only
+	// set for http(s) destinations.
+	Refresh template.HTMLAttr
+}
+
+// NewPreview shows where a short link goes instead of redirecting,
+// no click is counted.
+func NewPreview(log *slog.Logger, urlGetter URLGetter, aliases AliasNormalizer, unlocker LinkUnlocker, geoResolver GeoResolver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.redirect.NewPreview"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		link, ok := lookup(log, urlGetter, w, r, aliases.Normalize(chi.URLParam(r, "alias")), false)
+		if !ok {
+			return
+		}
+		if link.PasswordHash != "" && !unlocker.Unlocked(r, link) {
+			renderPassword(log, w, r, link, http.StatusUnauthorized, "")
+			return
+		}
+
+		target(&link, useragent.New(r.UserAgent()), locate(log, geoResolver, getIP(r)))
+		query, _ := clickQuery(r)
+		link.Url = destination.Build(link.Url, link.QueryMode, query, link.UTM)
+		renderPreview(log, w, r, link, 0)
+	}
+}
+
+// renderPreview writes the preview page of link, a positive countdown makes
+// the page continue to the destination after that many seconds.
+func renderPreview(log *slog.Logger, w http.ResponseWriter, r *http.Request, link urlInfo.UrlInfo, countdown int) {
+	page := previewPage{
+		ShortURL: r.Host + "/" + link.Alias,
+		URL:      link.Url,
+		Title:    link.Title,
+		Owner:    link.User.Username,
+	}
+
+	if u, err := url.Parse(link.Url); err == nil {
+		page.Host = u.Hostname()
+		if countdown > 0 && (u.Scheme == "http" || u.Scheme == "https") {
+			page.Countdown = countdown
+			page.Refresh = template.HTMLAttr(fmt.Sprintf(`content="%d;url=%s"`, countdown, html.EscapeString(link.Url)))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	if err := previewTemplate.Execute(w, page); err != nil {
+		log.Error("failed to render preview", sl.Err(err))
+	}
+}
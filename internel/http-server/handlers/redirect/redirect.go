@@ -7,24 +7,99 @@ import (
 	"github.com/go-chi/render"
 	"github.com/mssola/useragent"
 	"log/slog"
+	"math/rand"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
+	"url-shortner/internel/domain/entities/domain"
 	"url-shortner/internel/domain/entities/redirectInfo"
+	"url-shortner/internel/domain/entities/urlInfo"
 	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/clickstream"
+	"url-shortner/internel/lib/destination"
+	"url-shortner/internel/lib/geo"
 	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/privacy"
+	"url-shortner/internel/lib/shorturl"
+	"url-shortner/internel/lib/split"
+	"url-shortner/internel/lib/targeting"
 	"url-shortner/internel/storage"
 )
 
+// stickyVariantAge is how long visitors of sticky split links keep their
+// variant.
+const stickyVariantAge = 30 * 24 * time.Hour
+
+// Options are the redirect settings of the service.
+type Options struct {
+	// Countdown is how many seconds interstitial pages wait before continuing.
+	Countdown int
+	// Status is the redirect status code of links without a redirect type.
+	Status int
+	// CacheMaxAge lets caches reuse redirects that are the same for every
+	// visitor, 0 keeps them from being cached.
+	CacheMaxAge time.Duration
+	// HonorDoNotTrack stores clicks of visitors sending DNT or Sec-GPC
+	// without anything that tells them apart.
+	HonorDoNotTrack bool
+}
+
 // URLGetter is an interface for getting url by alias.
 //
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
 type URLGetter interface {
-	GetURL(alias string) (string, error)
+	GetDomainByHost(host string) (domain.Domain, error)
+	GetURL(domainId int64, alias string) (urlInfo.UrlInfo, error)
 	SaveRedirectInfo(redirectInfo *redirectInfo.RedirectInfo) error
 }
 
-func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
+// GeoResolver finds where a visitor is from their IP address.
+type GeoResolver interface {
+	Resolve(ip string) (geo.Location, error)
+}
+
+// BotDetector tells clicks of bots from those of people.
+type BotDetector interface {
+	IsBot(ua *useragent.UserAgent, raw string) bool
+}
+
+// VisitorHasher identifies visitors for unique click counts without storing
+// who they are.
+type VisitorHasher interface {
+	Hash(ip, userAgent string, now time.Time) string
+}
+
+// IPAnonymizer turns visitor IPs into what is stored with clicks.
+type IPAnonymizer interface {
+	IP(ip string) string
+}
+
+// ClickPublisher passes clicks on to live streams as they happen.
+type ClickPublisher interface {
+	Publish(click clickstream.Click)
+}
+
+// ClickNotifier tells webhooks about clicks.
+type ClickNotifier interface {
+	NotifyClick(link urlInfo.UrlInfo, click clickstream.Click)
+}
+
+// AliasNormalizer maps an alias as typed by a visitor to its stored form.
+type AliasNormalizer interface {
+	Normalize(alias string) string
+}
+
+// New redirects to the link's url with its redirect type. Links with the
+// interstitial flag show the preview page with a countdown instead, an alias
+// ending with + always shows the preview without counting a click. Password
+// protected links show the password form until they are unlocked. HEAD
+// requests get the same response but are not counted as clicks, clicks of
+// bots are stored flagged as such. Stored clicks are published to clicks and
+// sent to webhooks through notifier.
+func New(log *slog.Logger, urlGetter URLGetter, aliases AliasNormalizer, unlocker LinkUnlocker, geoResolver GeoResolver,
+	bots BotDetector, visitors VisitorHasher, ips IPAnonymizer, clicks ClickPublisher, notifier ClickNotifier, opts Options) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.redirect.New"
 
@@ -34,11 +109,31 @@ func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
 		)
 
 		alias := chi.URLParam(r, "alias")
-		if alias == "" {
-			log.Info("alias is empty")
+		if strings.HasSuffix(alias, "+") {
+			link, ok := lookup(log, urlGetter, w, r, aliases.Normalize(strings.TrimSuffix(alias, "+")), false)
+			if !ok {
+				return
+			}
+			if link.PasswordHash != "" && !unlocker.Unlocked(r, link) {
+				renderPassword(log, w, r, link, http.StatusUnauthorized, "")
+				return
+			}
+
+			target(&link, useragent.New(r.UserAgent()), locate(log, geoResolver, getIP(r)))
+			query, _ := clickQuery(r)
+			link.Url = destination.Build(link.Url, link.QueryMode, query, link.UTM)
+			renderPreview(log, w, r, link, 0)
 
-			render.JSON(w, r, response.Error("invalid request"))
+			return
+		}
 
+		link, ok := lookup(log, urlGetter, w, r, aliases.Normalize(alias), true)
+		if !ok {
+			return
+		}
+
+		if link.PasswordHash != "" && !unlocker.Unlocked(r, link) {
+			renderPassword(log, w, r, link, http.StatusUnauthorized, "")
 			return
 		}
 
@@ -47,41 +142,198 @@ func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
 		name, version := ua.Browser()
 		browser := name + " " + version
 		redirectInfoEntity := &redirectInfo.RedirectInfo{
+			UrlId:    link.Id,
+			Alias:    link.Alias,
 			Ip:       getIP(r),
 			Os:       ua.OS(),
 			Platform: ua.Platform(),
 			Browser:  browser,
+			IsBot:    bots.IsBot(ua, userAgentString),
 		}
-		err := urlGetter.SaveRedirectInfo(redirectInfoEntity)
-		if err != nil {
-			log.Error("Failed to save redirect info", sl.Err(err))
+		query, viaQR := clickQuery(r)
+		redirectInfoEntity.ReferrerHost, redirectInfoEntity.ReferrerPath = referrer(r)
+		redirectInfoEntity.Language = language(r.Header.Get("Accept-Language"))
+		redirectInfoEntity.ViaQR = viaQR
+		redirectInfoEntity.UTM = clickUTM(query)
+		redirectInfoEntity.VisitorHash = visitors.Hash(redirectInfoEntity.Ip, userAgentString, time.Now())
 
-			render.JSON(w, r, response.Error("internal error"))
+		location := locate(log, geoResolver, redirectInfoEntity.Ip)
+		redirectInfoEntity.CountryCode = location.CountryCode
+		redirectInfoEntity.Country = location.Country
+		redirectInfoEntity.City = location.City
+		redirectInfoEntity.RuleId = target(&link, ua, location)
+		if redirectInfoEntity.RuleId == 0 && len(link.Variants) > 0 {
+			redirectInfoEntity.VariantId = pickVariant(w, r, &link)
+		}
+		link.Url = destination.Build(link.Url, link.QueryMode, query, link.UTM)
 
-			return
+		// the raw ip was needed for the visitor hash and the location
+		redirectInfoEntity.Ip = ips.IP(redirectInfoEntity.Ip)
+		if opts.HonorDoNotTrack && privacy.OptedOut(r) {
+			untrack(redirectInfoEntity)
 		}
 
-		resURL, err := urlGetter.GetURL(alias)
-		if errors.Is(err, storage.ErrURLNotFound) {
-			log.Info("url not found", "alias", alias)
+		if r.Method != http.MethodHead {
+			err := urlGetter.SaveRedirectInfo(redirectInfoEntity)
+			if err != nil {
+				log.Error("Failed to save redirect info", sl.Err(err))
 
-			render.JSON(w, r, response.Error("not found"))
+				render.JSON(w, r, response.Error("internal error"))
 
-			return
+				return
+			}
+			click := clickstream.FromRedirectInfo(redirectInfoEntity, link.WorkspaceId, time.Now())
+			clicks.Publish(click)
+			notifier.NotifyClick(link, click)
 		}
-		if err != nil {
-			log.Error("failed to get url", sl.Err(err))
 
-			render.JSON(w, r, response.Error("internal error"))
+		log.Info("got url", slog.String("url", link.Url))
+
+		if link.Interstitial {
+			renderPreview(log, w, r, link, opts.Countdown)
 
 			return
 		}
 
-		log.Info("got url", slog.String("url", resURL))
-
 		// redirect to found url
-		http.Redirect(w, r, resURL, http.StatusFound)
+		status := link.RedirectType
+		if status == 0 {
+			status = opts.Status
+		}
+		w.Header().Set("Cache-Control", cacheControl(link, opts.CacheMaxAge))
+		http.Redirect(w, r, link.Url, status)
+	}
+}
+
+// cacheControl allows caching the redirect of link for maxAge when every
+// visitor gets the same one. Otherwise browsers and proxies have to ask again
+// on every visit, so each one is counted and sees the current destination.
+func cacheControl(link urlInfo.UrlInfo, maxAge time.Duration) string {
+	if maxAge <= 0 || link.PasswordHash != "" || len(link.Rules) > 0 || len(link.Variants) > 0 {
+		return "private, no-cache"
+	}
+
+	return "public, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+}
+
+// lookup finds the link for alias on the domain the request was made to and
+// writes the error response when there is none. Unknown aliases go to the
+// domain's fallback url when fallback is set, disabled links are gone.
+func lookup(log *slog.Logger, urlGetter URLGetter, w http.ResponseWriter, r *http.Request, alias string, fallback bool) (urlInfo.UrlInfo, bool) {
+	if alias == "" {
+		log.Info("alias is empty")
+
+		render.JSON(w, r, response.Error("invalid request"))
+
+		return urlInfo.UrlInfo{}, false
+	}
+
+	// aliases are namespaced by the domain the short link was opened on,
+	// hosts that aren't registered as custom domains use the default one
+	host := shorturl.Host(r.Host)
+	linkDomain, err := urlGetter.GetDomainByHost(host)
+	if err != nil && !errors.Is(err, storage.ErrDomainNotFound) {
+		log.Error("failed to get domain", sl.Err(err))
+
+		render.JSON(w, r, response.Error("internal error"))
+
+		return urlInfo.UrlInfo{}, false
+	}
+
+	link, err := urlGetter.GetURL(linkDomain.ID, alias)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		log.Info("url not found", "alias", alias, "host", host)
+
+		if fallback && linkDomain.FallbackUrl != "" {
+			http.Redirect(w, r, linkDomain.FallbackUrl, http.StatusFound)
+
+			return urlInfo.UrlInfo{}, false
+		}
+
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("not found"))
+
+		return urlInfo.UrlInfo{}, false
 	}
+	if err != nil {
+		log.Error("failed to get url", sl.Err(err))
+
+		render.JSON(w, r, response.Error("internal error"))
+
+		return urlInfo.UrlInfo{}, false
+	}
+
+	if link.DisabledReason != "" {
+		log.Info("link is disabled", "alias", alias, "reason", link.DisabledReason)
+
+		render.Status(r, http.StatusGone)
+		render.JSON(w, r, response.Error("link is disabled"))
+
+		return urlInfo.UrlInfo{}, false
+	}
+
+	link.Domain = linkDomain.Host
+
+	return link, true
+}
+
+// locate resolves where ip is, a click without a location is better than no
+// click, so failures only leave the location empty.
+func locate(log *slog.Logger, geoResolver GeoResolver, ip string) geo.Location {
+	location, err := geoResolver.Resolve(ip)
+	if err != nil {
+		log.Warn("failed to resolve ip location", sl.Err(err))
+	}
+
+	return location
+}
+
+// target points link.Url at the destination of the first rule matching the
+// visitor and returns the rule's id, 0 when the default url stays.
+func target(link *urlInfo.UrlInfo, ua *useragent.UserAgent, location geo.Location) int64 {
+	visitor := targeting.FromUserAgent(ua)
+	visitor.Country = location.CountryCode
+	visitor.Continent = location.ContinentCode
+
+	rule, ok := targeting.Match(link.Rules, visitor)
+	if !ok {
+		return 0
+	}
+	link.Url = rule.URL
+
+	return rule.Id
+}
+
+// pickVariant points link.Url at one of its variants and returns the
+// variant's id. Sticky links remember the variant in a cookie.
+func pickVariant(w http.ResponseWriter, r *http.Request, link *urlInfo.UrlInfo) int64 {
+	name := "variant_" + strconv.FormatInt(link.Id, 10)
+
+	if link.StickyVariants {
+		if cookie, err := r.Cookie(name); err == nil {
+			id, _ := strconv.ParseInt(cookie.Value, 10, 64)
+			if v, ok := split.Find(link.Variants, id); ok {
+				link.Url = v.URL
+				return v.Id
+			}
+		}
+	}
+
+	v := split.Pick(link.Variants, rand.Intn)
+	link.Url = v.URL
+
+	if link.StickyVariants {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    strconv.FormatInt(v.Id, 10),
+			Path:     "/",
+			MaxAge:   int(stickyVariantAge.Seconds()),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	return v.Id
 }
 
 func getIP(r *http.Request) string {
@@ -1,14 +1,28 @@
 package redirect_test
 
 import (
+	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/urlInfo"
 	"url-shortner/internel/http-server/handlers/redirect"
+	"url-shortner/internel/lib/alias"
 	"url-shortner/internel/lib/api"
+	"url-shortner/internel/lib/auth/linkpass"
+	"url-shortner/internel/lib/bots"
+	"url-shortner/internel/lib/clickstream"
+	"url-shortner/internel/lib/geo"
 	"url-shortner/internel/lib/logger/handlers/slogdiscard"
+	"url-shortner/internel/lib/privacy"
+	"url-shortner/internel/lib/visitor"
+	"url-shortner/internel/lib/webhooks"
+	"url-shortner/internel/storage"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -32,12 +46,29 @@ func TestSaveHandler(t *testing.T) {
 			urlGetterMock := mocks.NewURLGetter(t)
 
 			if tc.respError == "" || tc.mockError != nil {
-				urlGetterMock.On("GetURL", tc.alias).
-					Return(tc.url, tc.mockError).Once()
+				urlGetterMock.On("GetDomainByHost", mock.AnythingOfType("string")).
+					Return(domain.Domain{}, storage.ErrDomainNotFound).Once()
+				urlGetterMock.On("GetURL", int64(0), tc.alias).
+					Return(urlInfo.UrlInfo{Alias: tc.alias, Url: tc.url}, tc.mockError).Once()
 			}
 
+			policy, err := alias.NewPolicy(alias.PolicyOptions{MinLength: 3, MaxLength: 64, CaseSensitive: true})
+			require.NoError(t, err)
+
+			cookies, err := linkpass.New("secret", time.Hour)
+			require.NoError(t, err)
+
+			detector, err := bots.New("")
+			require.NoError(t, err)
+
+			visitors, err := visitor.New("secret")
+			require.NoError(t, err)
+
+			ips, err := privacy.New(privacy.IPRaw, "")
+			require.NoError(t, err)
+
 			r := chi.NewRouter()
-			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock))
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, policy, cookies, geo.Noop{}, detector, visitors, ips, clickstream.New(clickstream.Options{}), webhooks.Noop{}, redirect.Options{Status: http.StatusFound}))
 
 			ts := httptest.NewServer(r)
 			defer ts.Close()
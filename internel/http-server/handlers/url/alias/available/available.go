@@ -0,0 +1,111 @@
+package available
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/shorturl"
+	"url-shortner/internel/storage"
+)
+
+const suggestionsCount = 3
+
+type Response struct {
+	response.Response
+	Alias       string   `json:"alias"`
+	Available   bool     `json:"available"`
+	Reason      string   `json:"reason,omitempty"`
+	Suggestions []string `json:"suggestions"`
+}
+
+type AliasChecker interface {
+	GetDomainByHost(host string) (domain.Domain, error)
+	AliasExists(domainId int64, alias string) (bool, error)
+}
+
+type AliasPolicy interface {
+	Normalize(alias string) string
+	Validate(alias string) error
+	Suggest(alias string, n int, taken func(alias string) (bool, error)) ([]string, error)
+}
+
+func New(log *slog.Logger, checker AliasChecker, policy AliasPolicy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.alias.available.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		alias := policy.Normalize(chi.URLParam(r, "alias"))
+		if alias == "" {
+			render.JSON(w, r, response.Error("invalid request"))
+			return
+		}
+
+		var linkDomain domain.Domain
+		if host := r.URL.Query().Get("domain"); host != "" {
+			var err error
+			linkDomain, err = checker.GetDomainByHost(shorturl.Host(host))
+			if errors.Is(err, storage.ErrDomainNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("domain not found"))
+				return
+			}
+			if err != nil {
+				log.Error("failed to get domain", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal Server Error"))
+				return
+			}
+		}
+
+		taken := func(alias string) (bool, error) {
+			return checker.AliasExists(linkDomain.ID, alias)
+		}
+
+		resp := Response{
+			Response:  response.OK(),
+			Alias:     alias,
+			Available: true,
+		}
+
+		if err := policy.Validate(alias); err != nil {
+			resp.Available = false
+			resp.Reason = err.Error()
+		} else {
+			exists, err := taken(alias)
+			if err != nil {
+				log.Error("failed to check alias", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal Server Error"))
+				return
+			}
+			if exists {
+				resp.Available = false
+				resp.Reason = "alias is already taken"
+			}
+		}
+
+		resp.Suggestions = make([]string, 0)
+		if !resp.Available {
+			suggestions, err := policy.Suggest(alias, suggestionsCount, taken)
+			if err != nil {
+				log.Error("failed to suggest aliases", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal Server Error"))
+				return
+			}
+			resp.Suggestions = suggestions
+		}
+
+		render.JSON(w, r, resp)
+	}
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"github.com/go-chi/render"
+	"net/http"
+	"url-shortner/internel/lib/alias"
+	"url-shortner/internel/lib/api/response"
+)
+
+type Response struct {
+	response.Response
+	Stats alias.Stats `json:"stats"`
+}
+
+type StatsProvider interface {
+	Stats() alias.Stats
+}
+
+// New reports how often generated aliases collided with existing ones since
+// the server started.
+func New(provider StatsProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Stats:    provider.Stats(),
+		})
+	}
+}
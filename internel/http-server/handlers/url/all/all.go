@@ -9,13 +9,18 @@ import (
 	"net/http"
 	"strconv"
 	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/workspace"
 	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
 	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/shorturl"
 )
 
 type Request struct {
-	Start  int `json:"start" validate:"required,number,min=1"`
-	Length int `json:"length" validate:"required,number,min=1"`
+	Start       int   `json:"start" validate:"required,number,min=1"`
+	Length      int   `json:"length" validate:"required,number,min=1"`
+	WorkspaceId int64 `json:"workspace_id,omitempty"`
 }
 
 type Response struct {
@@ -24,14 +29,18 @@ type Response struct {
 }
 
 type UrlRepository interface {
-	GetAllUrl(start, length int64) ([]urlInfo.UrlInfo, error)
+	GetUserUrls(userId, start, length int64) ([]urlInfo.UrlInfo, error)
+	GetAllWorkspaceUrl(workspaceId, start, length int64) ([]urlInfo.UrlInfo, error)
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
 }
 
-func New(log *slog.Logger, repository UrlRepository) http.HandlerFunc {
+// New lists the links of a workspace with ?workspace_id=, otherwise personal
+// links of the user and links of the workspaces the user is a member of.
+func New(log *slog.Logger, repository UrlRepository, baseURL string) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.all.New"
 
-		log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("requestId", middleware.GetReqID(r.Context())),
 		)
@@ -55,6 +64,14 @@ func New(log *slog.Logger, repository UrlRepository) http.HandlerFunc {
 		req.Start = start
 		req.Length = length
 
+		if workspaceStr := r.URL.Query().Get("workspace_id"); workspaceStr != "" {
+			req.WorkspaceId, err = strconv.ParseInt(workspaceStr, 10, 64)
+			if err != nil {
+				render.JSON(w, r, response.Error("Invalid 'workspace_id' parameter"))
+				return
+			}
+		}
+
 		log.Info("request Body decoded", slog.Any("request", req))
 		if err := validator.New().Struct(req); err != nil {
 			var validateErr validator.ValidationErrors
@@ -64,7 +81,33 @@ func New(log *slog.Logger, repository UrlRepository) http.HandlerFunc {
 			return
 		}
 
-		urls, err := repository.GetAllUrl(int64(req.Start), int64(req.Length))
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		var urls []urlInfo.UrlInfo
+		if req.WorkspaceId != 0 {
+			_, err = access.Check(repository, req.WorkspaceId, userId, workspace.RoleViewer)
+			if errors.Is(err, access.ErrForbidden) {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("no access to workspace"))
+				return
+			}
+			if err != nil {
+				log.Error("Failed to check workspace access", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal Server Error"))
+				return
+			}
+
+			urls, err = repository.GetAllWorkspaceUrl(req.WorkspaceId, int64(req.Start), int64(req.Length))
+		} else {
+			urls, err = repository.GetUserUrls(userId, int64(req.Start), int64(req.Length))
+		}
 		if err != nil {
 			log.Error("Failed to get  urls", sl.Err(err))
 			render.Status(r, http.StatusInternalServerError)
@@ -72,6 +115,10 @@ func New(log *slog.Logger, repository UrlRepository) http.HandlerFunc {
 			return
 		}
 
+		for i := range urls {
+			urls[i].ShortUrl = shorturl.Build(baseURL, urls[i].Domain, urls[i].Alias)
+		}
+
 		responseOK(w, r, urls)
 	})
 }
@@ -0,0 +1,109 @@
+package all_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/user"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/http-server/handlers/url/all"
+	"url-shortner/internel/lib/logger/handlers/slogdiscard"
+	"url-shortner/internel/storage"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepository has personal links "a1" of user 1 and "b1" of user 2 and
+// link "team" in workspace 7, where only user 2 is a member.
+type fakeRepository struct{}
+
+var links = []urlInfo.UrlInfo{
+	{Alias: "a1", User: user.User{ID: 1}},
+	{Alias: "b1", User: user.User{ID: 2}},
+	{Alias: "team", User: user.User{ID: 2}, WorkspaceId: 7},
+}
+
+func (fakeRepository) GetMemberRole(workspaceId, userId int64) (workspace.Role, error) {
+	if workspaceId == 7 && userId == 2 {
+		return workspace.RoleViewer, nil
+	}
+	return "", storage.ErrMemberNotFound
+}
+
+func (f fakeRepository) GetUserUrls(userId, start, length int64) ([]urlInfo.UrlInfo, error) {
+	var urls []urlInfo.UrlInfo
+	for _, link := range links {
+		_, err := f.GetMemberRole(link.WorkspaceId, userId)
+		if (link.WorkspaceId == 0 && link.User.ID == userId) || (link.WorkspaceId != 0 && err == nil) {
+			urls = append(urls, link)
+		}
+	}
+	return urls, nil
+}
+
+func (fakeRepository) GetAllWorkspaceUrl(workspaceId, start, length int64) ([]urlInfo.UrlInfo, error) {
+	var urls []urlInfo.UrlInfo
+	for _, link := range links {
+		if link.WorkspaceId == workspaceId {
+			urls = append(urls, link)
+		}
+	}
+	return urls, nil
+}
+
+func TestAllHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		userId    int64
+		query     string
+		status    int
+		aliases   []string
+		respError string
+	}{
+		{name: "Own links", userId: 1, query: "", status: http.StatusOK, aliases: []string{"a1"}},
+		{name: "Own and workspace links", userId: 2, query: "", status: http.StatusOK, aliases: []string{"b1", "team"}},
+		{name: "No links", userId: 3, query: "", status: http.StatusOK},
+		{name: "Workspace member", userId: 2, query: "&workspace_id=7", status: http.StatusOK, aliases: []string{"team"}},
+		{name: "Workspace stranger", userId: 1, query: "&workspace_id=7", status: http.StatusForbidden, respError: "no access to workspace"},
+	}
+
+	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := all.New(slogdiscard.NewDiscardLogger(), fakeRepository{}, "https://sho.rt")
+
+			// claims only get their JSON types once the token is parsed
+			_, tokenString, err := tokenAuth.Encode(map[string]interface{}{"user_id": tc.userId})
+			require.NoError(t, err)
+			token, err := jwtauth.VerifyToken(tokenAuth, tokenString)
+			require.NoError(t, err)
+
+			ctx := jwtauth.NewContext(context.Background(), token, nil)
+			req := httptest.NewRequest(http.MethodGet, "/url?start=1&length=10"+tc.query, nil).WithContext(ctx)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+
+			var resp all.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tc.respError, resp.Error)
+
+			var aliases []string
+			for _, link := range resp.URLs {
+				aliases = append(aliases, link.Alias)
+			}
+			assert.Equal(t, tc.aliases, aliases)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package analytics
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"time"
+	"url-shortner/internel/domain/entities/analytics"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/shorturl"
+	"url-shortner/internel/lib/timeseries"
+	"url-shortner/internel/storage"
+)
+
+type Response struct {
+	response.Response
+	Analytics analytics.Analytics `json:"analytics"`
+}
+
+type AnalyticsRepository interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	GetDomainByHost(host string) (domain.Domain, error)
+	GetURL(domainId int64, alias string) (urlInfo.UrlInfo, error)
+	GetURLAnalytics(urlId int64, query analytics.Query) (analytics.Analytics, error)
+}
+
+type AliasNormalizer interface {
+	Normalize(alias string) string
+}
+
+// New returns the clicks of a link over time and its top countries,
+// browsers, operating systems, platforms, referrers, languages and UTM
+// parameters, see timeseries.ParseQuery for the parameters and filters. Links on custom domains are
+// addressed with ?domain=host.
+func New(log *slog.Logger, repository AnalyticsRepository, aliases AliasNormalizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.analytics.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		alias := aliases.Normalize(chi.URLParam(r, "alias"))
+		if alias == "" {
+			render.JSON(w, r, response.Error("invalid request"))
+			return
+		}
+
+		query, err := timeseries.ParseQuery(r.URL.Query(), time.Now())
+		if err != nil {
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		var linkDomain domain.Domain
+		if host := r.URL.Query().Get("domain"); host != "" {
+			linkDomain, err = repository.GetDomainByHost(shorturl.Host(host))
+			if errors.Is(err, storage.ErrDomainNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("domain not found"))
+				return
+			}
+			if err != nil {
+				log.Error("failed to get domain", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal Server Error"))
+				return
+			}
+		}
+
+		link, err := repository.GetURL(linkDomain.ID, alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		err = access.CheckLink(repository, link, userId, workspace.RoleViewer)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to link"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to check link access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		report, err := repository.GetURLAnalytics(link.Id, query)
+		if err != nil {
+			log.Error("failed to get url analytics", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		responseOK(w, r, report)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, report analytics.Analytics) {
+	render.JSON(w, r, Response{
+		Response:  response.OK(),
+		Analytics: report,
+	})
+}
@@ -0,0 +1,100 @@
+package broken
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/shorturl"
+)
+
+type Response struct {
+	response.Response
+	URLs []urlInfo.UrlInfo `json:"urls"`
+}
+
+type URLRepository interface {
+	GetBrokenURLs(workspaceId, userId int64, minFailures int) ([]urlInfo.UrlInfo, error)
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	IsAdmin(userId int64) (bool, error)
+}
+
+// New lists the links whose destination failed at least failureThreshold
+// health checks in a row, with the outcome of the last check. ?workspace_id=
+// lists those of a workspace, otherwise users get their own links and admins
+// all of them.
+func New(log *slog.Logger, repository URLRepository, baseURL string, failureThreshold int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.broken.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		var workspaceId int64
+		if workspaceStr := r.URL.Query().Get("workspace_id"); workspaceStr != "" {
+			workspaceId, err = strconv.ParseInt(workspaceStr, 10, 64)
+			if err != nil {
+				render.JSON(w, r, response.Error("Invalid 'workspace_id' parameter"))
+				return
+			}
+		}
+
+		ownerId := userId
+		if workspaceId != 0 {
+			_, err = access.Check(repository, workspaceId, userId, workspace.RoleViewer)
+			if errors.Is(err, access.ErrForbidden) {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("no access to workspace"))
+				return
+			}
+			ownerId = 0
+		} else {
+			var isAdmin bool
+			isAdmin, err = repository.IsAdmin(userId)
+			if isAdmin {
+				ownerId = 0
+			}
+		}
+		if err != nil {
+			log.Error("failed to check access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		urls, err := repository.GetBrokenURLs(workspaceId, ownerId, max(failureThreshold, 1))
+		if err != nil {
+			log.Error("failed to get broken urls", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		for i := range urls {
+			urls[i].ShortUrl = shorturl.Build(baseURL, urls[i].Domain, urls[i].Alias)
+		}
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			URLs:     urls,
+		})
+	}
+}
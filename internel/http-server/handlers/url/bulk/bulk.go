@@ -0,0 +1,292 @@
+package bulk
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/webhook"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/alias"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/shorturl"
+	"url-shortner/internel/storage"
+)
+
+const (
+	ModeBestEffort  = "best_effort"
+	ModeTransaction = "transaction"
+
+	StatusCreated    = "created"
+	StatusFailed     = "failed"
+	StatusRolledBack = "rolled_back"
+)
+
+var (
+	errTooManyItems = errors.New("too many items")
+	errRolledBack   = errors.New("rolled back")
+)
+
+// Item is one link of the batch, CSV uploads use the json names as header.
+type Item struct {
+	URL         string `json:"url" validate:"required,url"`
+	Alias       string `json:"alias,omitempty"`
+	WorkspaceId int64  `json:"workspace_id,omitempty"`
+	Domain      string `json:"domain,omitempty"`
+	Title       string `json:"title,omitempty" validate:"max=200"`
+	// Interstitial links show a preview page before redirecting.
+	Interstitial bool `json:"interstitial,omitempty"`
+}
+
+type Result struct {
+	Row      int    `json:"row"`
+	URL      string `json:"url"`
+	Alias    string `json:"alias,omitempty"`
+	ShortURL string `json:"short_url,omitempty"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+type Response struct {
+	response.Response
+	Mode    string   `json:"mode"`
+	Created int      `json:"created"`
+	Failed  int      `json:"failed"`
+	Results []Result `json:"results"`
+}
+
+type URLSaver interface {
+	SaveURL(urlInfo *urlInfo.UrlInfo) (int64, error)
+	BeginURLTx() (storage.URLTx, error)
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	GetDomainByHost(host string) (domain.Domain, error)
+}
+
+type AliasAllocator interface {
+	Allocate(save func(alias string) error) (string, error)
+	Bind(counter alias.Counter) *alias.Allocator
+}
+
+type AliasPolicy interface {
+	Normalize(alias string) string
+	Validate(alias string) error
+}
+
+// URLPolicy decides which destinations links may have.
+type URLPolicy interface {
+	CheckLink(link *urlInfo.UrlInfo) error
+}
+
+// row is an item that passed validation and access checks and can be saved.
+type row struct {
+	link   *urlInfo.UrlInfo
+	domain domain.Domain
+	err    error
+}
+
+// LinkNotifier tells webhooks about changes of links.
+type LinkNotifier interface {
+	NotifyLink(event webhook.Event, link urlInfo.UrlInfo)
+}
+
+// New creates up to maxItems links from a JSON array or a CSV upload.
+// ?mode=transaction saves all of them or none, the default best_effort mode
+// saves every valid row.
+func New(log *slog.Logger, urlSaver URLSaver, aliases AliasAllocator, policy AliasPolicy, urlPolicy URLPolicy, notifier LinkNotifier,
+	baseURL string, maxItems int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.bulk.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = ModeBestEffort
+		}
+		if mode != ModeBestEffort && mode != ModeTransaction {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("mode must be best_effort or transaction"))
+			return
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		items, parseErrs, err := decodeItems(r, maxItems)
+		if errors.Is(err, errTooManyItems) {
+			render.Status(r, http.StatusRequestEntityTooLarge)
+			render.JSON(w, r, response.Error(fmt.Sprintf("batch is limited to %d items", maxItems)))
+			return
+		}
+		if err != nil {
+			log.Info("failed to decode batch", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to parse batch: "+err.Error()))
+			return
+		}
+		if len(items) == 0 {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error("batch is empty"))
+			return
+		}
+
+		checker := newChecker(urlSaver, policy, urlPolicy, userId)
+		rows := make([]row, len(items))
+		for i, item := range items {
+			if parseErrs[i] != nil {
+				rows[i] = row{err: parseErrs[i]}
+				continue
+			}
+			rows[i] = checker.check(item)
+		}
+
+		results := make([]Result, len(items))
+		for i, item := range items {
+			results[i] = Result{Row: i + 1, URL: item.URL, Alias: item.Alias}
+		}
+
+		if mode == ModeTransaction {
+			err = saveAll(urlSaver, aliases, rows)
+		} else {
+			for i := range rows {
+				if rows[i].err == nil {
+					rows[i].err = saveRow(urlSaver.SaveURL, aliases, rows[i].link)
+				}
+			}
+		}
+		if err != nil {
+			log.Error("failed to save batch", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to save batch"))
+			return
+		}
+
+		resp := Response{Response: response.OK(), Mode: mode, Results: results}
+		for i, rw := range rows {
+			switch {
+			case rw.err == nil:
+				results[i].Status = StatusCreated
+				results[i].Alias = rw.link.Alias
+				results[i].ShortURL = shorturl.Build(baseURL, rw.domain.Host, rw.link.Alias)
+				resp.Created++
+
+				rw.link.Domain, rw.link.ShortUrl = rw.domain.Host, results[i].ShortURL
+				notifier.NotifyLink(webhook.EventLinkCreated, *rw.link)
+			case errors.Is(rw.err, errRolledBack):
+				results[i].Status = StatusRolledBack
+			default:
+				results[i].Status = StatusFailed
+				results[i].Error = errorMessage(log, rw.err)
+				resp.Failed++
+			}
+		}
+
+		if mode == ModeTransaction && resp.Failed > 0 {
+			resp.Response = response.Error("batch rolled back, no links were created")
+		}
+
+		log.Info("batch processed", slog.Int("created", resp.Created), slog.Int("failed", resp.Failed))
+
+		render.JSON(w, r, resp)
+	}
+}
+
+// saveAll saves the rows in one transaction. Rows are only committed when
+// every one of them is valid and saved, otherwise the saved ones are marked
+// as rolled back.
+func saveAll(urlSaver URLSaver, aliases AliasAllocator, rows []row) error {
+	failed := false
+	for _, rw := range rows {
+		if rw.err != nil {
+			failed = true
+		}
+	}
+
+	if !failed {
+		tx, err := urlSaver.BeginURLTx()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		txAliases := aliases.Bind(tx)
+		for i := range rows {
+			rows[i].err = saveRow(tx.SaveURL, txAliases, rows[i].link)
+			if rows[i].err != nil {
+				failed = true
+			}
+		}
+
+		if !failed {
+			return tx.Commit()
+		}
+	}
+
+	for i := range rows {
+		if rows[i].err == nil {
+			rows[i].err = errRolledBack
+		}
+	}
+
+	return nil
+}
+
+func saveRow(save func(*urlInfo.UrlInfo) (int64, error), aliases AliasAllocator, link *urlInfo.UrlInfo) error {
+	saveLink := func(alias string) error {
+		var err error
+		link.Alias = alias
+		link.Id, err = save(link)
+		return err
+	}
+
+	if link.Alias != "" {
+		return saveLink(link.Alias)
+	}
+
+	var err error
+	link.Alias, err = aliases.Allocate(saveLink)
+	return err
+}
+
+// rowError is a row failure whose message is shown to the client as is.
+type rowError string
+
+func (e rowError) Error() string {
+	return string(e)
+}
+
+// errorMessage turns a row failure into the message reported to the client,
+// unexpected errors are logged and hidden.
+func errorMessage(log *slog.Logger, err error) string {
+	var validateErr validator.ValidationErrors
+	var rowErr rowError
+
+	switch {
+	case errors.As(err, &validateErr):
+		return response.ValidationError(validateErr).Error
+	case errors.As(err, &rowErr):
+		return rowErr.Error()
+	case errors.Is(err, storage.ErrURLExists):
+		return "url already exists"
+	case errors.Is(err, alias.ErrExhausted):
+		return "failed to generate alias, try again"
+	default:
+		log.Error("failed to add url", sl.Err(err))
+		return "failed to add url"
+	}
+}
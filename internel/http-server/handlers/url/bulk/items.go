@@ -0,0 +1,233 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/user"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/shorturl"
+	"url-shortner/internel/storage"
+)
+
+// decodeItems reads the batch from a JSON array, a text/csv body or the
+// "file" field of a multipart form. Rows that can't be parsed get an error
+// at their index instead of failing the whole batch.
+func decodeItems(r *http.Request, maxItems int) ([]Item, []error, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "text/csv":
+		return decodeCSV(r.Body, maxItems)
+	case "multipart/form-data":
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, nil, err
+		}
+		defer file.Close()
+
+		return decodeCSV(file, maxItems)
+	default:
+		return decodeJSON(r.Body, maxItems)
+	}
+}
+
+func decodeJSON(body io.Reader, maxItems int) ([]Item, []error, error) {
+	dec := json.NewDecoder(body)
+
+	if tok, err := dec.Token(); err != nil {
+		return nil, nil, err
+	} else if tok != json.Delim('[') {
+		return nil, nil, errors.New("expected a JSON array")
+	}
+
+	var items []Item
+	for dec.More() {
+		if len(items) == maxItems {
+			return nil, nil, errTooManyItems
+		}
+
+		var item Item
+		if err := dec.Decode(&item); err != nil {
+			return nil, nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, make([]error, len(items)), nil
+}
+
+func decodeCSV(body io.Reader, maxItems int) ([]Item, []error, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["url"]; !ok {
+		return nil, nil, errors.New("header has no url column")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var items []Item
+	var errs []error
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(items) == maxItems {
+			return nil, nil, errTooManyItems
+		}
+
+		item := Item{
+			URL:    field(record, "url"),
+			Alias:  field(record, "alias"),
+			Domain: field(record, "domain"),
+			Title:  field(record, "title"),
+		}
+
+		var rowErr error
+		if ws := field(record, "workspace_id"); ws != "" {
+			item.WorkspaceId, err = strconv.ParseInt(ws, 10, 64)
+			if err != nil {
+				rowErr = rowError("invalid workspace_id")
+			}
+		}
+		if value := field(record, "interstitial"); value != "" {
+			item.Interstitial, err = strconv.ParseBool(value)
+			if err != nil {
+				rowErr = rowError("invalid interstitial")
+			}
+		}
+
+		items = append(items, item)
+		errs = append(errs, rowErr)
+	}
+
+	return items, errs, nil
+}
+
+// checker validates items the way a single POST /url does, caching access
+// checks and domain lookups that repeat across the batch.
+type checker struct {
+	urlSaver  URLSaver
+	policy    AliasPolicy
+	urlPolicy URLPolicy
+	validate  *validator.Validate
+	userId    int64
+
+	workspaces map[int64]error
+	domains    map[string]domainResult
+}
+
+type domainResult struct {
+	domain domain.Domain
+	err    error
+}
+
+func newChecker(urlSaver URLSaver, policy AliasPolicy, urlPolicy URLPolicy, userId int64) *checker {
+	return &checker{
+		urlSaver:   urlSaver,
+		policy:     policy,
+		urlPolicy:  urlPolicy,
+		validate:   validator.New(),
+		userId:     userId,
+		workspaces: make(map[int64]error),
+		domains:    make(map[string]domainResult),
+	}
+}
+
+func (c *checker) check(item Item) row {
+	if err := c.validate.Struct(item); err != nil {
+		return row{err: err}
+	}
+
+	if err := c.urlPolicy.CheckLink(&urlInfo.UrlInfo{Url: item.URL}); err != nil {
+		return row{err: rowError("url rejected: " + err.Error())}
+	}
+
+	if item.Alias != "" {
+		item.Alias = c.policy.Normalize(item.Alias)
+		if err := c.policy.Validate(item.Alias); err != nil {
+			return row{err: rowError("invalid alias: " + err.Error())}
+		}
+	}
+
+	if item.WorkspaceId != 0 {
+		err, ok := c.workspaces[item.WorkspaceId]
+		if !ok {
+			_, err = access.Check(c.urlSaver, item.WorkspaceId, c.userId, workspace.RoleEditor)
+			if errors.Is(err, access.ErrForbidden) {
+				err = rowError("no access to workspace")
+			}
+			c.workspaces[item.WorkspaceId] = err
+		}
+		if err != nil {
+			return row{err: err}
+		}
+	}
+
+	var linkDomain domain.Domain
+	if item.Domain != "" {
+		host := shorturl.Host(item.Domain)
+		res, ok := c.domains[host]
+		if !ok {
+			res.domain, res.err = c.urlSaver.GetDomainByHost(host)
+			if errors.Is(res.err, storage.ErrDomainNotFound) {
+				res.err = rowError("domain not found")
+			}
+			c.domains[host] = res
+		}
+		if res.err != nil {
+			return row{err: res.err}
+		}
+		linkDomain = res.domain
+
+		// workspace domains are shared by the workspace, personal ones by nobody
+		if linkDomain.WorkspaceId != item.WorkspaceId ||
+			(linkDomain.WorkspaceId == 0 && linkDomain.UserId != c.userId) {
+			return row{err: rowError("domain can't be used for this link")}
+		}
+	}
+
+	return row{
+		link: &urlInfo.UrlInfo{
+			Alias:        item.Alias,
+			Url:          item.URL,
+			User:         user.User{ID: c.userId},
+			WorkspaceId:  item.WorkspaceId,
+			DomainId:     linkDomain.ID,
+			Title:        item.Title,
+			Interstitial: item.Interstitial,
+		},
+		domain: linkDomain,
+	}
+}
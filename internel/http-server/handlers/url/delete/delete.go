@@ -7,8 +7,15 @@ import (
 	"github.com/go-chi/render"
 	"log/slog"
 	"net/http"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/webhook"
+	"url-shortner/internel/domain/entities/workspace"
 	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
 	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/shorturl"
 	"url-shortner/internel/storage"
 )
 
@@ -18,19 +25,33 @@ type Response struct {
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLDeleter
 type URLDeleter interface {
-	DeleteURL(alias string) error
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	GetDomainByHost(host string) (domain.Domain, error)
+	GetURL(domainId int64, alias string) (urlInfo.UrlInfo, error)
+	DeleteURL(domainId int64, alias string) error
 }
 
-func New(log *slog.Logger, deleter URLDeleter) http.HandlerFunc {
+type AliasNormalizer interface {
+	Normalize(alias string) string
+}
+
+// LinkNotifier tells webhooks about changes of links.
+type LinkNotifier interface {
+	NotifyLink(event webhook.Event, link urlInfo.UrlInfo)
+}
+
+// New deletes a link the user owns or may edit in its workspace, links on
+// custom domains are addressed with ?domain=host.
+func New(log *slog.Logger, deleter URLDeleter, aliases AliasNormalizer, notifier LinkNotifier) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.delete.new"
 
-		log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("requestId", middleware.GetReqID(r.Context())),
 		)
 
-		alias := chi.URLParam(r, "alias")
+		alias := aliases.Normalize(chi.URLParam(r, "alias"))
 		if alias == "" {
 			log.Info("alias is empty")
 
@@ -39,7 +60,53 @@ func New(log *slog.Logger, deleter URLDeleter) http.HandlerFunc {
 			return
 		}
 
-		err := deleter.DeleteURL(alias)
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		var linkDomain domain.Domain
+		if host := r.URL.Query().Get("domain"); host != "" {
+			linkDomain, err = deleter.GetDomainByHost(shorturl.Host(host))
+			if errors.Is(err, storage.ErrDomainNotFound) {
+				render.JSON(w, r, response.Error("domain not found"))
+				return
+			} else if err != nil {
+				log.Error("falied to get domain", sl.Err(err))
+				render.JSON(w, r, response.Error("falied to delete URL"))
+				return
+			}
+		}
+
+		// webhooks get the link as it was before it is gone
+		link, err := deleter.GetURL(linkDomain.ID, alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("alias doesn't exist", slog.String("alias", alias))
+			render.JSON(w, r, response.Error("ID doesn't exist"))
+			return
+		} else if err != nil {
+			log.Error("falied to get URL", sl.Err(err))
+			render.JSON(w, r, response.Error("falied to delete URL"))
+			return
+		}
+
+		err = access.CheckLink(deleter, link, userId, workspace.RoleEditor)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to link"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to check link access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		err = deleter.DeleteURL(linkDomain.ID, alias)
 		if errors.Is(err, storage.ErrIdNotFound) {
 			log.Error("ID doesn't exist", sl.Err(err))
 
@@ -52,6 +119,9 @@ func New(log *slog.Logger, deleter URLDeleter) http.HandlerFunc {
 			return
 		}
 
+		link.Domain = linkDomain.Host
+		notifier.NotifyLink(webhook.EventLinkDeleted, link)
+
 		responseOK(w, r)
 	}
 
@@ -0,0 +1,116 @@
+package delete_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/user"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/http-server/handlers/url/delete"
+	"url-shortner/internel/lib/alias"
+	"url-shortner/internel/lib/logger/handlers/slogdiscard"
+	"url-shortner/internel/lib/webhooks"
+	"url-shortner/internel/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDeleter has one personal link "own" of user 1 and one link "team" in
+// workspace 7, where user 2 is a viewer and user 3 an editor.
+type fakeDeleter struct {
+	deleted []string
+}
+
+func (f *fakeDeleter) GetMemberRole(workspaceId, userId int64) (workspace.Role, error) {
+	roles := map[int64]workspace.Role{2: workspace.RoleViewer, 3: workspace.RoleEditor}
+	if role, ok := roles[userId]; ok && workspaceId == 7 {
+		return role, nil
+	}
+	return "", storage.ErrMemberNotFound
+}
+
+func (f *fakeDeleter) GetDomainByHost(host string) (domain.Domain, error) {
+	return domain.Domain{}, storage.ErrDomainNotFound
+}
+
+func (f *fakeDeleter) GetURL(domainId int64, alias string) (urlInfo.UrlInfo, error) {
+	switch alias {
+	case "own":
+		return urlInfo.UrlInfo{Id: 1, Alias: alias, User: user.User{ID: 1}}, nil
+	case "team":
+		return urlInfo.UrlInfo{Id: 2, Alias: alias, User: user.User{ID: 1}, WorkspaceId: 7}, nil
+	}
+	return urlInfo.UrlInfo{}, storage.ErrURLNotFound
+}
+
+func (f *fakeDeleter) DeleteURL(domainId int64, alias string) error {
+	f.deleted = append(f.deleted, alias)
+	return nil
+}
+
+func TestDeleteHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		userId    int64
+		alias     string
+		status    int
+		respError string
+	}{
+		{name: "Owner", userId: 1, alias: "own", status: http.StatusOK},
+		{name: "Stranger", userId: 4, alias: "own", status: http.StatusForbidden, respError: "no access to link"},
+		{name: "Workspace editor", userId: 3, alias: "team", status: http.StatusOK},
+		{name: "Workspace viewer", userId: 2, alias: "team", status: http.StatusForbidden, respError: "no access to link"},
+		{name: "Workspace stranger", userId: 4, alias: "team", status: http.StatusForbidden, respError: "no access to link"},
+		{name: "Unknown alias", userId: 1, alias: "missing", status: http.StatusOK, respError: "ID doesn't exist"},
+		{name: "Mixed case alias", userId: 1, alias: "OwN", status: http.StatusOK},
+	}
+
+	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+
+	policy, err := alias.NewPolicy(alias.PolicyOptions{MinLength: 3, MaxLength: 64})
+	require.NoError(t, err)
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deleter := &fakeDeleter{}
+			handler := delete.New(slogdiscard.NewDiscardLogger(), deleter, policy, webhooks.Noop{})
+
+			// claims only get their JSON types once the token is parsed
+			_, tokenString, err := tokenAuth.Encode(map[string]interface{}{"user_id": tc.userId})
+			require.NoError(t, err)
+			token, err := jwtauth.VerifyToken(tokenAuth, tokenString)
+			require.NoError(t, err)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", tc.alias)
+			ctx := context.WithValue(jwtauth.NewContext(context.Background(), token, nil), chi.RouteCtxKey, rctx)
+
+			req := httptest.NewRequest(http.MethodDelete, "/url/"+tc.alias, nil).WithContext(ctx)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+
+			var resp delete.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tc.respError, resp.Error)
+
+			if tc.respError == "" {
+				assert.Equal(t, []string{policy.Normalize(tc.alias)}, deleter.deleted)
+			} else {
+				assert.Empty(t, deleter.deleted)
+			}
+		})
+	}
+}
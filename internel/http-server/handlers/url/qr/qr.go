@@ -0,0 +1,204 @@
+package qr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/qr"
+	"url-shortner/internel/lib/shorturl"
+	"url-shortner/internel/storage"
+)
+
+type URLGetter interface {
+	GetDomainByHost(host string) (domain.Domain, error)
+	GetURL(domainId int64, alias string) (urlInfo.UrlInfo, error)
+}
+
+type AliasNormalizer interface {
+	Normalize(alias string) string
+}
+
+// New renders a QR code of the link's short URL, marked with
+// shorturl.QRParam. The image only depends on
+// the short URL and the parameters, so it is served with an ETag derived
+// from them.
+//
+// Query parameters: format (png, svg), size, margin, level (L, M, Q, H),
+// fg and bg (hex colors) and domain for links on custom domains.
+func New(log *slog.Logger, urlGetter URLGetter, aliases AliasNormalizer, baseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.qr.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		alias := aliases.Normalize(chi.URLParam(r, "alias"))
+		if alias == "" {
+			render.JSON(w, r, response.Error("invalid request"))
+			return
+		}
+
+		query := r.URL.Query()
+
+		format, opts, err := parseOptions(r)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		var linkDomain domain.Domain
+		if host := query.Get("domain"); host != "" {
+			linkDomain, err = urlGetter.GetDomainByHost(shorturl.Host(host))
+			if errors.Is(err, storage.ErrDomainNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("domain not found"))
+				return
+			}
+			if err != nil {
+				log.Error("failed to get domain", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal Server Error"))
+				return
+			}
+		}
+
+		link, err := urlGetter.GetURL(linkDomain.ID, alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		// the marker tells visits from the code apart in the click stats
+		shortURL := shorturl.QR(shorturl.Build(baseURL, linkDomain.Host, link.Alias))
+
+		etag := etag(shortURL, format, opts)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+		w.Header().Set("Vary", "Accept")
+		if matchesETag(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		var image []byte
+		if format == qr.FormatSVG {
+			image, err = qr.SVG(shortURL, opts)
+			w.Header().Set("Content-Type", "image/svg+xml")
+		} else {
+			image, err = qr.PNG(shortURL, opts)
+			w.Header().Set("Content-Type", "image/png")
+		}
+		if err != nil {
+			w.Header().Del("ETag")
+			w.Header().Del("Cache-Control")
+			log.Error("failed to render qr code", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to render qr code"))
+			return
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(image)))
+		_, _ = w.Write(image)
+	}
+}
+
+// parseOptions reads the image parameters, the format comes from ?format=
+// or else the Accept header.
+func parseOptions(r *http.Request) (string, qr.Options, error) {
+	query := r.URL.Query()
+	opts := qr.DefaultOptions()
+
+	format := strings.ToLower(query.Get("format"))
+	switch format {
+	case qr.FormatPNG, qr.FormatSVG:
+	case "":
+		format = qr.FormatPNG
+		if strings.Contains(r.Header.Get("Accept"), "image/svg+xml") {
+			format = qr.FormatSVG
+		}
+	default:
+		return "", opts, errors.New("format must be png or svg")
+	}
+
+	if value := query.Get("size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < qr.MinSize || size > qr.MaxSize {
+			return "", opts, fmt.Errorf("size must be between %d and %d", qr.MinSize, qr.MaxSize)
+		}
+		opts.Size = size
+	}
+
+	if value := query.Get("margin"); value != "" {
+		margin, err := strconv.Atoi(value)
+		if err != nil || margin < 0 || margin > qr.MaxMargin {
+			return "", opts, fmt.Errorf("margin must be between 0 and %d", qr.MaxMargin)
+		}
+		opts.Margin = margin
+	}
+
+	if value := query.Get("level"); value != "" {
+		level, err := qr.ParseLevel(value)
+		if err != nil {
+			return "", opts, err
+		}
+		opts.Level = level
+	}
+
+	if value := query.Get("fg"); value != "" {
+		fg, err := qr.ParseColor(value)
+		if err != nil {
+			return "", opts, fmt.Errorf("fg: %w", err)
+		}
+		opts.Foreground = fg
+	}
+
+	if value := query.Get("bg"); value != "" {
+		bg, err := qr.ParseColor(value)
+		if err != nil {
+			return "", opts, fmt.Errorf("bg: %w", err)
+		}
+		opts.Background = bg
+	}
+
+	return format, opts, nil
+}
+
+func etag(shortURL, format string, opts qr.Options) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%d|%d|%v|%v",
+		shortURL, format, opts.Size, opts.Margin, opts.Level, opts.Foreground, opts.Background)))
+
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func matchesETag(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,192 @@
+package export
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/clickio"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/shorturl"
+	"url-shortner/internel/storage"
+)
+
+type ExportRepository interface {
+	clickio.Source
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	GetDomainByHost(host string) (domain.Domain, error)
+	GetURL(domainId int64, alias string) (urlInfo.UrlInfo, error)
+	IsAdmin(userId int64) (bool, error)
+}
+
+type AliasNormalizer interface {
+	Normalize(alias string) string
+}
+
+// New streams clicks in the ?format= given as a file download, filtered as
+// described by clickio.ParseFilter. Clicks of a link are selected with
+// ?alias= and ?domain=, those of a workspace with ?workspace_id=, only
+// admins may export all clicks and see the IPs of visitors. Every write to
+// the client gets writeTimeout instead of the server's write timeout, 0 for
+// no limit.
+func New(log *slog.Logger, repository ExportRepository, aliases AliasNormalizer, writeTimeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.redirectInfo.export.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+		format := query.Get("format")
+		if format == "" {
+			format = clickio.FormatCSV
+		}
+
+		writer, err := clickio.NewWriter(format, &deadlineWriter{w: w, rc: http.NewResponseController(w), timeout: writeTimeout})
+		if errors.Is(err, clickio.ErrUnknownFormat) {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		filter, err := clickio.ParseFilter(query)
+		if err != nil {
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		isAdmin, err := repository.IsAdmin(userId)
+		if err != nil {
+			log.Error("Failed to check admin", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		switch {
+		case query.Get("alias") != "":
+			link, ok := getLink(log, repository, w, r, aliases.Normalize(query.Get("alias")))
+			if !ok {
+				return
+			}
+			if !isAdmin {
+				err = access.CheckLink(repository, link, userId, workspace.RoleViewer)
+			}
+			filter.UrlId = link.Id
+		case query.Get("workspace_id") != "":
+			filter.WorkspaceId, err = strconv.ParseInt(query.Get("workspace_id"), 10, 64)
+			if err != nil {
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, response.Error("Invalid 'workspace_id' parameter"))
+				return
+			}
+			if !isAdmin {
+				_, err = access.Check(repository, filter.WorkspaceId, userId, workspace.RoleViewer)
+			}
+		case !isAdmin:
+			err = access.ErrForbidden
+		}
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to clicks"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to check access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		w.Header().Set("Content-Type", clickio.ContentType(format))
+		w.Header().Set("Content-Disposition", `attachment; filename="clicks.`+format+`"`)
+
+		// the status is sent with the first row, so later errors can only be logged
+		count, err := clickio.Export(r.Context(), repository, writer, clickio.ExportOptions{
+			Filter:  filter,
+			HideIPs: !isAdmin,
+		})
+		if err != nil {
+			log.Error("failed to export clicks", sl.Err(err))
+			return
+		}
+
+		log.Info("clicks exported", slog.Int("count", count))
+	}
+}
+
+// deadlineWriter moves the write deadline of the response before every
+// write, the formats buffer rows so that is once per batch of them. The
+// server's write timeout is meant for ordinary responses and would cut long
+// exports off in the middle of the file.
+type deadlineWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+func (d *deadlineWriter) Write(p []byte) (int, error) {
+	deadline := time.Time{}
+	if d.timeout > 0 {
+		deadline = time.Now().Add(d.timeout)
+	}
+	_ = d.rc.SetWriteDeadline(deadline)
+
+	return d.w.Write(p)
+}
+
+// getLink returns the link with alias on the ?domain= given, it writes the
+// error response when there is none.
+func getLink(log *slog.Logger, repository ExportRepository, w http.ResponseWriter, r *http.Request, alias string) (urlInfo.UrlInfo, bool) {
+	var linkDomain domain.Domain
+	if host := r.URL.Query().Get("domain"); host != "" {
+		var err error
+		linkDomain, err = repository.GetDomainByHost(shorturl.Host(host))
+		if errors.Is(err, storage.ErrDomainNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("domain not found"))
+			return urlInfo.UrlInfo{}, false
+		}
+		if err != nil {
+			log.Error("failed to get domain", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return urlInfo.UrlInfo{}, false
+		}
+	}
+
+	link, err := repository.GetURL(linkDomain.ID, alias)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, response.Error("not found"))
+		return urlInfo.UrlInfo{}, false
+	}
+	if err != nil {
+		log.Error("failed to get url", sl.Err(err))
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, response.Error("Internal Server Error"))
+		return urlInfo.UrlInfo{}, false
+	}
+
+	return link, true
+}
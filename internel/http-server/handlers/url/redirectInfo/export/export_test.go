@@ -0,0 +1,88 @@
+package export_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/redirectInfo"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/http-server/handlers/url/redirectInfo/export"
+	"url-shortner/internel/lib/alias"
+	"url-shortner/internel/lib/logger/handlers/slogdiscard"
+	"url-shortner/internel/storage"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowRepository lets an admin export clicks that take wait each to read.
+type slowRepository struct {
+	clicks int
+	wait   time.Duration
+}
+
+func (s slowRepository) ExportRedirectInfo(ctx context.Context, _ redirectInfo.Filter, fn func(redirectInfo.RedirectInfo) error) error {
+	for i := 1; i <= s.clicks; i++ {
+		time.Sleep(s.wait)
+		if err := fn(redirectInfo.RedirectInfo{Id: int64(i), Alias: "promo"}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (slowRepository) GetMemberRole(workspaceId, userId int64) (workspace.Role, error) {
+	return "", storage.ErrMemberNotFound
+}
+
+func (slowRepository) GetDomainByHost(host string) (domain.Domain, error) {
+	return domain.Domain{}, storage.ErrDomainNotFound
+}
+
+func (slowRepository) GetURL(domainId int64, alias string) (urlInfo.UrlInfo, error) {
+	return urlInfo.UrlInfo{}, storage.ErrURLNotFound
+}
+
+func (slowRepository) IsAdmin(userId int64) (bool, error) {
+	return true, nil
+}
+
+func TestExportHandler_OutlivesServerWriteTimeout(t *testing.T) {
+	policy, err := alias.NewPolicy(alias.PolicyOptions{MinLength: 3, MaxLength: 64})
+	require.NoError(t, err)
+
+	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	_, tokenString, err := tokenAuth.Encode(map[string]interface{}{"user_id": 1})
+	require.NoError(t, err)
+
+	handler := export.New(slogdiscard.NewDiscardLogger(), slowRepository{clicks: 10, wait: 20 * time.Millisecond}, policy, time.Second)
+
+	srv := httptest.NewUnstartedServer(jwtauth.Verifier(tokenAuth)(handler))
+	// the export takes twice as long as the server allows for a response
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/url/redirect-info/export?format=csv", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	require.Len(t, lines, 11)
+	assert.True(t, strings.HasPrefix(lines[10], "10,promo,"))
+}
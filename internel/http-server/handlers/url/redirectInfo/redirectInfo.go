@@ -1,9 +1,7 @@
 package redirectInfo
 
 import (
-	"encoding/json"
 	"errors"
-	"fmt"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
@@ -12,6 +10,7 @@ import (
 	"strconv"
 	"url-shortner/internel/domain/entities/redirectInfo"
 	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/jwt"
 	"url-shortner/internel/lib/logger/sl"
 )
 
@@ -25,16 +24,12 @@ type Response struct {
 	URLs []redirectInfo.RedirectInfo `json:"urlInfo"`
 }
 
-type IPApiResponse struct {
-	Country     string `json:"country"`
-	City        string `json:"city"`
-	CountryCode string `json:"countryCode"`
-}
-
 type InfoRepository interface {
 	GetAllRedirectInfo(start, length int64) ([]redirectInfo.RedirectInfo, error)
+	IsAdmin(userId int64) (bool, error)
 }
 
+// New returns a page of clicks, only admins get the IPs of visitors.
 func New(log *slog.Logger, repository InfoRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.RedirectInfo.New"
@@ -72,6 +67,22 @@ func New(log *slog.Logger, repository InfoRepository) http.HandlerFunc {
 			return
 		}
 
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		isAdmin, err := repository.IsAdmin(userId)
+		if err != nil {
+			log.Error("Failed to check admin", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
 		infos, err := repository.GetAllRedirectInfo(int64(req.Start), int64(req.Length))
 		if err != nil {
 			log.Error("Failed to get url Infos", sl.Err(err))
@@ -80,16 +91,10 @@ func New(log *slog.Logger, repository InfoRepository) http.HandlerFunc {
 			return
 		}
 
-		for i, info := range infos {
-			country, err := getCountryByIP(info.Ip)
-			if err != nil {
-				log.Error("Failed to get ip country info", sl.Err(err))
-				continue
+		if !isAdmin {
+			for i := range infos {
+				infos[i].Ip = ""
 			}
-
-			infos[i].Country = country.Country
-			infos[i].City = country.City
-			infos[i].CountryCode = country.CountryCode
 		}
 
 		responseOK(w, r, infos)
@@ -102,24 +107,3 @@ func responseOK(w http.ResponseWriter, r *http.Request, Urls []redirectInfo.Redi
 		URLs:     Urls,
 	})
 }
-
-func getCountryByIP(ip string) (IPApiResponse, error) {
-	url := fmt.Sprintf("http://ip-api.com/json/%s", ip)
-	resp, err := http.Get(url)
-	if err != nil {
-		return IPApiResponse{}, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return IPApiResponse{}, fmt.Errorf("failed to get response: %s", resp.Status)
-	}
-
-	var result IPApiResponse
-	err = json.NewDecoder(resp.Body).Decode(&result)
-	if err != nil {
-		return IPApiResponse{}, err
-	}
-
-	return result, nil
-}
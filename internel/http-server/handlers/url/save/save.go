@@ -3,35 +3,97 @@ package save
 import (
 	"errors"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/jwtauth/v5"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 	"log/slog"
 	"net/http"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/rule"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/user"
+	"url-shortner/internel/domain/entities/variant"
+	"url-shortner/internel/domain/entities/webhook"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/alias"
 	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/hash"
+	"url-shortner/internel/lib/auth/jwt"
 	"url-shortner/internel/lib/logger/sl"
-	"url-shortner/internel/lib/random"
+	"url-shortner/internel/lib/shorturl"
+	"url-shortner/internel/lib/split"
+	"url-shortner/internel/lib/targeting"
 	"url-shortner/internel/storage"
 )
 
 type Request struct {
-	URL   string `json:"url" validate:"required,url"`
-	Alias string `json:"alias,omitempty"`
+	URL         string `json:"url" validate:"required,url"`
+	Alias       string `json:"alias,omitempty"`
+	WorkspaceId int64  `json:"workspace_id,omitempty"`
+	Domain      string `json:"domain,omitempty"`
+	Title       string `json:"title,omitempty" validate:"max=200"`
+	// Interstitial links show a preview page before redirecting.
+	Interstitial bool `json:"interstitial,omitempty"`
+	// Password protected links ask for it before redirecting.
+	Password string `json:"password,omitempty" validate:"omitempty,min=4,max=72"`
+	// Rules are tried in order, visitors matching none go to URL.
+	Rules []rule.Rule `json:"rules,omitempty" validate:"dive"`
+	// Variants split visitors no rule matched between weighted destinations.
+	Variants       []variant.Variant `json:"variants,omitempty" validate:"dive"`
+	StickyVariants bool              `json:"sticky_variants,omitempty"`
+	// QueryMode decides what happens to the query string of the short link,
+	// see package destination.
+	QueryMode string       `json:"query_mode,omitempty" validate:"omitempty,oneof=drop merge override"`
+	UTM       *urlInfo.UTM `json:"utm,omitempty"`
+	// RedirectType is the status code of the redirect, the service's default
+	// when it is not set.
+	RedirectType int `json:"redirect_type,omitempty" validate:"omitempty,oneof=301 302 307 308"`
+}
+
+// LogValue keeps the password out of the logs.
+func (r Request) LogValue() slog.Value {
+	if r.Password != "" {
+		r.Password = "[REDACTED]"
+	}
+
+	type plain Request
+
+	return slog.AnyValue(plain(r))
 }
 
 type Response struct {
 	response.Response
-	Alias string `json:"alias,omitempty"`
+	Alias    string `json:"alias,omitempty"`
+	ShortURL string `json:"short_url,omitempty"`
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLSaver
 type URLSaver interface {
-	SaveURL(urlToSave, alias string, userId float64) (int64, error)
+	SaveURL(urlInfo *urlInfo.UrlInfo) (int64, error)
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	GetDomainByHost(host string) (domain.Domain, error)
 }
 
-const aliasLength = 6
+type AliasAllocator interface {
+	Allocate(save func(alias string) error) (string, error)
+}
+
+type AliasPolicy interface {
+	Normalize(alias string) string
+	Validate(alias string) error
+}
 
-func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
+// URLPolicy decides which destinations links may have.
+type URLPolicy interface {
+	CheckLink(link *urlInfo.UrlInfo) error
+}
+
+// LinkNotifier tells webhooks about changes of links.
+type LinkNotifier interface {
+	NotifyLink(event webhook.Event, link urlInfo.UrlInfo)
+}
+
+func New(log *slog.Logger, urlSaver URLSaver, aliases AliasAllocator, policy AliasPolicy, urlPolicy URLPolicy, notifier LinkNotifier, baseURL string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.save.New"
 
@@ -57,7 +119,24 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
 			render.JSON(w, r, response.ValidationError(validateErr))
 			return
 		}
-		_, claims, err := jwtauth.FromContext(r.Context())
+		if err := targeting.Normalize(req.Rules); err != nil {
+			render.JSON(w, r, response.Error("invalid rules: "+err.Error()))
+			return
+		}
+		if err := split.Check(req.Variants); err != nil {
+			render.JSON(w, r, response.Error("invalid variants: "+err.Error()))
+			return
+		}
+		if req.Alias != "" {
+			req.Alias = policy.Normalize(req.Alias)
+			if err := policy.Validate(req.Alias); err != nil {
+				log.Info("alias rejected by policy", slog.String("alias", req.Alias), sl.Err(err))
+				render.JSON(w, r, response.Error("invalid alias: "+err.Error()))
+				return
+			}
+		}
+
+		userId, err := jwt.UserID(r.Context())
 		if err != nil {
 			log.Error("Failed to get claims from jwt", sl.Err(err))
 			render.Status(r, http.StatusInternalServerError)
@@ -65,17 +144,100 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
 			return
 		}
 
-		alias := req.Alias
-		if alias == "" {
-			alias = random.NewRandomString(aliasLength)
+		if req.WorkspaceId != 0 {
+			_, err := access.Check(urlSaver, req.WorkspaceId, userId, workspace.RoleEditor)
+			if errors.Is(err, access.ErrForbidden) {
+				log.Info("no access to workspace", slog.Int64("workspace_id", req.WorkspaceId))
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("no access to workspace"))
+				return
+			}
+			if err != nil {
+				log.Error("failed to check workspace access", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal Server Error"))
+				return
+			}
+		}
+
+		var linkDomain domain.Domain
+		if req.Domain != "" {
+			linkDomain, err = urlSaver.GetDomainByHost(shorturl.Host(req.Domain))
+			if errors.Is(err, storage.ErrDomainNotFound) {
+				render.JSON(w, r, response.Error("domain not found"))
+				return
+			}
+			if err != nil {
+				log.Error("failed to get domain", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal Server Error"))
+				return
+			}
+
+			// workspace domains are shared by the workspace, personal ones by nobody
+			if linkDomain.WorkspaceId != req.WorkspaceId ||
+				(linkDomain.WorkspaceId == 0 && linkDomain.UserId != userId) {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("domain can't be used for this link"))
+				return
+			}
+		}
+
+		link := &urlInfo.UrlInfo{
+			Alias:          req.Alias,
+			Url:            req.URL,
+			User:           user.User{ID: userId},
+			WorkspaceId:    req.WorkspaceId,
+			DomainId:       linkDomain.ID,
+			Title:          req.Title,
+			Interstitial:   req.Interstitial,
+			Rules:          req.Rules,
+			Variants:       req.Variants,
+			StickyVariants: req.StickyVariants,
+			QueryMode:      req.QueryMode,
+			UTM:            req.UTM,
+			RedirectType:   req.RedirectType,
+		}
+		if err := urlPolicy.CheckLink(link); err != nil {
+			log.Info("url rejected by policy", slog.String("url", req.URL), sl.Err(err))
+			render.JSON(w, r, response.Error("url rejected: "+err.Error()))
+			return
+		}
+
+		if req.Password != "" {
+			link.PasswordHash, err = hash.GetHashPassword(req.Password)
+			if err != nil {
+				log.Error("failed to hash password", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal Server Error"))
+				return
+			}
+		}
+
+		var id int64
+		saveLink := func(alias string) error {
+			var err error
+			link.Alias = alias
+			id, err = urlSaver.SaveURL(link)
+			return err
 		}
 
-		id, err := urlSaver.SaveURL(req.URL, alias, claims["user_id"].(float64))
+		if link.Alias == "" {
+			link.Alias, err = aliases.Allocate(saveLink)
+		} else {
+			err = saveLink(link.Alias)
+		}
 		if errors.Is(err, storage.ErrURLExists) {
 			log.Info("url already exists", slog.String("url", req.URL))
 			render.JSON(w, r, response.Error("url already exists"))
 			return
 		}
+		if errors.Is(err, alias.ErrExhausted) {
+			log.Warn("failed to find a free alias", sl.Err(err))
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, response.Error("failed to generate alias, try again"))
+			return
+		}
 		if err != nil {
 			log.Error("failed to add url", sl.Err(err))
 			render.JSON(w, r, response.Error("failed to add url"))
@@ -84,13 +246,18 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
 
 		log.Info("url added", slog.Int64("id", id))
 
-		responseOK(w, r, alias)
+		link.Id, link.Domain = id, linkDomain.Host
+		link.ShortUrl = shorturl.Build(baseURL, linkDomain.Host, link.Alias)
+		notifier.NotifyLink(webhook.EventLinkCreated, *link)
+
+		responseOK(w, r, link.Alias, link.ShortUrl)
 	}
 }
 
-func responseOK(w http.ResponseWriter, r *http.Request, alias string) {
+func responseOK(w http.ResponseWriter, r *http.Request, alias, shortURL string) {
 	render.JSON(w, r, Response{
 		Response: response.OK(),
 		Alias:    alias,
+		ShortURL: shortURL,
 	})
 }
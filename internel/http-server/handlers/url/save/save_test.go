@@ -9,7 +9,11 @@ import (
 	"net/http/httptest"
 	"testing"
 	"url-shortner/internel/http-server/handlers/url/save"
+	"url-shortner/internel/lib/alias"
 	"url-shortner/internel/lib/logger/handlers/slogdiscard"
+	"url-shortner/internel/lib/random"
+	"url-shortner/internel/lib/urlpolicy"
+	"url-shortner/internel/lib/webhooks"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -63,12 +67,17 @@ func TestSaveHandler(t *testing.T) {
 			urlSaverMock := mocks.NewURLSaver(t)
 
 			if tc.respError == "" || tc.mockError != nil {
-				urlSaverMock.On("SaveURL", tc.url, mock.AnythingOfType("string")).
+				urlSaverMock.On("SaveURL", mock.AnythingOfType("*urlInfo.UrlInfo")).
 					Return(int64(1), tc.mockError).
 					Once()
 			}
 
-			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock)
+			aliases := alias.NewAllocator(alias.NewRandomGenerator(random.Base62), 6, 1, 0)
+			policy, err := alias.NewPolicy(alias.PolicyOptions{MinLength: 3, MaxLength: 64, CaseSensitive: true})
+			require.NoError(t, err)
+			urlPolicy, err := urlpolicy.New(urlpolicy.Options{})
+			require.NoError(t, err)
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, aliases, policy, urlPolicy, webhooks.Noop{}, "http://localhost:8080")
 
 			input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, tc.url, tc.alias)
 
@@ -0,0 +1,260 @@
+package update
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/rule"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/variant"
+	"url-shortner/internel/domain/entities/webhook"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/hash"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/shorturl"
+	"url-shortner/internel/lib/split"
+	"url-shortner/internel/lib/targeting"
+	"url-shortner/internel/storage"
+)
+
+// Request only changes the fields that are set.
+type Request struct {
+	Title        *string `json:"title,omitempty" validate:"omitempty,max=200"`
+	Interstitial *bool   `json:"interstitial,omitempty"`
+	// Password sets the link's password, an empty one removes it.
+	Password *string `json:"password,omitempty" validate:"omitempty,max=72"`
+	// Rules replace all rules of the link, an empty list removes them.
+	Rules *[]rule.Rule `json:"rules,omitempty" validate:"omitempty,dive"`
+	// Variants replace all variants of the link, an empty list removes them.
+	Variants       *[]variant.Variant `json:"variants,omitempty" validate:"omitempty,dive"`
+	StickyVariants *bool              `json:"sticky_variants,omitempty"`
+	QueryMode      *string            `json:"query_mode,omitempty" validate:"omitempty,oneof=drop merge override"`
+	// UTM replaces the UTM parameters of the link, an empty object removes them.
+	UTM *urlInfo.UTM `json:"utm,omitempty"`
+	// RedirectType 0 goes back to the default of the service.
+	RedirectType *int `json:"redirect_type,omitempty" validate:"omitempty,oneof=0 301 302 307 308"`
+}
+
+type Response struct {
+	response.Response
+	Link      urlInfo.UrlInfo `json:"link"`
+	Protected bool            `json:"protected"`
+}
+
+type URLUpdater interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	GetDomainByHost(host string) (domain.Domain, error)
+	GetURL(domainId int64, alias string) (urlInfo.UrlInfo, error)
+	UpdateURL(urlInfo *urlInfo.UrlInfo) error
+}
+
+type AliasNormalizer interface {
+	Normalize(alias string) string
+}
+
+// URLPolicy decides which destinations links may have.
+type URLPolicy interface {
+	CheckLink(link *urlInfo.UrlInfo) error
+}
+
+// LinkNotifier tells webhooks about changes of links.
+type LinkNotifier interface {
+	NotifyLink(event webhook.Event, link urlInfo.UrlInfo)
+}
+
+// New changes the settings of a link, links on custom domains are addressed
+// with ?domain=host.
+func New(log *slog.Logger, updater URLUpdater, aliases AliasNormalizer, urlPolicy URLPolicy, notifier LinkNotifier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		alias := aliases.Normalize(chi.URLParam(r, "alias"))
+		if alias == "" {
+			render.JSON(w, r, response.Error("invalid request"))
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to render.JSON", sl.Err(err))
+			render.JSON(w, r, response.Error("failed parse JSON body"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		if req.Rules != nil {
+			if err := targeting.Normalize(*req.Rules); err != nil {
+				render.JSON(w, r, response.Error("invalid rules: "+err.Error()))
+				return
+			}
+		}
+
+		if req.Variants != nil {
+			if err := split.Check(*req.Variants); err != nil {
+				render.JSON(w, r, response.Error("invalid variants: "+err.Error()))
+				return
+			}
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		var linkDomain domain.Domain
+		if host := r.URL.Query().Get("domain"); host != "" {
+			linkDomain, err = updater.GetDomainByHost(shorturl.Host(host))
+			if errors.Is(err, storage.ErrDomainNotFound) {
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, response.Error("domain not found"))
+				return
+			}
+			if err != nil {
+				log.Error("failed to get domain", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal Server Error"))
+				return
+			}
+		}
+
+		link, err := updater.GetURL(linkDomain.ID, alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		err = access.CheckLink(updater, link, userId, workspace.RoleEditor)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to link"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to check link access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		if req.Title != nil {
+			link.Title = *req.Title
+		}
+		if req.Interstitial != nil {
+			link.Interstitial = *req.Interstitial
+		}
+
+		if req.Password != nil {
+			link.PasswordHash = ""
+			if *req.Password != "" {
+				if len(*req.Password) < 4 {
+					render.JSON(w, r, response.Error("password must be at least 4 characters"))
+					return
+				}
+				link.PasswordHash, err = hash.GetHashPassword(*req.Password)
+				if err != nil {
+					log.Error("failed to hash password", sl.Err(err))
+					render.Status(r, http.StatusInternalServerError)
+					render.JSON(w, r, response.Error("Internal Server Error"))
+					return
+				}
+			}
+		}
+
+		if req.Rules != nil {
+			link.Rules = *req.Rules
+			// ids belong to stored rules, the new list gets its own
+			for i := range link.Rules {
+				link.Rules[i].Id = 0
+			}
+		}
+
+		if req.Variants != nil {
+			link.Variants = *req.Variants
+			for i := range link.Variants {
+				link.Variants[i].Id = 0
+			}
+		}
+		if req.StickyVariants != nil {
+			link.StickyVariants = *req.StickyVariants
+		}
+
+		if req.QueryMode != nil {
+			link.QueryMode = *req.QueryMode
+		}
+		if req.RedirectType != nil {
+			link.RedirectType = *req.RedirectType
+		}
+		if req.UTM != nil {
+			link.UTM = req.UTM
+			if *req.UTM == (urlInfo.UTM{}) {
+				link.UTM = nil
+			}
+		}
+
+		// links disabled by the policy work again once all their
+		// destinations pass it, new destinations have to
+		if err := urlPolicy.CheckLink(&link); err != nil {
+			if req.Rules != nil || req.Variants != nil {
+				log.Info("url rejected by policy", sl.Err(err))
+				render.JSON(w, r, response.Error("url rejected: "+err.Error()))
+				return
+			}
+		} else {
+			link.DisabledReason = ""
+		}
+
+		if err := updater.UpdateURL(&link); err != nil {
+			log.Error("failed to update url", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		log.Info("url updated", slog.Int64("id", link.Id))
+
+		link.Domain = linkDomain.Host
+		notifier.NotifyLink(webhook.EventLinkUpdated, link)
+
+		responseOK(w, r, link)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, link urlInfo.UrlInfo) {
+	render.JSON(w, r, Response{
+		Response:  response.OK(),
+		Link:      link,
+		Protected: link.PasswordHash != "",
+	})
+}
@@ -0,0 +1,63 @@
+package all
+
+import (
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"url-shortner/internel/domain/entities/webhook"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+)
+
+type Response struct {
+	response.Response
+	Webhooks []webhook.Webhook `json:"webhooks"`
+}
+
+type WebhookRepository interface {
+	GetUserWebhooks(userId int64) ([]webhook.Webhook, error)
+}
+
+// New lists the personal webhooks of the user and those of the workspaces the
+// user administers, without their secrets.
+func New(log *slog.Logger, repository WebhookRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhook.all.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		hooks, err := repository.GetUserWebhooks(userId)
+		if err != nil {
+			log.Error("Failed to get webhooks", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		for i := range hooks {
+			hooks[i].Secret = ""
+		}
+
+		responseOK(w, r, hooks)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, hooks []webhook.Webhook) {
+	render.JSON(w, r, Response{
+		Response: response.OK(),
+		Webhooks: hooks,
+	})
+}
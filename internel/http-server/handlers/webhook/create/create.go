@@ -0,0 +1,144 @@
+package create
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"url-shortner/internel/domain/entities/webhook"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/random"
+)
+
+const secretSize = 32
+
+type Request struct {
+	URL         string          `json:"url" validate:"required,url"`
+	Events      []webhook.Event `json:"events" validate:"required,min=1"`
+	WorkspaceId int64           `json:"workspace_id,omitempty"`
+}
+
+type Response struct {
+	response.Response
+	Webhook webhook.Webhook `json:"webhook"`
+}
+
+type WebhookSaver interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	SaveWebhook(hook *webhook.Webhook) (int64, error)
+}
+
+// URLPolicy keeps webhooks from being pointed at internal services.
+type URLPolicy interface {
+	Check(rawURL string) error
+}
+
+// New registers a webhook for the events of the user's personal links, or of
+// the links of a workspace the user administers. The response has the secret
+// deliveries are signed with, it is not shown again.
+func New(log *slog.Logger, saver WebhookSaver, urlPolicy URLPolicy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhook.create.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to render.JSON", sl.Err(err))
+			render.JSON(w, r, response.Error("failed parse JSON body"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		for _, event := range req.Events {
+			if event == webhook.EventLinkExpired {
+				render.JSON(w, r, response.Error("event link.expired is not sent yet, links have no expiry date"))
+				return
+			}
+			if !event.Valid() {
+				render.JSON(w, r, response.Error("unknown event "+string(event)))
+				return
+			}
+		}
+
+		if err := urlPolicy.Check(req.URL); err != nil {
+			log.Info("webhook url rejected by policy", slog.String("url", req.URL), sl.Err(err))
+			render.JSON(w, r, response.Error("url rejected: "+err.Error()))
+			return
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		hook := webhook.Webhook{
+			UserId:      userId,
+			WorkspaceId: req.WorkspaceId,
+			URL:         req.URL,
+			Events:      req.Events,
+		}
+
+		err = access.CheckWebhook(saver, hook, userId)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to workspace"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to check workspace access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		hook.Secret, err = random.NewToken(secretSize)
+		if err != nil {
+			log.Error("failed to generate webhook secret", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		hook.ID, err = saver.SaveWebhook(&hook)
+		if err != nil {
+			log.Error("failed to save webhook", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		log.Info("webhook added", slog.Int64("id", hook.ID))
+
+		responseOK(w, r, hook)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, hook webhook.Webhook) {
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, Response{
+		Response: response.OK(),
+		Webhook:  hook,
+	})
+}
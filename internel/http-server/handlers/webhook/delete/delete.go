@@ -0,0 +1,97 @@
+package delete
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"url-shortner/internel/domain/entities/webhook"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/storage"
+)
+
+type Response struct {
+	response.Response
+}
+
+type WebhookDeleter interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	GetWebhook(id int64) (webhook.Webhook, error)
+	DeleteWebhook(id int64) error
+}
+
+// New removes a webhook with its pending deliveries and delivery log.
+func New(log *slog.Logger, deleter WebhookDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhook.delete.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, response.Error("Invalid webhook id"))
+			return
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		hook, err := deleter.GetWebhook(id)
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("webhook not found"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to get webhook", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		err = access.CheckWebhook(deleter, hook, userId)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to webhook"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to check webhook access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		if err := deleter.DeleteWebhook(id); err != nil {
+			log.Error("Failed to delete webhook", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		log.Info("webhook deleted", slog.Int64("id", id))
+
+		responseOK(w, r)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, Response{
+		Response: response.OK(),
+	})
+}
@@ -0,0 +1,114 @@
+package deliveries
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"url-shortner/internel/domain/entities/webhook"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/storage"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+type Response struct {
+	response.Response
+	Deliveries []webhook.Delivery `json:"deliveries"`
+}
+
+type DeliveryRepository interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	GetWebhook(id int64) (webhook.Webhook, error)
+	GetWebhookDeliveries(webhookId int64, limit int) ([]webhook.Delivery, error)
+}
+
+// New returns the delivery log of a webhook, newest first: what was sent,
+// how often it was tried and how the last attempt went. ?limit= takes up to
+// 500 deliveries, 50 by default.
+func New(log *slog.Logger, repository DeliveryRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhook.deliveries.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, response.Error("Invalid webhook id"))
+			return
+		}
+
+		limit := defaultLimit
+		if s := r.URL.Query().Get("limit"); s != "" {
+			limit, err = strconv.Atoi(s)
+			if err != nil || limit < 1 || limit > maxLimit {
+				render.JSON(w, r, response.Error("limit must be between 1 and "+strconv.Itoa(maxLimit)))
+				return
+			}
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		hook, err := repository.GetWebhook(id)
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("webhook not found"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to get webhook", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		err = access.CheckWebhook(repository, hook, userId)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to webhook"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to check webhook access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		deliveries, err := repository.GetWebhookDeliveries(id, limit)
+		if err != nil {
+			log.Error("Failed to get webhook deliveries", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		responseOK(w, r, deliveries)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, deliveries []webhook.Delivery) {
+	render.JSON(w, r, Response{
+		Response:   response.OK(),
+		Deliveries: deliveries,
+	})
+}
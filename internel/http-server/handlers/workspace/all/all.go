@@ -0,0 +1,57 @@
+package all
+
+import (
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+)
+
+type Response struct {
+	response.Response
+	Workspaces []workspace.Workspace `json:"workspaces"`
+}
+
+type WorkspaceRepository interface {
+	GetUserWorkspaces(userId int64) ([]workspace.Workspace, error)
+}
+
+func New(log *slog.Logger, repository WorkspaceRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.all.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		workspaces, err := repository.GetUserWorkspaces(userId)
+		if err != nil {
+			log.Error("Failed to get workspaces", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		responseOK(w, r, workspaces)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, workspaces []workspace.Workspace) {
+	render.JSON(w, r, Response{
+		Response:   response.OK(),
+		Workspaces: workspaces,
+	})
+}
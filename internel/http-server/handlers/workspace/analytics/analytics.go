@@ -0,0 +1,93 @@
+package analytics
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"url-shortner/internel/domain/entities/analytics"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/timeseries"
+)
+
+type Response struct {
+	response.Response
+	Analytics analytics.Analytics `json:"analytics"`
+}
+
+type AnalyticsRepository interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	GetWorkspaceAnalytics(workspaceId int64, query analytics.Query) (analytics.Analytics, error)
+}
+
+// New returns the clicks of all links of a workspace over time and their top
+// countries, browsers, operating systems, platforms, referrers, languages and
+// UTM parameters, see timeseries.ParseQuery for the parameters and filters.
+func New(log *slog.Logger, repository AnalyticsRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.analytics.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		workspaceId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, response.Error("Invalid workspace id"))
+			return
+		}
+
+		query, err := timeseries.ParseQuery(r.URL.Query(), time.Now())
+		if err != nil {
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		_, err = access.Check(repository, workspaceId, userId, workspace.RoleViewer)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to workspace"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to check workspace access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		report, err := repository.GetWorkspaceAnalytics(workspaceId, query)
+		if err != nil {
+			log.Error("Failed to get workspace analytics", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		responseOK(w, r, report)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, report analytics.Analytics) {
+	render.JSON(w, r, Response{
+		Response:  response.OK(),
+		Analytics: report,
+	})
+}
@@ -0,0 +1,83 @@
+package create
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+)
+
+type Request struct {
+	Name string `json:"name" validate:"required,min=1,max=255"`
+}
+
+type Response struct {
+	response.Response
+	Workspace workspace.Workspace `json:"workspace"`
+}
+
+type WorkspaceCreator interface {
+	CreateWorkspace(name string, ownerId int64) (int64, error)
+}
+
+func New(log *slog.Logger, creator WorkspaceCreator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.create.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to render.JSON", sl.Err(err))
+			render.JSON(w, r, response.Error("failed parse JSON body"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		id, err := creator.CreateWorkspace(req.Name, userId)
+		if err != nil {
+			log.Error("failed to create workspace", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to create workspace"))
+			return
+		}
+
+		log.Info("workspace created", slog.Int64("id", id))
+
+		responseOK(w, r, workspace.Workspace{ID: id, Name: req.Name, Role: workspace.RoleOwner})
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, ws workspace.Workspace) {
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, Response{
+		Response:  response.OK(),
+		Workspace: ws,
+	})
+}
@@ -0,0 +1,78 @@
+package accept
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/storage"
+)
+
+type Response struct {
+	response.Response
+	Invitation workspace.Invitation `json:"invitation"`
+}
+
+type InvitationAcceptor interface {
+	AcceptInvitation(token string, userId int64) (workspace.Invitation, error)
+}
+
+func New(log *slog.Logger, acceptor InvitationAcceptor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.invitation.accept.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		token := chi.URLParam(r, "token")
+		if token == "" {
+			render.JSON(w, r, response.Error("invalid request"))
+			return
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		invitation, err := acceptor.AcceptInvitation(token, userId)
+		if errors.Is(err, storage.ErrInvitationNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("invitation not found or expired"))
+			return
+		}
+		if errors.Is(err, storage.ErrMemberExists) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error("already a member of the workspace"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to accept invitation", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		log.Info("invitation accepted", slog.Int64("id", invitation.ID))
+
+		responseOK(w, r, invitation)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, invitation workspace.Invitation) {
+	render.JSON(w, r, Response{
+		Response:   response.OK(),
+		Invitation: invitation,
+	})
+}
@@ -0,0 +1,153 @@
+package create
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"url-shortner/internel/domain/entities/user"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/random"
+	"url-shortner/internel/storage"
+)
+
+type Request struct {
+	Username string         `json:"username" validate:"required"`
+	Role     workspace.Role `json:"role" validate:"required,oneof=owner admin editor viewer"`
+}
+
+type Response struct {
+	response.Response
+	Invitation workspace.Invitation `json:"invitation"`
+}
+
+type InvitationSaver interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	GetUser(userName string) (user.User, error)
+	SaveInvitation(invitation *workspace.Invitation) (int64, error)
+}
+
+const (
+	tokenSize         = 24
+	invitationExpires = 7 * 24 * time.Hour
+)
+
+func New(log *slog.Logger, saver InvitationSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.invitation.create.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		workspaceId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, response.Error("Invalid workspace id"))
+			return
+		}
+
+		var req Request
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to render.JSON", sl.Err(err))
+			render.JSON(w, r, response.Error("failed parse JSON body"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		role, err := access.Check(saver, workspaceId, userId, workspace.RoleAdmin)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to workspace"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to check workspace access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		if req.Role == workspace.RoleOwner && role != workspace.RoleOwner {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("only owners can invite owners"))
+			return
+		}
+
+		invitee, err := saver.GetUser(req.Username)
+		if errors.Is(err, storage.UserNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("user not found"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to get user", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		token, err := random.NewToken(tokenSize)
+		if err != nil {
+			log.Error("Failed to generate invitation token", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		invitation := workspace.Invitation{
+			WorkspaceID: workspaceId,
+			UserID:      invitee.ID,
+			Role:        req.Role,
+			Token:       token,
+			InvitedBy:   userId,
+			Expires:     time.Now().UTC().Add(invitationExpires).Format(time.DateTime),
+		}
+
+		invitation.ID, err = saver.SaveInvitation(&invitation)
+		if err != nil {
+			log.Error("Failed to save invitation", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		log.Info("invitation created", slog.Int64("id", invitation.ID))
+
+		responseOK(w, r, invitation)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, invitation workspace.Invitation) {
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, Response{
+		Response:   response.OK(),
+		Invitation: invitation,
+	})
+}
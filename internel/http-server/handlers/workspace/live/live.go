@@ -0,0 +1,77 @@
+package live
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/clickstream"
+	"url-shortner/internel/lib/logger/sl"
+)
+
+type LiveRepository interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+}
+
+// Streamer sends clicks to a client as they happen.
+type Streamer interface {
+	Stream(w http.ResponseWriter, r *http.Request, filter clickstream.Filter) error
+}
+
+// New streams the clicks of all links of a workspace as they happen, over a
+// WebSocket when the request asks for one and as Server-Sent Events
+// otherwise, see clickstream.Hub.Stream.
+func New(log *slog.Logger, repository LiveRepository, streamer Streamer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.live.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		workspaceId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, response.Error("Invalid workspace id"))
+			return
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		_, err = access.Check(repository, workspaceId, userId, workspace.RoleViewer)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to workspace"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to check workspace access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		// the response has started, failures can only be logged
+		err = streamer.Stream(w, r, clickstream.Filter{WorkspaceId: workspaceId})
+		if errors.Is(err, clickstream.ErrDropped) {
+			log.Info("live stream dropped for falling behind")
+			return
+		}
+		if err != nil {
+			log.Warn("live stream failed", sl.Err(err))
+		}
+	}
+}
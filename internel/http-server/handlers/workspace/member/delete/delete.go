@@ -0,0 +1,121 @@
+package delete
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/storage"
+)
+
+type Response struct {
+	response.Response
+}
+
+type MemberDeleter interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	DeleteMember(workspaceId, userId int64) error
+}
+
+// New removes a member from a workspace. Members may always leave on their
+// own; removing somebody else requires the admin role, and only owners can
+// remove other owners. Links created by the member stay in the workspace.
+func New(log *slog.Logger, deleter MemberDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.member.delete.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		workspaceId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, response.Error("Invalid workspace id"))
+			return
+		}
+
+		memberId, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, response.Error("Invalid user id"))
+			return
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		minRole := workspace.RoleAdmin
+		if memberId == userId {
+			minRole = workspace.RoleViewer
+		}
+
+		role, err := access.Check(deleter, workspaceId, userId, minRole)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to workspace"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to check workspace access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		if memberId != userId && role != workspace.RoleOwner {
+			memberRole, err := deleter.GetMemberRole(workspaceId, memberId)
+			if err != nil && !errors.Is(err, storage.ErrMemberNotFound) {
+				log.Error("Failed to get member role", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal Server Error"))
+				return
+			}
+			if memberRole == workspace.RoleOwner {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("only owners can remove owners"))
+				return
+			}
+		}
+
+		err = deleter.DeleteMember(workspaceId, memberId)
+		if errors.Is(err, storage.ErrMemberNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("member not found"))
+			return
+		}
+		if errors.Is(err, storage.ErrLastOwner) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to delete member", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		log.Info("member removed", slog.Int64("user_id", memberId))
+
+		responseOK(w, r)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, Response{
+		Response: response.OK(),
+	})
+}
@@ -0,0 +1,136 @@
+package update
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/storage"
+)
+
+type Request struct {
+	Role workspace.Role `json:"role" validate:"required,oneof=owner admin editor viewer"`
+}
+
+type Response struct {
+	response.Response
+}
+
+type MemberUpdater interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	UpdateMemberRole(workspaceId, userId int64, role workspace.Role) error
+}
+
+func New(log *slog.Logger, updater MemberUpdater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.member.update.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		workspaceId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, response.Error("Invalid workspace id"))
+			return
+		}
+
+		memberId, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, response.Error("Invalid user id"))
+			return
+		}
+
+		var req Request
+
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to render.JSON", sl.Err(err))
+			render.JSON(w, r, response.Error("failed parse JSON body"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+			log.Error("invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		role, err := access.Check(updater, workspaceId, userId, workspace.RoleAdmin)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to workspace"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to check workspace access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		// only owners may hand out or take away ownership
+		if role != workspace.RoleOwner {
+			memberRole, err := updater.GetMemberRole(workspaceId, memberId)
+			if err != nil && !errors.Is(err, storage.ErrMemberNotFound) {
+				log.Error("Failed to get member role", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal Server Error"))
+				return
+			}
+			if req.Role == workspace.RoleOwner || memberRole == workspace.RoleOwner {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("only owners can change ownership"))
+				return
+			}
+		}
+
+		err = updater.UpdateMemberRole(workspaceId, memberId, req.Role)
+		if errors.Is(err, storage.ErrMemberNotFound) {
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, response.Error("member not found"))
+			return
+		}
+		if errors.Is(err, storage.ErrLastOwner) {
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to update member role", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		log.Info("member role updated", slog.Int64("user_id", memberId), slog.String("role", string(req.Role)))
+
+		responseOK(w, r)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, Response{
+		Response: response.OK(),
+	})
+}
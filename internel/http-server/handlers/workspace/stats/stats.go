@@ -0,0 +1,95 @@
+package stats
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/access"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+)
+
+type Response struct {
+	response.Response
+	Stats workspace.Stats `json:"stats"`
+}
+
+type StatsRepository interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+	GetWorkspaceStats(workspaceId int64, top int64, includeBots bool) (workspace.Stats, error)
+}
+
+const defaultTop = 10
+
+// New returns the link, click and member counts of a workspace and its ?top=
+// most clicked links. Clicks of bots are only counted with ?bots=true.
+func New(log *slog.Logger, repository StatsRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.stats.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("requestId", middleware.GetReqID(r.Context())),
+		)
+
+		workspaceId, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			render.JSON(w, r, response.Error("Invalid workspace id"))
+			return
+		}
+
+		top := int64(defaultTop)
+		if topStr := r.URL.Query().Get("top"); topStr != "" {
+			top, err = strconv.ParseInt(topStr, 10, 64)
+			if err != nil || top < 1 {
+				render.JSON(w, r, response.Error("Invalid 'top' parameter"))
+				return
+			}
+		}
+
+		userId, err := jwt.UserID(r.Context())
+		if err != nil {
+			log.Error("Failed to get claims from jwt", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		_, err = access.Check(repository, workspaceId, userId, workspace.RoleViewer)
+		if errors.Is(err, access.ErrForbidden) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("no access to workspace"))
+			return
+		}
+		if err != nil {
+			log.Error("Failed to check workspace access", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		includeBots, _ := strconv.ParseBool(r.URL.Query().Get("bots"))
+		stats, err := repository.GetWorkspaceStats(workspaceId, top, includeBots)
+		if err != nil {
+			log.Error("Failed to get workspace stats", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("Internal Server Error"))
+			return
+		}
+
+		responseOK(w, r, stats)
+	}
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, stats workspace.Stats) {
+	render.JSON(w, r, Response{
+		Response: response.OK(),
+		Stats:    stats,
+	})
+}
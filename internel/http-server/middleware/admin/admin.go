@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"url-shortner/internel/lib/api/response"
+	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/logger/sl"
+)
+
+type AdminChecker interface {
+	IsAdmin(userId int64) (bool, error)
+}
+
+// New only lets users flagged as admins through, it must run after the jwt
+// authenticator.
+func New(log *slog.Logger, checker AdminChecker) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/admin"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			userId, err := jwt.UserID(r.Context())
+			if err != nil {
+				log.Error("Failed to get claims from jwt", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal Server Error"))
+				return
+			}
+
+			isAdmin, err := checker.IsAdmin(userId)
+			if err != nil {
+				log.Error("failed to check admin flag", sl.Err(err),
+					slog.String("request_id", middleware.GetReqID(r.Context())))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("Internal Server Error"))
+				return
+			}
+			if !isAdmin {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("admin access required"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,68 @@
+package requestMetrics
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"net/http"
+	"strconv"
+	"time"
+	"url-shortner/internel/lib/metrics"
+)
+
+// unmatched is the route of requests no route matched, their paths would
+// give every scanned URL its own series.
+const unmatched = "unmatched"
+
+// New counts and times requests by method, route pattern and status in reg.
+// It has to be used on the root router, the pattern is only known once the
+// request was routed.
+func New(reg *metrics.Registry) func(next http.Handler) http.Handler {
+	requests := reg.Counter("shortener_http_requests_total",
+		"HTTP requests by method, route pattern and status.", "method", "route", "status")
+	durations := reg.Histogram("shortener_http_request_duration_seconds",
+		"How long HTTP requests took by method, route pattern and status.", nil, "method", "route", "status")
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			start := time.Now()
+			next.ServeHTTP(ww, r)
+			took := time.Since(start).Seconds()
+
+			route := unmatched
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			status := statusOf(ww)
+
+			requests.Inc(r.Method, route, status)
+			durations.Observe(took, r.Method, route, status)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Timed times next in durations, which must take the status as its only
+// label.
+func Timed(durations *metrics.HistogramVec, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		start := time.Now()
+		next.ServeHTTP(ww, r)
+
+		durations.Observe(time.Since(start).Seconds(), statusOf(ww))
+	}
+}
+
+// statusOf is the status written to ww, handlers that write nothing answer
+// with 200.
+func statusOf(ww middleware.WrapResponseWriter) string {
+	if ww.Status() == 0 {
+		return strconv.Itoa(http.StatusOK)
+	}
+
+	return strconv.Itoa(ww.Status())
+}
@@ -0,0 +1,56 @@
+package alias
+
+import "strings"
+
+// CounterGenerator encodes the next value of a counter in the alphabet,
+// left padded with its first character up to the requested length.
+type CounterGenerator struct {
+	alphabet []rune
+	counter  Counter
+}
+
+func NewCounterGenerator(alphabet string, counter Counter) *CounterGenerator {
+	return &CounterGenerator{alphabet: []rune(alphabet), counter: counter}
+}
+
+func (g *CounterGenerator) withCounter(counter Counter) AliasGenerator {
+	return &CounterGenerator{alphabet: g.alphabet, counter: counter}
+}
+
+func (g *CounterGenerator) chars() []rune {
+	return g.alphabet
+}
+
+func (g *CounterGenerator) withChars(alphabet []rune) AliasGenerator {
+	return &CounterGenerator{alphabet: alphabet, counter: g.counter}
+}
+
+func (g *CounterGenerator) Generate(length int) (string, error) {
+	n, err := g.counter.NextAliasSequence()
+	if err != nil {
+		return "", err
+	}
+
+	id := encode(uint64(n), g.alphabet)
+	if pad := length - len(id); pad > 0 {
+		id = strings.Repeat(string(g.alphabet[0]), pad) + id
+	}
+
+	return id, nil
+}
+
+// encode writes n in base len(alphabet), most significant digit first.
+func encode(n uint64, alphabet []rune) string {
+	base := uint64(len(alphabet))
+
+	var id []rune
+	for {
+		id = append([]rune{alphabet[n%base]}, id...)
+		n /= base
+		if n == 0 {
+			break
+		}
+	}
+
+	return string(id)
+}
@@ -0,0 +1,264 @@
+package alias
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"url-shortner/internel/lib/random"
+	"url-shortner/internel/storage"
+)
+
+const (
+	StrategyRandom  = "random"
+	StrategyCounter = "counter"
+	StrategySqids   = "sqids"
+
+	// Lookalikes are characters that are easily confused when a short link
+	// is read aloud or typed from print.
+	Lookalikes = "0Oo1Il"
+)
+
+var (
+	ErrExhausted       = errors.New("no free alias found")
+	ErrUnknownStrategy = errors.New("unknown alias strategy")
+	ErrInvalidAlphabet = errors.New("alias alphabet must contain at least 3 unique characters")
+)
+
+// AliasGenerator produces alias candidates. Generators that derive aliases
+// from ids treat length as the minimum length.
+type AliasGenerator interface {
+	Generate(length int) (string, error)
+}
+
+// Counter hands out unique, increasing numbers.
+type Counter interface {
+	NextAliasSequence() (int64, error)
+}
+
+type Options struct {
+	Strategy          string
+	Length            int
+	Alphabet          string
+	ExcludeLookalikes bool
+	// MaxAttempts is the number of candidates tried before giving up.
+	MaxAttempts int
+	// GrowAfter makes candidates one character longer after that many
+	// collisions in a row, 0 keeps the length.
+	GrowAfter int
+}
+
+// Allocator finds a free alias with the configured generator,
+// retrying on collisions and keeping collision statistics.
+type Allocator struct {
+	generator   AliasGenerator
+	length      int
+	maxAttempts int
+	growAfter   int
+	policy      *Policy
+	counters    *counters
+}
+
+type counters struct {
+	attempts   atomic.Int64
+	collisions atomic.Int64
+	rejected   atomic.Int64
+	exhausted  atomic.Int64
+}
+
+// counterBound is implemented by generators that draw from a Counter.
+type counterBound interface {
+	withCounter(counter Counter) AliasGenerator
+}
+
+// alphabetBound is implemented by generators that build aliases from the
+// characters of an alphabet.
+type alphabetBound interface {
+	chars() []rune
+	withChars(alphabet []rune) AliasGenerator
+}
+
+type Stats struct {
+	Strategy      string  `json:"strategy,omitempty"`
+	Attempts      int64   `json:"attempts"`
+	Collisions    int64   `json:"collisions"`
+	Rejected      int64   `json:"rejected"`
+	Exhausted     int64   `json:"exhausted"`
+	CollisionRate float64 `json:"collision_rate"`
+}
+
+func New(opts Options, counter Counter) (*Allocator, error) {
+	const op = "lib.alias.New"
+
+	alphabet := uniqueChars(opts.Alphabet)
+	if alphabet == "" {
+		alphabet = random.Base62
+	}
+	if opts.ExcludeLookalikes {
+		alphabet = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(Lookalikes, r) {
+				return -1
+			}
+			return r
+		}, alphabet)
+	}
+	if len([]rune(alphabet)) < 3 {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidAlphabet)
+	}
+
+	var generator AliasGenerator
+	switch opts.Strategy {
+	case StrategyRandom, "":
+		generator = NewRandomGenerator(alphabet)
+	case StrategyCounter:
+		generator = NewCounterGenerator(alphabet, counter)
+	case StrategySqids:
+		generator = NewSqidsGenerator(alphabet, counter)
+	default:
+		return nil, fmt.Errorf("%s: %w: %s", op, ErrUnknownStrategy, opts.Strategy)
+	}
+
+	return NewAllocator(generator, opts.Length, opts.MaxAttempts, opts.GrowAfter), nil
+}
+
+func NewAllocator(generator AliasGenerator, length, maxAttempts, growAfter int) *Allocator {
+	if length < 1 {
+		length = 1
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return &Allocator{
+		generator:   generator,
+		length:      length,
+		maxAttempts: maxAttempts,
+		growAfter:   growAfter,
+		counters:    &counters{},
+	}
+}
+
+// Bind returns an allocator that draws ids from counter instead, e.g. a
+// storage transaction, and shares statistics with a.
+func (a *Allocator) Bind(counter Counter) *Allocator {
+	bound := *a
+	if g, ok := a.generator.(counterBound); ok {
+		bound.generator = g.withCounter(counter)
+	}
+
+	return &bound
+}
+
+// UsePolicy makes the allocator normalize candidates and skip the ones the
+// policy rejects. Characters that normalize to another character of the
+// alphabet are dropped from it, so distinct candidates stay distinct aliases.
+// It must be called before the allocator is shared.
+func (a *Allocator) UsePolicy(policy *Policy) {
+	a.policy = policy
+
+	g, ok := a.generator.(alphabetBound)
+	if !ok {
+		return
+	}
+	if alphabet := foldAlphabet(g.chars(), policy); len(alphabet) < len(g.chars()) && len(alphabet) >= 3 {
+		a.generator = g.withChars(alphabet)
+	}
+}
+
+// foldAlphabet keeps the characters of alphabet that policy doesn't turn into
+// another one of them, e.g. "aAbB" becomes "ab" for case-insensitive aliases.
+func foldAlphabet(alphabet []rune, policy *Policy) []rune {
+	set := make(map[string]bool, len(alphabet))
+	for _, r := range alphabet {
+		set[string(r)] = true
+	}
+
+	var folded []rune
+	for _, r := range alphabet {
+		if normalized := policy.Normalize(string(r)); normalized == string(r) || !set[normalized] {
+			folded = append(folded, r)
+		}
+	}
+
+	return folded
+}
+
+// Allocate generates candidates and passes them to save until one is stored.
+// save must return storage.ErrURLExists when the alias is taken, any other
+// error stops the allocation.
+func (a *Allocator) Allocate(save func(alias string) error) (string, error) {
+	const op = "lib.alias.Allocate"
+
+	length := a.length
+	for attempt := 1; attempt <= a.maxAttempts; attempt++ {
+		candidate, err := a.generator.Generate(length)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+
+		a.counters.attempts.Add(1)
+
+		if a.policy != nil {
+			candidate = a.policy.Normalize(candidate)
+			if a.policy.Validate(candidate) != nil {
+				a.counters.rejected.Add(1)
+				continue
+			}
+		}
+
+		err = save(candidate)
+		if err == nil {
+			return candidate, nil
+		}
+		if !errors.Is(err, storage.ErrURLExists) {
+			return "", err
+		}
+
+		a.counters.collisions.Add(1)
+
+		if a.growAfter > 0 && attempt%a.growAfter == 0 {
+			length++
+		}
+	}
+
+	a.counters.exhausted.Add(1)
+
+	return "", ErrExhausted
+}
+
+func (a *Allocator) Stats() Stats {
+	stats := Stats{
+		Attempts:   a.counters.attempts.Load(),
+		Collisions: a.counters.collisions.Load(),
+		Rejected:   a.counters.rejected.Load(),
+		Exhausted:  a.counters.exhausted.Load(),
+	}
+	if stats.Attempts > 0 {
+		stats.CollisionRate = float64(stats.Collisions) / float64(stats.Attempts)
+	}
+
+	switch a.generator.(type) {
+	case *RandomGenerator:
+		stats.Strategy = StrategyRandom
+	case *CounterGenerator:
+		stats.Strategy = StrategyCounter
+	case *SqidsGenerator:
+		stats.Strategy = StrategySqids
+	}
+
+	return stats
+}
+
+func uniqueChars(s string) string {
+	seen := make(map[rune]bool, len(s))
+
+	var b strings.Builder
+	for _, r := range s {
+		if !seen[r] {
+			seen[r] = true
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
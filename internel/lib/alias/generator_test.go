@@ -0,0 +1,136 @@
+package alias
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"url-shortner/internel/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sequence struct {
+	next int64
+}
+
+func (s *sequence) NextAliasSequence() (int64, error) {
+	s.next++
+	return s.next, nil
+}
+
+func TestSqidsGenerator_Encode(t *testing.T) {
+	// reference ids from the Sqids spec for the default alphabet
+	g := NewSqidsGenerator("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789", nil)
+
+	tests := []struct {
+		n  uint64
+		id string
+	}{
+		{n: 0, id: "bM"},
+		{n: 1, id: "Uk"},
+		{n: 2, id: "gb"},
+		{n: 3, id: "Ef"},
+		{n: 9, id: "nJ"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.id, g.Encode(tt.n, 0))
+	}
+
+	assert.Len(t, g.Encode(1, 8), 8)
+	assert.True(t, strings.HasPrefix(g.Encode(1, 8), "Uk"))
+}
+
+func TestCounterGenerator_Generate(t *testing.T) {
+	g := NewCounterGenerator("abc", &sequence{next: 4})
+
+	alias, err := g.Generate(4)
+	require.NoError(t, err)
+	// 5 in base 3 is "12"
+	assert.Equal(t, "aabc", alias)
+
+	alias, err = g.Generate(1)
+	require.NoError(t, err)
+	assert.Equal(t, "ca", alias)
+}
+
+func TestNew_ExcludeLookalikes(t *testing.T) {
+	a, err := New(Options{Length: 200, ExcludeLookalikes: true, MaxAttempts: 1}, nil)
+	require.NoError(t, err)
+
+	alias, err := a.Allocate(func(string) error { return nil })
+	require.NoError(t, err)
+	assert.Len(t, alias, 200)
+	assert.False(t, strings.ContainsAny(alias, Lookalikes))
+
+	_, err = New(Options{Alphabet: "aab"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidAlphabet)
+
+	_, err = New(Options{Strategy: "uuid"}, nil)
+	assert.ErrorIs(t, err, ErrUnknownStrategy)
+}
+
+func TestAllocator_Allocate(t *testing.T) {
+	a := NewAllocator(NewRandomGenerator("ab"), 2, 5, 2)
+
+	var lengths []int
+	alias, err := a.Allocate(func(alias string) error {
+		lengths = append(lengths, len(alias))
+		if len(lengths) < 4 {
+			return storage.ErrURLExists
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, alias, 3)
+	assert.Equal(t, []int{2, 2, 3, 3}, lengths)
+
+	stats := a.Stats()
+	assert.Equal(t, int64(4), stats.Attempts)
+	assert.Equal(t, int64(3), stats.Collisions)
+	assert.InDelta(t, 0.75, stats.CollisionRate, 0.001)
+
+	_, err = a.Allocate(func(string) error { return storage.ErrURLExists })
+	assert.ErrorIs(t, err, ErrExhausted)
+	assert.Equal(t, int64(1), a.Stats().Exhausted)
+
+	boom := errors.New("boom")
+	_, err = a.Allocate(func(string) error { return boom })
+	assert.ErrorIs(t, err, boom)
+}
+
+func TestAllocator_UsePolicyFoldsAlphabet(t *testing.T) {
+	insensitive, err := NewPolicy(PolicyOptions{Charset: "aAbBcCdD", MaxLength: 200})
+	require.NoError(t, err)
+
+	a, err := New(Options{Strategy: StrategyCounter, Alphabet: "aAbBcCdD", Length: 1, MaxAttempts: 1}, &sequence{})
+	require.NoError(t, err)
+	a.UsePolicy(insensitive)
+
+	// without folding "A" and "a" would be drawn as two aliases
+	seen := make(map[string]bool)
+	for i := 0; i < 64; i++ {
+		alias, err := a.Allocate(func(string) error { return nil })
+		require.NoError(t, err)
+		assert.False(t, seen[alias], alias)
+		seen[alias] = true
+	}
+
+	r, err := New(Options{Alphabet: "aAbBcCdD", Length: 200, MaxAttempts: 1}, nil)
+	require.NoError(t, err)
+	r.UsePolicy(insensitive)
+	assert.Equal(t, "abcd", string(r.generator.(alphabetBound).chars()))
+
+	sensitive, err := NewPolicy(PolicyOptions{Charset: "aAbBcCdD", MaxLength: 200, CaseSensitive: true})
+	require.NoError(t, err)
+	s, err := New(Options{Alphabet: "aAbBcCdD", Length: 200, MaxAttempts: 1}, nil)
+	require.NoError(t, err)
+	s.UsePolicy(sensitive)
+	assert.Equal(t, "aAbBcCdD", string(s.generator.(alphabetBound).chars()))
+
+	// an alphabet that only differs in case keeps its uppercase letters
+	upper, err := New(Options{Alphabet: "ABCD", Length: 200, MaxAttempts: 1}, nil)
+	require.NoError(t, err)
+	upper.UsePolicy(insensitive)
+	assert.Equal(t, "ABCD", string(upper.generator.(alphabetBound).chars()))
+}
@@ -0,0 +1,246 @@
+package alias
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+	"url-shortner/internel/lib/random"
+)
+
+var (
+	ErrInvalidChars = errors.New("alias contains characters that are not allowed")
+	ErrTooShort     = errors.New("alias is too short")
+	ErrTooLong      = errors.New("alias is too long")
+	ErrReserved     = errors.New("alias is reserved")
+	ErrProfane      = errors.New("alias contains a blocked word")
+)
+
+// DefaultCharset are the characters allowed in aliases unless configured otherwise.
+const DefaultCharset = random.Base62 + "-_"
+
+type PolicyOptions struct {
+	Charset       string
+	MinLength     int
+	MaxLength     int
+	CaseSensitive bool
+	Reserved      []string
+	// ProfanityList is a file with one blocked word per line,
+	// empty lines and lines starting with # are skipped.
+	ProfanityList string
+}
+
+// Policy decides which aliases may be used for links.
+type Policy struct {
+	charset       string
+	minLength     int
+	maxLength     int
+	caseSensitive bool
+	profanity     []string
+
+	mu       sync.RWMutex
+	reserved map[string]struct{}
+}
+
+func NewPolicy(opts PolicyOptions) (*Policy, error) {
+	const op = "lib.alias.NewPolicy"
+
+	p := &Policy{
+		charset:       uniqueChars(opts.Charset),
+		minLength:     opts.MinLength,
+		maxLength:     opts.MaxLength,
+		caseSensitive: opts.CaseSensitive,
+		reserved:      make(map[string]struct{}),
+	}
+	if p.charset == "" {
+		p.charset = DefaultCharset
+	}
+	if p.minLength < 1 {
+		p.minLength = 1
+	}
+	if p.maxLength < p.minLength {
+		p.maxLength = p.minLength
+	}
+
+	p.Reserve(opts.Reserved...)
+
+	if opts.ProfanityList != "" {
+		words, err := readWordList(opts.ProfanityList)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		p.profanity = words
+	}
+
+	return p, nil
+}
+
+// Normalize returns the form an alias is stored and looked up in.
+func (p *Policy) Normalize(alias string) string {
+	if p.caseSensitive {
+		return alias
+	}
+
+	return strings.ToLower(alias)
+}
+
+// Reserve blocks words from being used as aliases, e.g. top level routes.
+func (p *Policy) Reserve(words ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			p.reserved[strings.ToLower(word)] = struct{}{}
+		}
+	}
+}
+
+// Validate checks a normalized alias against the policy.
+func (p *Policy) Validate(alias string) error {
+	length := utf8.RuneCountInString(alias)
+	if length < p.minLength {
+		return fmt.Errorf("%w, minimum is %d", ErrTooShort, p.minLength)
+	}
+	if length > p.maxLength {
+		return fmt.Errorf("%w, maximum is %d", ErrTooLong, p.maxLength)
+	}
+
+	for _, r := range alias {
+		if !strings.ContainsRune(p.charset, r) {
+			return ErrInvalidChars
+		}
+	}
+
+	lower := strings.ToLower(alias)
+
+	p.mu.RLock()
+	_, reserved := p.reserved[lower]
+	p.mu.RUnlock()
+	if reserved {
+		return ErrReserved
+	}
+
+	plain := unleet(lower)
+	for _, word := range p.profanity {
+		if strings.Contains(lower, word) || strings.Contains(plain, word) {
+			return ErrProfane
+		}
+	}
+
+	return nil
+}
+
+// Suggest returns up to n aliases similar to alias that pass the policy and
+// are not taken.
+func (p *Policy) Suggest(alias string, n int, taken func(alias string) (bool, error)) ([]string, error) {
+	base := p.sanitize(alias)
+
+	separator := ""
+	if strings.ContainsRune(p.charset, '-') {
+		separator = "-"
+	}
+
+	suffixChars := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' {
+			return -1
+		}
+		return r
+	}, p.Normalize(p.charset))
+
+	var candidates []string
+	for i := 1; i <= n; i++ {
+		candidates = append(candidates, p.fit(base, strconv.Itoa(i)))
+	}
+	for i := 0; i < 2*n && suffixChars != ""; i++ {
+		suffix, err := random.NewString(suffixChars, 3)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, p.fit(base, separator+suffix))
+	}
+
+	suggestions := make([]string, 0, n)
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if len(suggestions) == n {
+			break
+		}
+		if seen[candidate] || p.Validate(candidate) != nil {
+			continue
+		}
+		seen[candidate] = true
+
+		isTaken, err := taken(candidate)
+		if err != nil {
+			return nil, err
+		}
+		if !isTaken {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+
+	return suggestions, nil
+}
+
+// sanitize drops characters outside of the charset.
+func (p *Policy) sanitize(alias string) string {
+	return strings.Map(func(r rune) rune {
+		if !strings.ContainsRune(p.charset, r) {
+			return -1
+		}
+		return r
+	}, p.Normalize(alias))
+}
+
+// fit appends suffix to base, cutting base so the result stays within the
+// maximum length.
+func (p *Policy) fit(base, suffix string) string {
+	runes := []rune(base)
+	if keep := p.maxLength - utf8.RuneCountInString(suffix); len(runes) > keep {
+		runes = runes[:max(keep, 0)]
+	}
+
+	return string(runes) + suffix
+}
+
+var leetReplacer = strings.NewReplacer(
+	"0", "o",
+	"1", "i",
+	"3", "e",
+	"4", "a",
+	"5", "s",
+	"7", "t",
+	"-", "",
+	"_", "",
+)
+
+// unleet undoes the usual digit for letter substitutions and separators
+// used to sneak blocked words past a plain comparison.
+func unleet(s string) string {
+	return leetReplacer.Replace(s)
+}
+
+func readWordList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+
+	return words, scanner.Err()
+}
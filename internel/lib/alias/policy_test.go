@@ -0,0 +1,63 @@
+package alias
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Validate(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "profanity.txt")
+	require.NoError(t, os.WriteFile(list, []byte("# blocked\nbadword\n"), 0o600))
+
+	p, err := NewPolicy(PolicyOptions{MinLength: 3, MaxLength: 10, Reserved: []string{"admin"}, ProfanityList: list})
+	require.NoError(t, err)
+	p.Reserve("url")
+
+	tests := []struct {
+		alias string
+		err   error
+	}{
+		{alias: "my-link", err: nil},
+		{alias: "ab", err: ErrTooShort},
+		{alias: "abcdefghijk", err: ErrTooLong},
+		{alias: "with space", err: ErrInvalidChars},
+		{alias: "Admin", err: ErrReserved},
+		{alias: "url", err: ErrReserved},
+		{alias: "xBadWord", err: ErrProfane},
+		{alias: "b4d-w0rd", err: ErrProfane},
+	}
+	for _, tt := range tests {
+		assert.ErrorIs(t, p.Validate(tt.alias), tt.err, tt.alias)
+	}
+}
+
+func TestPolicy_Suggest(t *testing.T) {
+	p, err := NewPolicy(PolicyOptions{MinLength: 3, MaxLength: 8})
+	require.NoError(t, err)
+
+	taken := map[string]bool{"promo1": true}
+	suggestions, err := p.Suggest("promo", 3, func(alias string) (bool, error) {
+		return taken[alias], nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, suggestions, 3)
+	for _, s := range suggestions {
+		assert.NotEqual(t, "promo1", s)
+		assert.NoError(t, p.Validate(s))
+	}
+}
+
+func TestPolicy_Normalize(t *testing.T) {
+	p, err := NewPolicy(PolicyOptions{MinLength: 1, MaxLength: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "promo", p.Normalize("PrOmO"))
+
+	p, err = NewPolicy(PolicyOptions{MinLength: 1, MaxLength: 10, CaseSensitive: true})
+	require.NoError(t, err)
+	assert.Equal(t, "PrOmO", p.Normalize("PrOmO"))
+}
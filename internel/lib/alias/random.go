@@ -0,0 +1,24 @@
+package alias
+
+import "url-shortner/internel/lib/random"
+
+// RandomGenerator picks every character from the alphabet with crypto/rand.
+type RandomGenerator struct {
+	alphabet string
+}
+
+func NewRandomGenerator(alphabet string) *RandomGenerator {
+	return &RandomGenerator{alphabet: alphabet}
+}
+
+func (g *RandomGenerator) Generate(length int) (string, error) {
+	return random.NewString(g.alphabet, length)
+}
+
+func (g *RandomGenerator) chars() []rune {
+	return []rune(g.alphabet)
+}
+
+func (g *RandomGenerator) withChars(alphabet []rune) AliasGenerator {
+	return NewRandomGenerator(string(alphabet))
+}
@@ -0,0 +1,80 @@
+package alias
+
+// SqidsGenerator turns counter values into short ids that don't look
+// sequential, following the Sqids (https://sqids.org) encoding of a single
+// number: the alphabet is shuffled once, rotated by an offset derived from
+// the number and the first character of the rotated alphabet is used as a
+// prefix. Changing the alphabet order changes every generated id.
+type SqidsGenerator struct {
+	alphabet []rune
+	counter  Counter
+}
+
+func NewSqidsGenerator(alphabet string, counter Counter) *SqidsGenerator {
+	return &SqidsGenerator{alphabet: shuffle([]rune(alphabet)), counter: counter}
+}
+
+func (g *SqidsGenerator) withCounter(counter Counter) AliasGenerator {
+	return &SqidsGenerator{alphabet: g.alphabet, counter: counter}
+}
+
+func (g *SqidsGenerator) chars() []rune {
+	return g.alphabet
+}
+
+// withChars keeps the shuffled order of the characters, alphabet must be a
+// subset of the ones of g.
+func (g *SqidsGenerator) withChars(alphabet []rune) AliasGenerator {
+	return &SqidsGenerator{alphabet: alphabet, counter: g.counter}
+}
+
+func (g *SqidsGenerator) Generate(length int) (string, error) {
+	n, err := g.counter.NextAliasSequence()
+	if err != nil {
+		return "", err
+	}
+
+	return g.Encode(uint64(n), length), nil
+}
+
+// Encode returns the id of n, at least minLength characters long.
+func (g *SqidsGenerator) Encode(n uint64, minLength int) string {
+	size := len(g.alphabet)
+
+	// a single number is encoded, so the offset is 1 + alphabet[n % size]
+	offset := (int(g.alphabet[n%uint64(size)]) + 1) % size
+
+	alphabet := append(append([]rune{}, g.alphabet[offset:]...), g.alphabet[:offset]...)
+	prefix := alphabet[0]
+	reverse(alphabet)
+
+	id := append([]rune{prefix}, []rune(encode(n, alphabet[1:]))...)
+
+	if minLength > len(id) {
+		id = append(id, alphabet[0])
+
+		for minLength > len(id) {
+			alphabet = shuffle(alphabet)
+			id = append(id, alphabet[:min(minLength-len(id), size)]...)
+		}
+	}
+
+	return string(id)
+}
+
+func shuffle(alphabet []rune) []rune {
+	chars := append([]rune{}, alphabet...)
+
+	for i, j := 0, len(chars)-1; j > 0; i, j = i+1, j-1 {
+		r := (i*j + int(chars[i]) + int(chars[j])) % len(chars)
+		chars[i], chars[r] = chars[r], chars[i]
+	}
+
+	return chars
+}
+
+func reverse(chars []rune) {
+	for i, j := 0, len(chars)-1; i < j; i, j = i+1, j-1 {
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+}
@@ -0,0 +1,82 @@
+package access
+
+import (
+	"errors"
+	"fmt"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/webhook"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/storage"
+)
+
+var ErrForbidden = errors.New("access denied")
+
+type RoleGetter interface {
+	GetMemberRole(workspaceId, userId int64) (workspace.Role, error)
+}
+
+// Check returns the user's role in the workspace, or ErrForbidden when the
+// user is not a member or their role is lower than min.
+func Check(getter RoleGetter, workspaceId, userId int64, min workspace.Role) (workspace.Role, error) {
+	const op = "lib.auth.access.Check"
+
+	role, err := getter.GetMemberRole(workspaceId, userId)
+	if errors.Is(err, storage.ErrMemberNotFound) {
+		return "", ErrForbidden
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !role.AtLeast(min) {
+		return role, ErrForbidden
+	}
+
+	return role, nil
+}
+
+// CheckDomain allows managing personal domains to their owner and workspace
+// domains to workspace admins, otherwise ErrForbidden is returned.
+func CheckDomain(getter RoleGetter, d domain.Domain, userId int64) error {
+	if d.WorkspaceId == 0 {
+		if d.UserId != userId {
+			return ErrForbidden
+		}
+		return nil
+	}
+
+	_, err := Check(getter, d.WorkspaceId, userId, workspace.RoleAdmin)
+
+	return err
+}
+
+// CheckWebhook allows managing personal webhooks to their owner and workspace
+// webhooks to workspace admins, otherwise ErrForbidden is returned.
+func CheckWebhook(getter RoleGetter, hook webhook.Webhook, userId int64) error {
+	if hook.WorkspaceId == 0 {
+		if hook.UserId != userId {
+			return ErrForbidden
+		}
+		return nil
+	}
+
+	_, err := Check(getter, hook.WorkspaceId, userId, workspace.RoleAdmin)
+	return err
+}
+
+// CheckLink allows access to personal links to their owner and to workspace
+// links to members with at least the min role, otherwise ErrForbidden is
+// returned.
+func CheckLink(getter RoleGetter, link urlInfo.UrlInfo, userId int64, min workspace.Role) error {
+	if link.WorkspaceId == 0 {
+		if link.User.ID != userId {
+			return ErrForbidden
+		}
+		return nil
+	}
+
+	_, err := Check(getter, link.WorkspaceId, userId, min)
+
+	return err
+}
@@ -1,6 +1,8 @@
 package jwt
 
 import (
+	"context"
+	"errors"
 	"github.com/go-chi/jwtauth/v5"
 	"os"
 	"time"
@@ -8,6 +10,8 @@ import (
 
 var TokenAuth *jwtauth.JWTAuth
 
+var ErrNoUserID = errors.New("token has no user_id claim")
+
 func Init() {
 	TokenAuth = jwtauth.New(os.Getenv("JWT_ALGO"), []byte(os.Getenv("JWT_SECRET")), nil)
 }
@@ -30,3 +34,19 @@ func GenerateToken(userId int64) (string, error) {
 
 	return tokenString, nil
 }
+
+// UserID returns the id of the user the verified request token was issued to.
+func UserID(ctx context.Context) (int64, error) {
+	_, claims, err := jwtauth.FromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// JSON numbers are decoded as float64
+	userId, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, ErrNoUserID
+	}
+
+	return int64(userId), nil
+}
@@ -0,0 +1,81 @@
+package linkpass
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortner/internel/domain/entities/urlInfo"
+)
+
+// Cookies remembers for a while that a visitor entered the password of a
+// link. The cookie is signed over the link's password hash, so changing the
+// password locks everybody out again.
+type Cookies struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// New signs cookies with secret, an empty secret is replaced by a random one,
+// which makes cookies invalid after a restart.
+func New(secret string, ttl time.Duration) (*Cookies, error) {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Cookies{secret: key, ttl: ttl, now: time.Now}, nil
+}
+
+// Unlocked reports whether the request carries a valid cookie for link.
+func (c *Cookies) Unlocked(r *http.Request, link urlInfo.UrlInfo) bool {
+	cookie, err := r.Cookie(cookieName(link))
+	if err != nil {
+		return false
+	}
+
+	expires, mac, ok := strings.Cut(cookie.Value, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || c.now().Unix() >= unix {
+		return false
+	}
+
+	return hmac.Equal([]byte(mac), []byte(c.sign(link, unix)))
+}
+
+// Unlock sets the cookie for link.
+func (c *Cookies) Unlock(w http.ResponseWriter, r *http.Request, link urlInfo.UrlInfo) {
+	expires := c.now().Add(c.ttl)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     cookieName(link),
+		Value:    strconv.FormatInt(expires.Unix(), 10) + "." + c.sign(link, expires.Unix()),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (c *Cookies) sign(link urlInfo.UrlInfo, expires int64) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(strconv.FormatInt(link.Id, 10) + "|" + strconv.FormatInt(expires, 10) + "|" + link.PasswordHash))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func cookieName(link urlInfo.UrlInfo) string {
+	return "link_" + strconv.FormatInt(link.Id, 10)
+}
@@ -0,0 +1,40 @@
+package linkpass
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"url-shortner/internel/domain/entities/urlInfo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCookies(t *testing.T) {
+	c, err := New("secret", time.Hour)
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	link := urlInfo.UrlInfo{Id: 7, PasswordHash: "hash"}
+
+	w := httptest.NewRecorder()
+	c.Unlock(w, httptest.NewRequest(http.MethodPost, "/abc", nil), link)
+
+	r := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	for _, cookie := range w.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+
+	assert.True(t, c.Unlocked(r, link))
+	assert.False(t, c.Unlocked(r, urlInfo.UrlInfo{Id: 7, PasswordHash: "changed"}), "password changed")
+	assert.False(t, c.Unlocked(r, urlInfo.UrlInfo{Id: 8, PasswordHash: "hash"}), "other link")
+
+	other, err := New("other", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, other.Unlocked(r, link), "other secret")
+
+	now = now.Add(time.Hour)
+	assert.False(t, c.Unlocked(r, link), "expired")
+}
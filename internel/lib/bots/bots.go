@@ -0,0 +1,69 @@
+package bots
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mssola/useragent"
+)
+
+//go:embed signatures.txt
+var defaultSignatures string
+
+// Detector tells bots from people by their user agent.
+type Detector struct {
+	signatures []string
+}
+
+// New uses the built in signatures and those in the file at path, if any.
+// Signature files have one user agent substring per line, empty lines and
+// lines starting with # are skipped.
+func New(path string) (*Detector, error) {
+	const op = "lib.bots.New"
+
+	signatures := parseSignatures(defaultSignatures)
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		signatures = append(signatures, parseSignatures(string(data))...)
+	}
+
+	return &Detector{signatures: signatures}, nil
+}
+
+// IsBot reports whether the user agent ua, parsed from raw, is a bot.
+// Requests without a user agent are counted as bots too.
+func (d *Detector) IsBot(ua *useragent.UserAgent, raw string) bool {
+	if strings.TrimSpace(raw) == "" || ua.Bot() {
+		return true
+	}
+
+	raw = strings.ToLower(raw)
+	for _, signature := range d.signatures {
+		if strings.Contains(raw, signature) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseSignatures(data string) []string {
+	var signatures []string
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		signatures = append(signatures, line)
+	}
+
+	return signatures
+}
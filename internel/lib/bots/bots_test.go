@@ -0,0 +1,39 @@
+package bots
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mssola/useragent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetector_IsBot(t *testing.T) {
+	extra := filepath.Join(t.TempDir(), "bots.txt")
+	require.NoError(t, os.WriteFile(extra, []byte("# ours\nInternalMonitor\n"), 0o600))
+
+	d, err := New(extra)
+	require.NoError(t, err)
+
+	tests := []struct {
+		ua  string
+		bot bool
+	}{
+		{ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36", bot: false},
+		{ua: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1", bot: false},
+		{ua: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", bot: true},
+		{ua: "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", bot: true},
+		{ua: "Twitterbot/1.0", bot: true},
+		{ua: "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", bot: true},
+		{ua: "Mozilla/5.0 (compatible; UptimeRobot/2.0; http://www.uptimerobot.com/)", bot: true},
+		{ua: "curl/8.4.0", bot: true},
+		{ua: "Go-http-client/1.1", bot: true},
+		{ua: "Mozilla/5.0 InternalMonitor/3", bot: true},
+		{ua: "", bot: true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.bot, d.IsBot(useragent.New(tt.ua), tt.ua), tt.ua)
+	}
+}
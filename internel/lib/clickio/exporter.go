@@ -0,0 +1,104 @@
+package clickio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+	"url-shortner/internel/domain/entities/redirectInfo"
+	"url-shortner/internel/lib/timeseries"
+)
+
+var ErrBotsFilter = errors.New("bots must be true or false")
+
+type Source interface {
+	ExportRedirectInfo(ctx context.Context, filter redirectInfo.Filter, fn func(redirectInfo.RedirectInfo) error) error
+}
+
+type ExportOptions struct {
+	Filter redirectInfo.Filter
+	// HideIPs leaves the IPs of visitors out.
+	HideIPs bool
+}
+
+// Export writes the clicks of src selected by opts and returns how many
+// were written.
+func Export(ctx context.Context, src Source, w Writer, opts ExportOptions) (int, error) {
+	const op = "lib.clickio.Export"
+
+	count := 0
+	err := src.ExportRedirectInfo(ctx, opts.Filter, func(click redirectInfo.RedirectInfo) error {
+		if opts.HideIPs {
+			click.Ip = ""
+		}
+		count++
+
+		return w.Write(click)
+	})
+	if err != nil {
+		return count, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return count, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// ParseFilter reads the ?from= and ?to= times, as RFC 3339 or dates in ?tz=,
+// ?bots=false and the analytics filter dimensions. A date as to includes
+// that day. The link or workspace is left to the caller.
+func ParseFilter(values url.Values) (redirectInfo.Filter, error) {
+	var filter redirectInfo.Filter
+
+	loc := time.UTC
+	if tz := values.Get("tz"); tz != "" {
+		var err error
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			return redirectInfo.Filter{}, fmt.Errorf("%w: %s", timeseries.ErrTimezone, tz)
+		}
+	}
+
+	if s := values.Get("from"); s != "" {
+		from, _, err := timeseries.ParseTime(s, loc)
+		if err != nil {
+			return redirectInfo.Filter{}, err
+		}
+		filter.From = from
+	}
+
+	if s := values.Get("to"); s != "" {
+		to, date, err := timeseries.ParseTime(s, loc)
+		if err != nil {
+			return redirectInfo.Filter{}, err
+		}
+		if date {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = to
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return redirectInfo.Filter{}, timeseries.ErrRange
+	}
+
+	if bots := values.Get("bots"); bots != "" {
+		include, err := strconv.ParseBool(bots)
+		if err != nil {
+			return redirectInfo.Filter{}, ErrBotsFilter
+		}
+		filter.ExcludeBots = !include
+	}
+
+	dimensions, err := timeseries.ParseFilters(values)
+	if err != nil {
+		return redirectInfo.Filter{}, err
+	}
+	filter.Dimensions = dimensions
+
+	return filter, nil
+}
@@ -0,0 +1,130 @@
+package clickio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"url-shortner/internel/domain/entities/redirectInfo"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/lib/timeseries"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sliceSource []redirectInfo.RedirectInfo
+
+func (s sliceSource) ExportRedirectInfo(_ context.Context, _ redirectInfo.Filter, fn func(redirectInfo.RedirectInfo) error) error {
+	for _, click := range s {
+		if err := fn(click); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var clicks = sliceSource{
+	{Id: 1, Alias: "promo", Created: "2026-01-02T03:04:05Z", Ip: "203.0.113.0", CountryCode: "DE", IsBot: true,
+		UTM: urlInfo.UTM{Source: "news,letter"}},
+	{Id: 2, Alias: "promo", Created: "2026-01-02 04:00:00", ViaQR: true},
+}
+
+func TestExport_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCSV, &buf)
+	require.NoError(t, err)
+
+	count, err := Export(context.Background(), clicks, w, ExportOptions{HideIPs: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "id,alias,created_at,ip,country_code,"))
+	assert.True(t, strings.HasPrefix(lines[1], "1,promo,2026-01-02T03:04:05Z,,DE,"))
+	assert.Contains(t, lines[1], `,true,`)
+	assert.Contains(t, lines[1], `"news,letter"`)
+	assert.True(t, strings.HasPrefix(lines[2], "2,promo,2026-01-02T04:00:00Z,"))
+}
+
+func TestExport_NDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatNDJSON, &buf)
+	require.NoError(t, err)
+
+	_, err = Export(context.Background(), clicks, w, ExportOptions{})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], `{"id":1,"alias":"promo","created_at":"2026-01-02T03:04:05Z","ip":"203.0.113.0"`))
+
+	var row map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &row))
+	assert.Equal(t, true, row["via_qr"])
+	assert.Equal(t, "", row["utm_source"])
+}
+
+func TestExport_Parquet(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatParquet, &buf)
+	require.NoError(t, err)
+
+	_, err = Export(context.Background(), clicks, w, ExportOptions{})
+	require.NoError(t, err)
+
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(clicks)), file.NumRows())
+	require.Len(t, file.Schema().Fields(), len(columns))
+	for i, field := range file.Schema().Fields() {
+		assert.Equal(t, columns[i].Name, field.Name())
+	}
+
+	type row struct {
+		Id        int64     `parquet:"id"`
+		Alias     string    `parquet:"alias"`
+		Created   time.Time `parquet:"created_at,timestamp(millisecond)"`
+		Ip        string    `parquet:"ip"`
+		IsBot     bool      `parquet:"is_bot"`
+		ViaQR     bool      `parquet:"via_qr"`
+		UTMSource string    `parquet:"utm_source"`
+	}
+	rows, err := parquet.Read[row](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, row{Id: 1, Alias: "promo", Created: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Ip: "203.0.113.0",
+		IsBot: true, UTMSource: "news,letter"}, rows[0])
+	assert.Equal(t, int64(2), rows[1].Id)
+	assert.True(t, rows[1].ViaQR)
+	assert.True(t, rows[1].Created.Equal(time.Date(2026, 1, 2, 4, 0, 0, 0, time.UTC)))
+
+	_, err = NewWriter("xlsx", &buf)
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter(url.Values{})
+	require.NoError(t, err)
+	assert.True(t, filter.From.IsZero())
+	assert.False(t, filter.ExcludeBots)
+
+	filter, err = ParseFilter(url.Values{
+		"from": {"2026-01-01"}, "to": {"2026-01-31"}, "tz": {"Europe/Berlin"}, "bots": {"false"}, "country": {"de"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC), filter.From.UTC())
+	assert.Equal(t, time.Date(2026, 1, 31, 23, 0, 0, 0, time.UTC), filter.To.UTC())
+	assert.True(t, filter.ExcludeBots)
+	assert.Equal(t, map[string]string{"country": "DE"}, filter.Dimensions)
+
+	_, err = ParseFilter(url.Values{"from": {"2026-02-01"}, "to": {"2026-01-01"}})
+	assert.ErrorIs(t, err, timeseries.ErrRange)
+	_, err = ParseFilter(url.Values{"bots": {"some"}})
+	assert.ErrorIs(t, err, ErrBotsFilter)
+}
@@ -0,0 +1,206 @@
+package clickio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+	"url-shortner/internel/domain/entities/redirectInfo"
+	"url-shortner/internel/lib/parquet"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatParquet = "parquet"
+)
+
+var ErrUnknownFormat = errors.New("unknown format")
+
+// column is a field of exported clicks, Type is how it is stored in parquet.
+type column struct {
+	Name  string
+	Type  int
+	Value func(click *redirectInfo.RedirectInfo) any
+}
+
+var columns = []column{
+	{"id", parquet.Int64, func(c *redirectInfo.RedirectInfo) any { return c.Id }},
+	{"alias", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.Alias }},
+	{"created_at", parquet.Timestamp, func(c *redirectInfo.RedirectInfo) any { return parseCreated(c.Created) }},
+	{"ip", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.Ip }},
+	{"country_code", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.CountryCode }},
+	{"country", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.Country }},
+	{"city", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.City }},
+	{"os", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.Os }},
+	{"platform", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.Platform }},
+	{"browser", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.Browser }},
+	{"rule_id", parquet.Int64, func(c *redirectInfo.RedirectInfo) any { return c.RuleId }},
+	{"variant_id", parquet.Int64, func(c *redirectInfo.RedirectInfo) any { return c.VariantId }},
+	{"is_bot", parquet.Bool, func(c *redirectInfo.RedirectInfo) any { return c.IsBot }},
+	{"referrer_host", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.ReferrerHost }},
+	{"referrer_path", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.ReferrerPath }},
+	{"language", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.Language }},
+	{"via_qr", parquet.Bool, func(c *redirectInfo.RedirectInfo) any { return c.ViaQR }},
+	{"utm_source", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.UTM.Source }},
+	{"utm_medium", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.UTM.Medium }},
+	{"utm_campaign", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.UTM.Campaign }},
+	{"utm_term", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.UTM.Term }},
+	{"utm_content", parquet.String, func(c *redirectInfo.RedirectInfo) any { return c.UTM.Content }},
+}
+
+type Writer interface {
+	Write(click redirectInfo.RedirectInfo) error
+	// Flush writes what is buffered, and for parquet the footer, it is
+	// called once after the last click.
+	Flush() error
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "text/csv"
+	}
+}
+
+// NewWriter writes clicks in format, with the same columns in all of them.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{w: bufio.NewWriter(w)}, nil
+	case FormatParquet:
+		parquetColumns := make([]parquet.Column, len(columns))
+		for i, col := range columns {
+			parquetColumns[i] = parquet.Column{Name: col.Name, Type: col.Type}
+		}
+		buf := bufio.NewWriter(w)
+		return &parquetWriter{buf: buf, writer: parquet.NewWriter(buf, parquetColumns, 0)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+type csvWriter struct {
+	writer      *csv.Writer
+	wroteHeader bool
+}
+
+func (c *csvWriter) header() error {
+	if c.wroteHeader {
+		return nil
+	}
+	c.wroteHeader = true
+
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+
+	return c.writer.Write(names)
+}
+
+func (c *csvWriter) Write(click redirectInfo.RedirectInfo) error {
+	if err := c.header(); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for i, col := range columns {
+		switch v := col.Value(&click).(type) {
+		case int64:
+			record[i] = strconv.FormatInt(v, 10)
+		case bool:
+			record[i] = strconv.FormatBool(v)
+		case time.Time:
+			record[i] = v.Format(time.RFC3339)
+		case string:
+			record[i] = v
+		}
+	}
+
+	return c.writer.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	if err := c.header(); err != nil {
+		return err
+	}
+
+	c.writer.Flush()
+
+	return c.writer.Error()
+}
+
+type ndjsonWriter struct {
+	w *bufio.Writer
+}
+
+// Write writes click as an object with the keys in column order.
+func (n *ndjsonWriter) Write(click redirectInfo.RedirectInfo) error {
+	n.w.WriteByte('{')
+	for i, col := range columns {
+		if i > 0 {
+			n.w.WriteByte(',')
+		}
+		value, err := json.Marshal(col.Value(&click))
+		if err != nil {
+			return err
+		}
+		n.w.WriteString(strconv.Quote(col.Name))
+		n.w.WriteByte(':')
+		n.w.Write(value)
+	}
+	n.w.WriteString("}\n")
+
+	// errors of the writer are sticky and returned by Flush
+	return nil
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return n.w.Flush()
+}
+
+type parquetWriter struct {
+	buf    *bufio.Writer
+	writer *parquet.Writer
+	row    []any
+}
+
+func (p *parquetWriter) Write(click redirectInfo.RedirectInfo) error {
+	p.row = p.row[:0]
+	for _, col := range columns {
+		p.row = append(p.row, col.Value(&click))
+	}
+
+	return p.writer.Write(p.row...)
+}
+
+func (p *parquetWriter) Flush() error {
+	if err := p.writer.Close(); err != nil {
+		return err
+	}
+
+	return p.buf.Flush()
+}
+
+// parseCreated reads the time a click was stored with, the zero time when
+// it is not known.
+func parseCreated(created string) time.Time {
+	for _, layout := range []string{time.RFC3339, time.DateTime} {
+		if t, err := time.Parse(layout, created); err == nil {
+			return t.UTC()
+		}
+	}
+
+	return time.Time{}
+}
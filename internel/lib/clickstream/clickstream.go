@@ -0,0 +1,195 @@
+package clickstream
+
+import (
+	"sync"
+	"time"
+	"url-shortner/internel/domain/entities/redirectInfo"
+	"url-shortner/internel/domain/entities/urlInfo"
+)
+
+// DefaultBuffer is how many clicks a subscriber may fall behind by default.
+const DefaultBuffer = 64
+
+// Click is what subscribers see of a click. It leaves out the IP and visitor
+// hash, so streams show the same for every viewer.
+type Click struct {
+	UrlId        int64       `json:"url_id"`
+	WorkspaceId  int64       `json:"workspace_id,omitempty"`
+	Alias        string      `json:"alias"`
+	Time         time.Time   `json:"time"`
+	CountryCode  string      `json:"country_code,omitempty"`
+	Country      string      `json:"country,omitempty"`
+	City         string      `json:"city,omitempty"`
+	Os           string      `json:"os,omitempty"`
+	Platform     string      `json:"platform,omitempty"`
+	Browser      string      `json:"browser,omitempty"`
+	ReferrerHost string      `json:"referrer_host,omitempty"`
+	Language     string      `json:"language,omitempty"`
+	RuleId       int64       `json:"rule_id,omitempty"`
+	VariantId    int64       `json:"variant_id,omitempty"`
+	ViaQR        bool        `json:"via_qr"`
+	IsBot        bool        `json:"is_bot"`
+	UTM          urlInfo.UTM `json:"utm"`
+}
+
+// FromRedirectInfo is the click of info on a link of the workspace, 0 for
+// links without one, made at t.
+func FromRedirectInfo(info *redirectInfo.RedirectInfo, workspaceId int64, t time.Time) Click {
+	return Click{
+		UrlId:        info.UrlId,
+		WorkspaceId:  workspaceId,
+		Alias:        info.Alias,
+		Time:         t.UTC(),
+		CountryCode:  info.CountryCode,
+		Country:      info.Country,
+		City:         info.City,
+		Os:           info.Os,
+		Platform:     info.Platform,
+		Browser:      info.Browser,
+		ReferrerHost: info.ReferrerHost,
+		Language:     info.Language,
+		RuleId:       info.RuleId,
+		VariantId:    info.VariantId,
+		ViaQR:        info.ViaQR,
+		IsBot:        info.IsBot,
+		UTM:          info.UTM,
+	}
+}
+
+// Filter selects the clicks of a subscription, the clicks of one link or of
+// all links of a workspace. Its zero value selects all clicks.
+type Filter struct {
+	UrlId       int64
+	WorkspaceId int64
+}
+
+func (f Filter) match(c Click) bool {
+	return (f.UrlId == 0 || f.UrlId == c.UrlId) && (f.WorkspaceId == 0 || f.WorkspaceId == c.WorkspaceId)
+}
+
+// Options of a Hub.
+type Options struct {
+	// Buffer is how many clicks a subscriber may fall behind before it is
+	// dropped, DefaultBuffer when it is 0.
+	Buffer int
+	// Heartbeat is how often streams without clicks send a keep alive, 0
+	// sends none.
+	Heartbeat time.Duration
+	// WriteTimeout is how long writing to a stream may take before the
+	// client is disconnected, 0 waits forever.
+	WriteTimeout time.Duration
+}
+
+// Hub passes published clicks on to the subscriptions they match. Publishing
+// never waits for subscribers: one that has Buffer clicks waiting is dropped,
+// so slow clients can't hold up redirects.
+type Hub struct {
+	opts Options
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+func New(opts Options) *Hub {
+	if opts.Buffer <= 0 {
+		opts.Buffer = DefaultBuffer
+	}
+
+	return &Hub{opts: opts, subs: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives the clicks matching its filter until it is closed or
+// dropped.
+type Subscription struct {
+	hub    *Hub
+	filter Filter
+	clicks chan Click
+	// dropped is only written by the hub with its lock held before clicks is
+	// closed, so it can be read once clicks is.
+	dropped bool
+}
+
+// Subscribe starts a subscription to the clicks matching filter, it has to be
+// closed when it is not needed anymore.
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	s := &Subscription{hub: h, filter: filter, clicks: make(chan Click, h.opts.Buffer)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(s.clicks)
+		return s
+	}
+	h.subs[s] = struct{}{}
+
+	return s
+}
+
+// Clicks are the clicks of the subscription, closed when it is closed or
+// dropped.
+func (s *Subscription) Clicks() <-chan Click {
+	return s.clicks
+}
+
+// Dropped reports whether the subscription was ended for falling behind, it
+// is only meaningful once Clicks is closed.
+func (s *Subscription) Dropped() bool {
+	return s.dropped
+}
+
+// Close ends the subscription, closing it again does nothing.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.remove(s)
+}
+
+// Publish passes c on to the subscriptions it matches.
+func (h *Hub) Publish(c Click) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for s := range h.subs {
+		if !s.filter.match(c) {
+			continue
+		}
+		select {
+		case s.clicks <- c:
+		default:
+			s.dropped = true
+			h.remove(s)
+		}
+	}
+}
+
+// Subscribers is the number of open subscriptions.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subs)
+}
+
+// Close ends all subscriptions and those made afterwards, so streams finish
+// when the server shuts down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for s := range h.subs {
+		h.remove(s)
+	}
+}
+
+// remove needs h.mu to be held.
+func (h *Hub) remove(s *Subscription) {
+	if _, ok := h.subs[s]; !ok {
+		return
+	}
+	delete(h.subs, s)
+	close(s.clicks)
+}
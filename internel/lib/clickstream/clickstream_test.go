@@ -0,0 +1,141 @@
+package clickstream
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func TestHub_Publish(t *testing.T) {
+	hub := New(Options{Buffer: 2})
+
+	link := hub.Subscribe(Filter{UrlId: 1})
+	defer link.Close()
+	ws := hub.Subscribe(Filter{WorkspaceId: 7})
+	defer ws.Close()
+
+	hub.Publish(Click{UrlId: 1, WorkspaceId: 7})
+	hub.Publish(Click{UrlId: 2, WorkspaceId: 7})
+	hub.Publish(Click{UrlId: 3})
+
+	assert.Equal(t, int64(1), (<-link.Clicks()).UrlId)
+	assert.Empty(t, link.Clicks())
+	assert.Equal(t, int64(1), (<-ws.Clicks()).UrlId)
+	assert.Equal(t, int64(2), (<-ws.Clicks()).UrlId)
+	assert.Empty(t, ws.Clicks())
+	assert.Equal(t, 2, hub.Subscribers())
+}
+
+func TestHub_DropsSlowSubscribers(t *testing.T) {
+	hub := New(Options{Buffer: 2})
+
+	slow := hub.Subscribe(Filter{})
+	defer slow.Close()
+
+	for i := int64(1); i <= 3; i++ {
+		hub.Publish(Click{UrlId: i})
+	}
+
+	var got []int64
+	for c := range slow.Clicks() {
+		got = append(got, c.UrlId)
+	}
+	assert.Equal(t, []int64{1, 2}, got)
+	assert.True(t, slow.Dropped())
+	assert.Equal(t, 0, hub.Subscribers())
+}
+
+func TestHub_Close(t *testing.T) {
+	hub := New(Options{})
+	sub := hub.Subscribe(Filter{})
+	sub.Close()
+	sub.Close()
+
+	open := hub.Subscribe(Filter{})
+	hub.Close()
+
+	_, ok := <-open.Clicks()
+	assert.False(t, ok)
+	assert.False(t, open.Dropped())
+
+	_, ok = <-hub.Subscribe(Filter{}).Clicks()
+	assert.False(t, ok)
+}
+
+func TestHub_Stream(t *testing.T) {
+	hub := New(Options{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = hub.Stream(w, r, Filter{UrlId: 1})
+	}))
+	defer srv.Close()
+
+	t.Run("events", func(t *testing.T) {
+		resp, err := http.Get(srv.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		lines := bufio.NewScanner(resp.Body)
+		require.True(t, lines.Scan())
+		assert.Equal(t, ": ping", lines.Text())
+
+		waitSubscribers(t, hub, 1)
+		hub.Publish(Click{UrlId: 2, Alias: "other"})
+		hub.Publish(Click{UrlId: 1, Alias: "abc"})
+
+		var event []string
+		for lines.Scan() {
+			if lines.Text() == "" && len(event) > 0 {
+				break
+			}
+			if lines.Text() != "" {
+				event = append(event, lines.Text())
+			}
+		}
+		require.Len(t, event, 2)
+		assert.Equal(t, "event: click", event[0])
+
+		var click Click
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(event[1], "data: ")), &click))
+		assert.Equal(t, "abc", click.Alias)
+	})
+
+	t.Run("websocket", func(t *testing.T) {
+		waitSubscribers(t, hub, 0)
+
+		ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), "", srv.URL)
+		require.NoError(t, err)
+		defer ws.Close()
+
+		var msg struct {
+			Event string `json:"event"`
+			Data  Click  `json:"data"`
+		}
+		require.NoError(t, websocket.JSON.Receive(ws, &msg))
+		assert.Equal(t, "ping", msg.Event)
+
+		waitSubscribers(t, hub, 1)
+		hub.Publish(Click{UrlId: 1, Alias: "abc"})
+
+		require.NoError(t, websocket.JSON.Receive(ws, &msg))
+		assert.Equal(t, "click", msg.Event)
+		assert.Equal(t, "abc", msg.Data.Alias)
+
+		ws.Close()
+		waitSubscribers(t, hub, 0)
+	})
+}
+
+func waitSubscribers(t *testing.T, hub *Hub, n int) {
+	t.Helper()
+
+	assert.Eventually(t, func() bool { return hub.Subscribers() == n }, time.Second, 5*time.Millisecond)
+}
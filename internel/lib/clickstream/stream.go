@@ -0,0 +1,160 @@
+package clickstream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrDropped is returned by Stream when the client fell too far behind.
+var ErrDropped = errors.New("subscriber fell behind")
+
+// message is how events are sent over WebSockets, the same events are sent
+// with their name and data fields over Server-Sent Events.
+type message struct {
+	Event string `json:"event"`
+	Data  any    `json:"data,omitempty"`
+}
+
+// Stream sends the clicks matching filter to the client of r until it
+// disconnects or the hub is closed, over a WebSocket when r asks for one and
+// as Server-Sent Events otherwise. Every click is a "click" event, a client
+// that falls behind gets a "dropped" event before it is disconnected.
+func (h *Hub) Stream(w http.ResponseWriter, r *http.Request, filter Filter) error {
+	sub := h.Subscribe(filter)
+	defer sub.Close()
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		var err error
+		websocket.Server{Handler: func(ws *websocket.Conn) {
+			err = h.streamWebSocket(ws, sub)
+		}}.ServeHTTP(w, r)
+
+		return err
+	}
+
+	return h.streamEvents(w, r, sub)
+}
+
+func (h *Hub) streamEvents(w http.ResponseWriter, r *http.Request, sub *Subscription) error {
+	const op = "lib.clickstream.streamEvents"
+
+	rc := http.NewResponseController(w)
+	send := func(event string, data any) error {
+		// the server's write timeout is meant for ordinary responses
+		_ = rc.SetWriteDeadline(h.deadline())
+
+		if event == "" {
+			_, err := fmt.Fprint(w, ": ping\n\n")
+			if err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// keeps proxies like nginx from holding events back
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	err := h.serve(r.Context().Done(), sub, send)
+	if err != nil && !errors.Is(err, ErrDropped) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return err
+}
+
+func (h *Hub) streamWebSocket(ws *websocket.Conn, sub *Subscription) error {
+	const op = "lib.clickstream.streamWebSocket"
+
+	// the request context isn't cancelled once the connection is taken over,
+	// reading is how a closed connection is noticed
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+
+		var msg []byte
+		for websocket.Message.Receive(ws, &msg) == nil {
+		}
+	}()
+
+	send := func(event string, data any) error {
+		if event == "" {
+			event = "ping"
+		}
+		_ = ws.SetWriteDeadline(h.deadline())
+
+		return websocket.JSON.Send(ws, message{Event: event, Data: data})
+	}
+
+	err := h.serve(gone, sub, send)
+	if err != nil && !errors.Is(err, ErrDropped) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return err
+}
+
+// serve passes the clicks of sub to send until done is closed, send gets an
+// empty event for heartbeats.
+func (h *Hub) serve(done <-chan struct{}, sub *Subscription, send func(event string, data any) error) error {
+	var heartbeat <-chan time.Time
+	if h.opts.Heartbeat > 0 {
+		ticker := time.NewTicker(h.opts.Heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	// lets the client know the stream is open before the first click
+	if err := send("", nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-heartbeat:
+			if err := send("", nil); err != nil {
+				return err
+			}
+		case click, ok := <-sub.Clicks():
+			if !ok {
+				if sub.Dropped() {
+					_ = send("dropped", nil)
+					return ErrDropped
+				}
+				return nil
+			}
+			if err := send("click", click); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// deadline is when the next write has to be done by, the zero time for no
+// limit.
+func (h *Hub) deadline() time.Time {
+	if h.opts.WriteTimeout <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(h.opts.WriteTimeout)
+}
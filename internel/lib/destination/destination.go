@@ -0,0 +1,71 @@
+package destination
+
+import (
+	"net/url"
+	"url-shortner/internel/domain/entities/urlInfo"
+)
+
+const (
+	// QueryDrop ignores the query string of the short link, it is the default.
+	QueryDrop = "drop"
+	// QueryMerge adds parameters of the short link the destination doesn't have.
+	QueryMerge = "merge"
+	// QueryOverride adds parameters of the short link, replacing those of the
+	// destination.
+	QueryOverride = "override"
+)
+
+// Build adds the UTM parameters and, depending on mode, the query of the
+// short link to target. UTM parameters replace those already in target.
+// Targets that need no changes are returned as they are.
+func Build(target string, mode string, incoming url.Values, utm *urlInfo.UTM) string {
+	params := utmParams(utm)
+	forward := (mode == QueryMerge || mode == QueryOverride) && len(incoming) > 0
+	if len(params) == 0 && !forward {
+		return target
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+
+	query := u.Query()
+	for key, value := range params {
+		query.Set(key, value)
+	}
+
+	if forward {
+		for key, values := range incoming {
+			if _, ok := query[key]; ok && mode == QueryMerge {
+				continue
+			}
+			query[key] = values
+		}
+	}
+
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+func utmParams(utm *urlInfo.UTM) map[string]string {
+	if utm == nil {
+		return nil
+	}
+
+	params := make(map[string]string)
+	for key, value := range map[string]string{
+		"utm_source":   utm.Source,
+		"utm_medium":   utm.Medium,
+		"utm_campaign": utm.Campaign,
+		"utm_term":     utm.Term,
+		"utm_content":  utm.Content,
+	} {
+		if value != "" {
+			params[key] = value
+		}
+	}
+
+	return params
+}
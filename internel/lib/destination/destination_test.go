@@ -0,0 +1,64 @@
+package destination
+
+import (
+	"net/url"
+	"testing"
+	"url-shortner/internel/domain/entities/urlInfo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuild(t *testing.T) {
+	incoming := url.Values{"ref": {"tw"}, "lang": {"de"}}
+	utm := &urlInfo.UTM{Source: "newsletter", Medium: "email"}
+
+	tests := []struct {
+		name   string
+		target string
+		mode   string
+		query  url.Values
+		utm    *urlInfo.UTM
+		want   string
+	}{
+		{
+			name:   "unchanged",
+			target: "https://a.example/p?b=2&a=1#top",
+			mode:   QueryDrop,
+			query:  incoming,
+			want:   "https://a.example/p?b=2&a=1#top",
+		},
+		{
+			name:   "merge keeps destination parameters",
+			target: "https://a.example/p?lang=en#top",
+			mode:   QueryMerge,
+			query:  incoming,
+			want:   "https://a.example/p?lang=en&ref=tw#top",
+		},
+		{
+			name:   "override replaces destination parameters",
+			target: "https://a.example/p?lang=en",
+			mode:   QueryOverride,
+			query:  incoming,
+			want:   "https://a.example/p?lang=de&ref=tw",
+		},
+		{
+			name:   "utm",
+			target: "https://a.example/p?utm_source=old",
+			mode:   QueryDrop,
+			query:  incoming,
+			utm:    utm,
+			want:   "https://a.example/p?utm_medium=email&utm_source=newsletter",
+		},
+		{
+			name:   "visitor utm overrides link utm",
+			target: "https://a.example/p",
+			mode:   QueryOverride,
+			query:  url.Values{"utm_source": {"qr"}},
+			utm:    utm,
+			want:   "https://a.example/p?utm_medium=email&utm_source=qr",
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Build(tt.target, tt.mode, tt.query, tt.utm), tt.name)
+	}
+}
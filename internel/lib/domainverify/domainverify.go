@@ -0,0 +1,95 @@
+package domainverify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"url-shortner/internel/lib/shorturl"
+)
+
+// RecordPrefix is prepended to a domain to get the name of its TXT record,
+// _shortener-verify.go.example.com for go.example.com.
+const RecordPrefix = "_shortener-verify."
+
+// valuePrefix keeps the record from being mistaken for one of another
+// service.
+const valuePrefix = "shortener-verify="
+
+var (
+	ErrNotVerified  = errors.New("verification record not found")
+	ErrReservedHost = errors.New("host can't be used as a custom domain")
+)
+
+// Record is the TXT record that proves control of a domain.
+type Record struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// RecordFor is the record host has to publish for token.
+func RecordFor(host, token string) Record {
+	return Record{Name: RecordPrefix + host, Value: valuePrefix + token}
+}
+
+// CheckHost returns ErrReservedHost for hosts nobody may register: the host
+// of baseURL, localhost and IP addresses, which can't publish records.
+func CheckHost(host, baseURL string) error {
+	host = shorturl.Host(host)
+
+	if host == baseHost(baseURL) ||
+		host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		net.ParseIP(host) != nil {
+		return fmt.Errorf("%w: %s", ErrReservedHost, host)
+	}
+
+	return nil
+}
+
+func baseHost(baseURL string) string {
+	if i := strings.Index(baseURL, "://"); i >= 0 {
+		baseURL = baseURL[i+3:]
+	}
+	host, _, _ := strings.Cut(baseURL, "/")
+
+	return shorturl.Host(host)
+}
+
+// Resolver looks up TXT records, net.DefaultResolver is one.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Verifier checks that domains publish their verification record.
+type Verifier struct {
+	resolver Resolver
+}
+
+func New(resolver Resolver) *Verifier {
+	return &Verifier{resolver: resolver}
+}
+
+// Verify returns ErrNotVerified unless host publishes the record of token.
+func (v *Verifier) Verify(ctx context.Context, host, token string) error {
+	const op = "lib.domainverify.Verify"
+
+	want := RecordFor(host, token)
+
+	values, err := v.resolver.LookupTXT(ctx, want.Name)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return ErrNotVerified
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, value := range values {
+		if strings.TrimSpace(value) == want.Value {
+			return nil
+		}
+	}
+
+	return ErrNotVerified
+}
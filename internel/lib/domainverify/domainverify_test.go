@@ -0,0 +1,51 @@
+package domainverify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if name == "_shortener-verify.broken.example" {
+		return nil, errors.New("server misbehaving")
+	}
+	values, ok := f[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return values, nil
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	v := New(fakeResolver{
+		"_shortener-verify.go.example":    {"v=spf1 -all", "shortener-verify=abc"},
+		"_shortener-verify.other.example": {"shortener-verify=xyz"},
+	})
+
+	assert.NoError(t, v.Verify(context.Background(), "go.example", "abc"))
+	assert.ErrorIs(t, v.Verify(context.Background(), "other.example", "abc"), ErrNotVerified)
+	assert.ErrorIs(t, v.Verify(context.Background(), "missing.example", "abc"), ErrNotVerified)
+
+	err := v.Verify(context.Background(), "broken.example", "abc")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotVerified)
+}
+
+func TestCheckHost(t *testing.T) {
+	for _, host := range []string{"sho.rt", "SHO.RT.", "localhost", "app.localhost", "127.0.0.1", "::1", "10.0.0.1"} {
+		assert.ErrorIs(t, CheckHost(host, "https://sho.rt:8443/"), ErrReservedHost, host)
+	}
+
+	assert.NoError(t, CheckHost("go.example", "https://sho.rt"))
+	assert.NoError(t, CheckHost("go.sho.rt", "https://sho.rt"))
+}
+
+func TestRecordFor(t *testing.T) {
+	assert.Equal(t, Record{Name: "_shortener-verify.go.example", Value: "shortener-verify=abc"}, RecordFor("go.example", "abc"))
+}
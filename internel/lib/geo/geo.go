@@ -0,0 +1,90 @@
+package geo
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Location is where an IP address is, fields are empty when unknown.
+type Location struct {
+	// ContinentCode is one of AF, AN, AS, EU, NA, OC and SA.
+	ContinentCode string
+	CountryCode   string
+	Country       string
+	City          string
+}
+
+// Resolver looks up the location of an IP address.
+type Resolver interface {
+	Resolve(ip string) (Location, error)
+}
+
+// Noop is used when no GeoIP database is configured, every address is unknown.
+type Noop struct{}
+
+func (Noop) Resolve(string) (Location, error) {
+	return Location{}, nil
+}
+
+// Cache keeps the last size lookups of another resolver in memory. Failed
+// lookups are not cached.
+type Cache struct {
+	next Resolver
+	size int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	ip       string
+	location Location
+}
+
+func NewCache(next Resolver, size int) *Cache {
+	return &Cache{
+		next:    next,
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+// Len is the number of cached lookups.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *Cache) Resolve(ip string) (Location, error) {
+	c.mu.Lock()
+	if el, ok := c.entries[ip]; ok {
+		c.order.MoveToFront(el)
+		location := el.Value.(cacheEntry).location
+		c.mu.Unlock()
+		return location, nil
+	}
+	c.mu.Unlock()
+
+	location, err := c.next.Resolve(ip)
+	if err != nil {
+		return Location{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[ip]; !ok && c.size > 0 {
+		c.entries[ip] = c.order.PushFront(cacheEntry{ip: ip, location: location})
+		if c.order.Len() > c.size {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(cacheEntry).ip)
+		}
+	}
+
+	return location, nil
+}
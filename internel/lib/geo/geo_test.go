@@ -0,0 +1,42 @@
+package geo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingResolver struct {
+	calls map[string]int
+}
+
+func (r *countingResolver) Resolve(ip string) (Location, error) {
+	r.calls[ip]++
+	if ip == "bad" {
+		return Location{}, errors.New("lookup failed")
+	}
+
+	return Location{CountryCode: "DE", City: ip}, nil
+}
+
+func TestCache(t *testing.T) {
+	next := &countingResolver{calls: make(map[string]int)}
+	c := NewCache(next, 2)
+
+	for _, ip := range []string{"a", "b", "a", "c", "a", "b"} {
+		loc, err := c.Resolve(ip)
+		require.NoError(t, err)
+		assert.Equal(t, Location{CountryCode: "DE", City: ip}, loc)
+	}
+
+	assert.Equal(t, 1, next.calls["a"], "a stays cached while it is used")
+	assert.Equal(t, 2, next.calls["b"], "b was evicted by c")
+	assert.Equal(t, 1, next.calls["c"])
+
+	_, err := c.Resolve("bad")
+	assert.Error(t, err)
+	_, _ = c.Resolve("bad")
+	assert.Equal(t, 2, next.calls["bad"], "failures aren't cached")
+}
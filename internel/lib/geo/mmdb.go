@@ -0,0 +1,66 @@
+package geo
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// MMDB resolves addresses with a local MaxMind format database, such as
+// GeoLite2 City or Country or the DB-IP lite databases.
+type MMDB struct {
+	reader *maxminddb.Reader
+}
+
+// mmdbRecord is the part of the GeoIP2 City and Country layout that is used.
+type mmdbRecord struct {
+	Continent struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"continent"`
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+func OpenMMDB(path string) (*MMDB, error) {
+	const op = "lib.geo.OpenMMDB"
+
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &MMDB{reader: reader}, nil
+}
+
+// Resolve returns an empty location for addresses that aren't in the
+// database, like private ones.
+func (m *MMDB) Resolve(ip string) (Location, error) {
+	const op = "lib.geo.MMDB.Resolve"
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return Location{}, nil
+	}
+
+	var record mmdbRecord
+	if err := m.reader.Lookup(addr, &record); err != nil {
+		return Location{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return Location{
+		ContinentCode: record.Continent.Code,
+		CountryCode:   record.Country.ISOCode,
+		Country:       record.Country.Names["en"],
+		City:          record.City.Names["en"],
+	}, nil
+}
+
+func (m *MMDB) Close() error {
+	return m.reader.Close()
+}
@@ -0,0 +1,247 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"url-shortner/internel/domain/entities/urlInfo"
+)
+
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultConcurrency  = 4
+	DefaultMaxRedirects = 5
+	DefaultUserAgent    = "url-shortener-health-check/1.0"
+)
+
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+type Store interface {
+	// GetURLDestinations returns every link with its url and disabled reason.
+	GetURLDestinations() ([]urlInfo.UrlInfo, error)
+	SaveURLHealth(urlId int64, health urlInfo.Health, ok bool) error
+}
+
+// Policy keeps checks away from destinations links may not have, see
+// package urlpolicy.
+type Policy interface {
+	Check(rawURL string) error
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+type Options struct {
+	// Policy is applied to every url of a redirect chain and to the
+	// addresses connected to, nil checks any url.
+	Policy Policy
+	// Timeout bounds each request of a check.
+	Timeout time.Duration
+	// Concurrency is how many hosts are checked at the same time.
+	Concurrency int
+	// HostDelay is the pause between two requests to the same host.
+	HostDelay    time.Duration
+	MaxRedirects int
+	UserAgent    string
+}
+
+// Checker requests the destinations of links to find the broken ones.
+type Checker struct {
+	client *http.Client
+	opts   Options
+}
+
+func New(opts Options) *Checker {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = DefaultMaxRedirects
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+
+	client := &http.Client{
+		Timeout: opts.Timeout,
+		// redirects are followed by Check to record the chain
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	if opts.Policy != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = opts.Policy.DialContext
+		// a proxy would connect to the destination instead of the policy
+		transport.Proxy = nil
+		client.Transport = transport
+	}
+
+	return &Checker{
+		client: client,
+		opts:   opts,
+	}
+}
+
+// Check requests rawURL with HEAD, or GET for servers that don't support
+// HEAD, and follows its redirects. The destination is healthy when the last
+// response has a status below 400, a redirect to a url the policy refuses
+// ends the check as unhealthy.
+func (c *Checker) Check(ctx context.Context, rawURL string) (health urlInfo.Health, ok bool) {
+	start := time.Now()
+	defer func() {
+		health.LatencyMs = time.Since(start).Milliseconds()
+	}()
+
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		health.Error = err.Error()
+		return health, false
+	}
+
+	for redirects := 0; ; redirects++ {
+		if c.opts.Policy != nil {
+			if err := c.opts.Policy.Check(target.String()); err != nil {
+				health.Status = 0
+				health.Error = fmt.Sprintf("refused by url policy: %s", err)
+				return health, false
+			}
+		}
+
+		resp, err := c.request(ctx, http.MethodHead, target)
+		if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+			resp, err = c.request(ctx, http.MethodGet, target)
+		}
+		if err != nil {
+			health.Status = 0
+			health.Error = err.Error()
+			return health, false
+		}
+		health.Status = resp.StatusCode
+
+		location := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || location == "" {
+			break
+		}
+		if redirects == c.opts.MaxRedirects {
+			health.Error = ErrTooManyRedirects.Error()
+			return health, false
+		}
+
+		next, err := target.Parse(location)
+		if err != nil {
+			health.Error = fmt.Sprintf("bad redirect location %q", location)
+			return health, false
+		}
+		target = next
+		health.RedirectChain = append(health.RedirectChain, target.String())
+	}
+
+	if health.Status >= 400 {
+		health.Error = http.StatusText(health.Status)
+		return health, false
+	}
+
+	return health, true
+}
+
+func (c *Checker) request(ctx context.Context, method string, target *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// only the status and headers are needed
+	_ = resp.Body.Close()
+
+	return resp, nil
+}
+
+// CheckAll checks the destinations of all links that are not disabled and
+// stores the outcomes. Links on the same host are checked one after another
+// with HostDelay between them, up to Concurrency hosts at a time.
+func (c *Checker) CheckAll(ctx context.Context, store Store) (checked, failed int, err error) {
+	const op = "lib.health.CheckAll"
+
+	links, err := store.GetURLDestinations()
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hosts := make(map[string][]urlInfo.UrlInfo)
+	for _, link := range links {
+		if link.DisabledReason != "" {
+			continue
+		}
+		host := ""
+		if u, err := url.Parse(link.Url); err == nil {
+			host = strings.ToLower(u.Hostname())
+		}
+		hosts[host] = append(hosts[host], link)
+	}
+
+	var (
+		mu       sync.Mutex
+		storeErr error
+		wg       sync.WaitGroup
+	)
+	sem := make(chan struct{}, c.opts.Concurrency)
+
+	for _, hostLinks := range hosts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(hostLinks []urlInfo.UrlInfo) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			for i, link := range hostLinks {
+				if i > 0 && c.opts.HostDelay > 0 {
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(c.opts.HostDelay):
+					}
+				}
+				if ctx.Err() != nil {
+					return
+				}
+
+				health, ok := c.Check(ctx, link.Url)
+				if ctx.Err() != nil {
+					// a cancelled check says nothing about the link
+					return
+				}
+				err := store.SaveURLHealth(link.Id, health, ok)
+
+				mu.Lock()
+				checked++
+				if !ok {
+					failed++
+				}
+				if err != nil && storeErr == nil {
+					storeErr = err
+				}
+				mu.Unlock()
+			}
+		}(hostLinks)
+	}
+	wg.Wait()
+
+	if storeErr != nil {
+		return checked, failed, fmt.Errorf("%s: %w", op, storeErr)
+	}
+
+	return checked, failed, ctx.Err()
+}
@@ -0,0 +1,145 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/lib/urlpolicy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved-again", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/moved-again", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/metadata", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusFound)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestChecker_Check(t *testing.T) {
+	srv := testServer(t)
+	c := New(Options{MaxRedirects: 3})
+
+	health, ok := c.Check(context.Background(), srv.URL+"/ok")
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusOK, health.Status)
+	assert.Empty(t, health.Error)
+
+	health, ok = c.Check(context.Background(), srv.URL+"/missing")
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusNotFound, health.Status)
+
+	health, ok = c.Check(context.Background(), srv.URL+"/get-only")
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusOK, health.Status)
+
+	health, ok = c.Check(context.Background(), srv.URL+"/moved")
+	assert.True(t, ok)
+	assert.Equal(t, []string{srv.URL + "/moved-again", srv.URL + "/ok"}, health.RedirectChain)
+
+	health, ok = c.Check(context.Background(), srv.URL+"/loop")
+	assert.False(t, ok)
+	assert.Equal(t, ErrTooManyRedirects.Error(), health.Error)
+	assert.Len(t, health.RedirectChain, 3)
+
+	health, ok = c.Check(context.Background(), "http://127.0.0.1:1/")
+	assert.False(t, ok)
+	assert.Zero(t, health.Status)
+	assert.NotEmpty(t, health.Error)
+}
+
+func TestChecker_CheckPolicy(t *testing.T) {
+	srv := testServer(t)
+
+	strict, err := urlpolicy.New(urlpolicy.Options{})
+	require.NoError(t, err)
+
+	health, ok := New(Options{Policy: strict}).Check(context.Background(), srv.URL+"/ok")
+	assert.False(t, ok)
+	assert.Zero(t, health.Status)
+	assert.Contains(t, health.Error, "private")
+
+	// the test server is allowed, where it redirects to is not
+	allow := filepath.Join(t.TempDir(), "allow.txt")
+	require.NoError(t, os.WriteFile(allow, []byte("127.0.0.1\n"), 0o600))
+	allowed, err := urlpolicy.New(urlpolicy.Options{AllowList: allow})
+	require.NoError(t, err)
+	c := New(Options{Policy: allowed})
+
+	health, ok = c.Check(context.Background(), srv.URL+"/moved")
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusOK, health.Status)
+
+	health, ok = c.Check(context.Background(), srv.URL+"/metadata")
+	assert.False(t, ok)
+	assert.Zero(t, health.Status)
+	assert.Contains(t, health.Error, "refused by url policy")
+	assert.Equal(t, []string{"http://169.254.169.254/latest/meta-data"}, health.RedirectChain)
+}
+
+type memStore struct {
+	links []urlInfo.UrlInfo
+
+	mu      sync.Mutex
+	results map[int64]bool
+}
+
+func (m *memStore) GetURLDestinations() ([]urlInfo.UrlInfo, error) {
+	return m.links, nil
+}
+
+func (m *memStore) SaveURLHealth(urlId int64, health urlInfo.Health, ok bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results[urlId] = ok
+
+	return nil
+}
+
+func TestChecker_CheckAll(t *testing.T) {
+	srv := testServer(t)
+	store := &memStore{
+		links: []urlInfo.UrlInfo{
+			{Id: 1, Url: srv.URL + "/ok"},
+			{Id: 2, Url: srv.URL + "/missing"},
+			{Id: 3, Url: srv.URL + "/moved"},
+			{Id: 4, Url: srv.URL + "/missing", DisabledReason: "blocked"},
+		},
+		results: make(map[int64]bool),
+	}
+
+	checked, failed, err := New(Options{Concurrency: 2}).CheckAll(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, 3, checked)
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, map[int64]bool{1: true, 2: false, 3: true}, store.results)
+}
@@ -0,0 +1,43 @@
+package linkio
+
+import (
+	"fmt"
+	"url-shortner/internel/domain/entities/urlInfo"
+)
+
+type Source interface {
+	ExportURLs(fn func(link urlInfo.UrlInfo) error) error
+}
+
+// Export writes every link of src and returns how many were written.
+func Export(src Source, w Writer) (int, error) {
+	const op = "lib.linkio.Export"
+
+	count := 0
+	err := src.ExportURLs(func(link urlInfo.UrlInfo) error {
+		created, err := normalizeTime(link.Created)
+		if err != nil {
+			created = ""
+		}
+
+		count++
+
+		return w.Write(Record{
+			Alias:       link.Alias,
+			URL:         link.Url,
+			Domain:      link.Domain,
+			Owner:       link.User.Username,
+			WorkspaceId: link.WorkspaceId,
+			Created:     created,
+		})
+	})
+	if err != nil {
+		return count, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return count, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,334 @@
+package linkio
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortner/internel/lib/shorturl"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	// FormatYOURLS is the keyword,url,title,timestamp,ip,clicks layout of the
+	// YOURLS url table.
+	FormatYOURLS = "yourls"
+	// FormatBitly is the CSV layout of Bitly link exports.
+	FormatBitly = "bitly"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown format")
+	// ErrInvalidRecord marks a single bad row, reading can go on after it.
+	ErrInvalidRecord = errors.New("invalid record")
+)
+
+// Record is a link in the portable form used by imports and exports.
+type Record struct {
+	Alias       string `json:"alias"`
+	URL         string `json:"url"`
+	Domain      string `json:"domain,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	WorkspaceId int64  `json:"workspace_id,omitempty"`
+	// Created is in RFC 3339, UTC.
+	Created string `json:"created_at,omitempty"`
+}
+
+type Reader interface {
+	// Read returns the next record, io.EOF after the last one.
+	Read() (Record, error)
+}
+
+type Writer interface {
+	Write(record Record) error
+	Flush() error
+}
+
+func ContentType(format string) string {
+	if format == FormatJSONL {
+		return "application/x-ndjson"
+	}
+
+	return "text/csv"
+}
+
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r, map[string]string{
+			"alias":        "alias",
+			"url":          "url",
+			"domain":       "domain",
+			"owner":        "owner",
+			"workspace_id": "workspace_id",
+			"created_at":   "created_at",
+		})
+	case FormatYOURLS:
+		return newCSVReader(r, map[string]string{
+			"keyword":   "alias",
+			"url":       "url",
+			"timestamp": "created_at",
+		})
+	case FormatBitly:
+		return newCSVReader(r, map[string]string{
+			"link":       "link",
+			"bitlink":    "link",
+			"id":         "link",
+			"long_url":   "url",
+			"long url":   "url",
+			"created_at": "created_at",
+			"created":    "created_at",
+		})
+	case FormatJSONL:
+		return &jsonlReader{dec: json.NewDecoder(r)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// NewWriter writes records in format, baseURL is used for the short links
+// of the Bitly layout.
+func NewWriter(format string, w io.Writer, baseURL string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, []string{"alias", "url", "domain", "owner", "workspace_id", "created_at"},
+			func(rec Record) []string {
+				return []string{rec.Alias, rec.URL, rec.Domain, rec.Owner, formatInt(rec.WorkspaceId),
+					formatTime(rec.Created, time.RFC3339)}
+			}), nil
+	case FormatYOURLS:
+		return newCSVWriter(w, []string{"keyword", "url", "title", "timestamp", "ip", "clicks"},
+			func(rec Record) []string {
+				return []string{rec.Alias, rec.URL, "", formatTime(rec.Created, time.DateTime), "", ""}
+			}), nil
+	case FormatBitly:
+		return newCSVWriter(w, []string{"id", "link", "long_url", "title", "created_at"},
+			func(rec Record) []string {
+				link := shorturl.Build(baseURL, rec.Domain, rec.Alias)
+				return []string{strings.SplitN(link, "://", 2)[1], link, rec.URL, "",
+					formatTime(rec.Created, "2006-01-02T15:04:05-0700")}
+			}), nil
+	case FormatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// csvReader maps header names of a format to Record fields.
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+	line    int
+}
+
+func newCSVReader(r io.Reader, names map[string]string) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := names[name]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+
+	_, hasURL := columns["url"]
+	_, hasAlias := columns["alias"]
+	_, hasLink := columns["link"]
+	if !hasURL || (!hasAlias && !hasLink) {
+		return nil, errors.New("header must name the alias and url columns")
+	}
+
+	return &csvReader{reader: reader, columns: columns, line: 1}, nil
+}
+
+func (c *csvReader) Read() (Record, error) {
+	fields, err := c.reader.Read()
+	if err != nil {
+		return Record{}, err
+	}
+	c.line++
+
+	field := func(name string) string {
+		i, ok := c.columns[name]
+		if !ok || i >= len(fields) {
+			return ""
+		}
+		return strings.TrimSpace(fields[i])
+	}
+
+	rec := Record{
+		Alias:  field("alias"),
+		URL:    field("url"),
+		Domain: field("domain"),
+		Owner:  field("owner"),
+	}
+
+	// Bitly only exports the short link, its last path segment is the alias
+	if link := field("link"); link != "" && rec.Alias == "" {
+		if !strings.Contains(link, "://") {
+			link = "https://" + link
+		}
+		u, err := url.Parse(link)
+		if err != nil {
+			return Record{}, fmt.Errorf("%w: line %d: bad link %q", ErrInvalidRecord, c.line, link)
+		}
+		rec.Alias = path.Base(u.Path)
+	}
+
+	if ws := field("workspace_id"); ws != "" {
+		rec.WorkspaceId, err = strconv.ParseInt(ws, 10, 64)
+		if err != nil {
+			return Record{}, fmt.Errorf("%w: line %d: bad workspace_id %q", ErrInvalidRecord, c.line, ws)
+		}
+	}
+
+	rec.Created, err = normalizeTime(field("created_at"))
+	if err != nil {
+		return Record{}, fmt.Errorf("%w: line %d: %w", ErrInvalidRecord, c.line, err)
+	}
+
+	return rec, nil
+}
+
+type jsonlReader struct {
+	dec  *json.Decoder
+	line int
+}
+
+func (j *jsonlReader) Read() (Record, error) {
+	var rec Record
+	if err := j.dec.Decode(&rec); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Record{}, io.EOF
+		}
+		// the decoder can't resync after a syntax error, so it stops here
+		return Record{}, fmt.Errorf("line %d: %w", j.line+1, err)
+	}
+	j.line++
+
+	var err error
+	rec.Created, err = normalizeTime(rec.Created)
+	if err != nil {
+		return Record{}, fmt.Errorf("%w: line %d: %w", ErrInvalidRecord, j.line, err)
+	}
+
+	return rec, nil
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+	header []string
+	fields func(Record) []string
+}
+
+func newCSVWriter(w io.Writer, header []string, fields func(Record) []string) *csvWriter {
+	return &csvWriter{writer: csv.NewWriter(w), header: header, fields: fields}
+}
+
+func (c *csvWriter) Write(rec Record) error {
+	if c.header != nil {
+		if err := c.writer.Write(c.header); err != nil {
+			return err
+		}
+		c.header = nil
+	}
+
+	return c.writer.Write(c.fields(rec))
+}
+
+func (c *csvWriter) Flush() error {
+	if c.header != nil {
+		if err := c.writer.Write(c.header); err != nil {
+			return err
+		}
+		c.header = nil
+	}
+
+	c.writer.Flush()
+
+	return c.writer.Error()
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonlWriter) Write(rec Record) error {
+	return j.enc.Encode(rec)
+}
+
+func (j *jsonlWriter) Flush() error {
+	return nil
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	time.DateTime,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05",
+	time.DateOnly,
+}
+
+// ParseTime accepts the date formats found in exports of this and other
+// shorteners, as well as unix timestamps. Times without a zone are UTC.
+func ParseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("unknown date format %q", value)
+}
+
+func normalizeTime(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	t, err := ParseTime(value)
+	if err != nil {
+		return "", err
+	}
+
+	return t.Format(time.RFC3339), nil
+}
+
+func formatTime(value string, layout string) string {
+	t, err := ParseTime(value)
+	if err != nil {
+		return value
+	}
+
+	return t.Format(layout)
+}
+
+func formatInt(n int64) string {
+	if n == 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,68 @@
+package linkio
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReader_Bitly(t *testing.T) {
+	input := "title,long_url,link,created_at\n" +
+		"a,https://example.com/a,https://bit.ly/3xYz,2021-02-03T04:05:06+0000\n" +
+		"b,https://example.com/b,bit.ly/spring-sale,\n" +
+		"c,https://example.com/c,bit.ly/c,yesterday\n"
+
+	r, err := NewReader(FormatBitly, strings.NewReader(input))
+	require.NoError(t, err)
+
+	rec, err := r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, Record{Alias: "3xYz", URL: "https://example.com/a", Created: "2021-02-03T04:05:06Z"}, rec)
+
+	rec, err = r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "spring-sale", rec.Alias)
+
+	_, err = r.Read()
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+
+	_, err = r.Read()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestWriterReader_RoundTrip(t *testing.T) {
+	records := []Record{
+		{Alias: "promo", URL: "https://example.com", Owner: "alice", WorkspaceId: 3, Created: "2019-05-01T10:00:00Z"},
+		{Alias: "plain", URL: "https://example.org", Domain: "go.example.com"},
+	}
+
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		var buf bytes.Buffer
+		w, err := NewWriter(format, &buf, "http://localhost:8080")
+		require.NoError(t, err)
+		for _, rec := range records {
+			require.NoError(t, w.Write(rec))
+		}
+		require.NoError(t, w.Flush())
+
+		r, err := NewReader(format, &buf)
+		require.NoError(t, err)
+		for _, want := range records {
+			got, err := r.Read()
+			require.NoError(t, err)
+			assert.Equal(t, want, got, format)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	for _, value := range []string{"2015-06-07 08:09:10", "2015-06-07T08:09:10Z", "2015-06-07T10:09:10+0200", "1433664550"} {
+		got, err := ParseTime(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, "2015-06-07T08:09:10Z", got.Format("2006-01-02T15:04:05Z07:00"), value)
+	}
+}
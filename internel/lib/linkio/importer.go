@@ -0,0 +1,302 @@
+package linkio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/user"
+	"url-shortner/internel/lib/shorturl"
+	"url-shortner/internel/storage"
+)
+
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictRename    = "rename"
+
+	ActionCreated     = "created"
+	ActionOverwritten = "overwritten"
+	ActionRenamed     = "renamed"
+	ActionSkipped     = "skipped"
+	ActionFailed      = "failed"
+
+	// maxRenames bounds the alias-2, alias-3, ... candidates tried on rename.
+	maxRenames = 100
+)
+
+var ErrUnknownConflict = errors.New("conflict policy must be skip, overwrite or rename")
+
+type Store interface {
+	BeginURLTx() (storage.URLTx, error)
+}
+
+// URLPolicy decides which destinations links may have.
+type URLPolicy interface {
+	CheckLink(link *urlInfo.UrlInfo) error
+}
+
+type ImportOptions struct {
+	// Conflict decides what happens to records whose alias already exists.
+	Conflict string
+	// DryRun imports into a transaction that is rolled back, so the report
+	// shows what would happen without changing anything.
+	DryRun bool
+	// DefaultOwner owns records without an owner or with an unknown one,
+	// 0 makes such records fail.
+	DefaultOwner int64
+	// URLPolicy rejects records with urls it doesn't accept, nil accepts all.
+	URLPolicy URLPolicy
+}
+
+type Report struct {
+	DryRun      bool        `json:"dry_run"`
+	Total       int         `json:"total"`
+	Created     int         `json:"created"`
+	Overwritten int         `json:"overwritten"`
+	Renamed     int         `json:"renamed"`
+	Skipped     int         `json:"skipped"`
+	Failed      int         `json:"failed"`
+	Rows        []RowReport `json:"rows"`
+}
+
+type RowReport struct {
+	Row    int    `json:"row"`
+	Alias  string `json:"alias"`
+	Action string `json:"action"`
+	// NewAlias is set for renamed records.
+	NewAlias string `json:"new_alias,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Import stores the records read from r in one transaction, keeping their
+// aliases, owners and creation dates. Rows that fail don't stop the import.
+func Import(store Store, r Reader, opts ImportOptions) (Report, error) {
+	const op = "lib.linkio.Import"
+
+	switch opts.Conflict {
+	case ConflictSkip, ConflictOverwrite, ConflictRename:
+	case "":
+		opts.Conflict = ConflictSkip
+	default:
+		return Report{}, fmt.Errorf("%s: %w", op, ErrUnknownConflict)
+	}
+
+	tx, err := store.BeginURLTx()
+	if err != nil {
+		return Report{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	imp := &importer{
+		tx:      tx,
+		opts:    opts,
+		owners:  make(map[string]int64),
+		domains: make(map[string]int64),
+		report:  Report{DryRun: opts.DryRun, Rows: make([]RowReport, 0)},
+	}
+
+	for row := 1; ; row++ {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, ErrInvalidRecord) {
+			imp.add(RowReport{Row: row, Action: ActionFailed, Message: err.Error()})
+			continue
+		}
+		if err != nil {
+			return Report{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		report, err := imp.importRecord(rec)
+		if err != nil {
+			return Report{}, fmt.Errorf("%s: row %d: %w", op, row, err)
+		}
+		report.Row = row
+		imp.add(report)
+	}
+
+	if !opts.DryRun {
+		if err := tx.Commit(); err != nil {
+			return Report{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return imp.report, nil
+}
+
+type importer struct {
+	tx   storage.URLTx
+	opts ImportOptions
+
+	owners  map[string]int64
+	domains map[string]int64
+	report  Report
+}
+
+func (imp *importer) add(row RowReport) {
+	imp.report.Total++
+	switch row.Action {
+	case ActionCreated:
+		imp.report.Created++
+	case ActionOverwritten:
+		imp.report.Overwritten++
+	case ActionRenamed:
+		imp.report.Renamed++
+	case ActionSkipped:
+		imp.report.Skipped++
+	case ActionFailed:
+		imp.report.Failed++
+	}
+	imp.report.Rows = append(imp.report.Rows, row)
+}
+
+// importRecord returns the outcome of a single record, the error is only set
+// when the import can't go on.
+func (imp *importer) importRecord(rec Record) (RowReport, error) {
+	report := RowReport{Alias: rec.Alias}
+	fail := func(msg string) (RowReport, error) {
+		report.Action = ActionFailed
+		report.Message = msg
+		return report, nil
+	}
+
+	if rec.Alias == "" {
+		return fail("alias is required")
+	}
+	if u, err := url.ParseRequestURI(rec.URL); err != nil || u.Host == "" {
+		return fail("url is not valid")
+	}
+	if imp.opts.URLPolicy != nil {
+		if err := imp.opts.URLPolicy.CheckLink(&urlInfo.UrlInfo{Url: rec.URL}); err != nil {
+			return fail("url rejected: " + err.Error())
+		}
+	}
+
+	ownerId, known, err := imp.owner(rec.Owner)
+	if err != nil {
+		return RowReport{}, err
+	}
+	if ownerId == 0 {
+		return fail(fmt.Sprintf("owner %q not found", rec.Owner))
+	}
+	if !known && rec.Owner != "" {
+		report.Message = fmt.Sprintf("owner %q not found, assigned to the default owner", rec.Owner)
+	}
+
+	domainId, err := imp.domain(rec.Domain)
+	if errors.Is(err, storage.ErrDomainNotFound) {
+		return fail(fmt.Sprintf("domain %q not found", rec.Domain))
+	}
+	if err != nil {
+		return RowReport{}, err
+	}
+
+	link := &urlInfo.UrlInfo{
+		Alias:       rec.Alias,
+		Url:         rec.URL,
+		User:        user.User{ID: ownerId},
+		WorkspaceId: rec.WorkspaceId,
+		DomainId:    domainId,
+		Created:     storedTime(rec.Created),
+	}
+
+	_, err = imp.tx.SaveURL(link)
+	if err == nil {
+		report.Action = ActionCreated
+		return report, nil
+	}
+	if !errors.Is(err, storage.ErrURLExists) {
+		return RowReport{}, err
+	}
+
+	switch imp.opts.Conflict {
+	case ConflictOverwrite:
+		existing, err := imp.tx.GetURL(domainId, rec.Alias)
+		if err != nil {
+			return RowReport{}, err
+		}
+		link.Id = existing.Id
+		if err := imp.tx.UpdateURL(link); err != nil {
+			return RowReport{}, err
+		}
+		report.Action = ActionOverwritten
+	case ConflictRename:
+		for i := 2; i <= maxRenames; i++ {
+			link.Alias = rec.Alias + "-" + strconv.Itoa(i)
+			_, err = imp.tx.SaveURL(link)
+			if !errors.Is(err, storage.ErrURLExists) {
+				break
+			}
+		}
+		if errors.Is(err, storage.ErrURLExists) {
+			return fail("no free alias to rename to")
+		}
+		if err != nil {
+			return RowReport{}, err
+		}
+		report.Action = ActionRenamed
+		report.NewAlias = link.Alias
+	default:
+		report.Action = ActionSkipped
+		report.Message = "alias already exists"
+	}
+
+	return report, nil
+}
+
+// owner resolves a username, known is false when the default owner is used.
+func (imp *importer) owner(username string) (id int64, known bool, err error) {
+	if username == "" {
+		return imp.opts.DefaultOwner, false, nil
+	}
+
+	id, ok := imp.owners[username]
+	if !ok {
+		u, err := imp.tx.GetUser(username)
+		if err != nil && !errors.Is(err, storage.UserNotFound) {
+			return 0, false, err
+		}
+		id = u.ID
+		imp.owners[username] = id
+	}
+
+	if id == 0 {
+		return imp.opts.DefaultOwner, false, nil
+	}
+
+	return id, true, nil
+}
+
+func (imp *importer) domain(host string) (int64, error) {
+	if host == "" {
+		return 0, nil
+	}
+
+	host = shorturl.Host(host)
+	if id, ok := imp.domains[host]; ok {
+		return id, nil
+	}
+
+	d, err := imp.tx.GetDomainByHost(host)
+	if err != nil {
+		return 0, err
+	}
+	imp.domains[host] = d.ID
+
+	return d.ID, nil
+}
+
+// storedTime converts an RFC 3339 time to the layout of CURRENT_TIMESTAMP.
+func storedTime(value string) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.DateTime)
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	reg := NewRegistry()
+
+	requests := reg.Counter("requests_total", "Requests by route.", "route", "status")
+	requests.Inc("/{alias}", "302")
+	requests.Inc("/{alias}", "302")
+	requests.Add(3, "/url", "200")
+
+	durations := reg.Histogram("request_duration_seconds", "How long requests took.", []float64{.1, 1}, "route")
+	durations.Observe(.05, "/url")
+	durations.Observe(.1, "/url")
+	durations.Observe(5, "/url")
+
+	reg.GaugeFunc("subscribers", "Open streams.", func() float64 { return 2 })
+
+	var b strings.Builder
+	_, err := reg.WriteTo(&b)
+	require.NoError(t, err)
+
+	assert.Equal(t, `# HELP requests_total Requests by route.
+# TYPE requests_total counter
+requests_total{route="/url",status="200"} 3
+requests_total{route="/{alias}",status="302"} 2
+# HELP request_duration_seconds How long requests took.
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{route="/url",le="0.1"} 2
+request_duration_seconds_bucket{route="/url",le="1"} 2
+request_duration_seconds_bucket{route="/url",le="+Inf"} 3
+request_duration_seconds_sum{route="/url"} 5.15
+request_duration_seconds_count{route="/url"} 3
+# HELP subscribers Open streams.
+# TYPE subscribers gauge
+subscribers 2
+`, b.String())
+}
+
+func TestRegistry_Escaping(t *testing.T) {
+	reg := NewRegistry()
+	reg.Counter("errors_total", "Errors with \\ and\nnewlines.", "error").Inc("say \"hi\"\n")
+
+	var b strings.Builder
+	_, err := reg.WriteTo(&b)
+	require.NoError(t, err)
+
+	assert.Contains(t, b.String(), `# HELP errors_total Errors with \\ and\nnewlines.`)
+	assert.Contains(t, b.String(), `errors_total{error="say \"hi\"\n"} 1`)
+}
+
+func TestRegistry_Panics(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.Counter("requests_total", "", "route")
+
+	assert.Panics(t, func() { reg.GaugeFunc("requests_total", "", func() float64 { return 0 }) })
+	assert.Panics(t, func() { c.Inc("/url", "200") })
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	reg := NewRegistry()
+	reg.CounterFunc("waits_total", "Waits.", func() float64 { return 4 })
+
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	body, _ := io.ReadAll(rec.Body)
+	assert.Contains(t, string(body), "# TYPE waits_total counter\nwaits_total 4\n")
+}
@@ -0,0 +1,314 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Types of columns.
+const (
+	Bool = iota
+	Int64
+	String
+	// Timestamp columns hold milliseconds since the Unix epoch in UTC.
+	Timestamp
+)
+
+// DefaultRowGroupSize is how many rows are buffered before they are written.
+const DefaultRowGroupSize = 10000
+
+var (
+	ErrRowLength = errors.New("row doesn't match the columns")
+	ErrValueType = errors.New("value doesn't match the column type")
+)
+
+// Parquet enum values, from parquet.thrift.
+const (
+	typeBoolean   = 0
+	typeInt64     = 2
+	typeByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionRequired = 0
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecUncompressed  = 0
+	pageData           = 0
+)
+
+var magic = []byte("PAR1")
+
+type Column struct {
+	Name string
+	Type int
+}
+
+// Writer writes rows as a parquet file with required, uncompressed and
+// plainly encoded columns. Rows are buffered in row groups, so memory use is
+// bounded by the row group size rather than the number of rows.
+type Writer struct {
+	w            io.Writer
+	columns      []Column
+	rowGroupSize int
+
+	offset    int64
+	pages     [][]byte
+	rows      int
+	numRows   int64
+	rowGroups []rowGroup
+	err       error
+}
+
+type rowGroup struct {
+	numRows int64
+	size    int64
+	chunks  []columnChunk
+}
+
+type columnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+// NewWriter returns a Writer of columns to w, rowGroupSize 0 uses
+// DefaultRowGroupSize.
+func NewWriter(w io.Writer, columns []Column, rowGroupSize int) *Writer {
+	if rowGroupSize <= 0 {
+		rowGroupSize = DefaultRowGroupSize
+	}
+
+	return &Writer{
+		w:            w,
+		columns:      columns,
+		rowGroupSize: rowGroupSize,
+		pages:        make([][]byte, len(columns)),
+	}
+}
+
+// Write adds a row, its values have to be bool, int64, string and
+// time.Time for the columns in their order. A value of the wrong type makes
+// the Writer unusable.
+func (p *Writer) Write(row ...any) error {
+	if p.err != nil {
+		return p.err
+	}
+	if len(row) != len(p.columns) {
+		return ErrRowLength
+	}
+
+	for i, col := range p.columns {
+		page, err := appendValue(p.pages[i], col, p.rows, row[i])
+		if err != nil {
+			// the earlier columns already have the row
+			p.err = err
+			return err
+		}
+		p.pages[i] = page
+	}
+	p.rows++
+
+	if p.rows == p.rowGroupSize {
+		return p.flush()
+	}
+
+	return nil
+}
+
+// Close writes the buffered rows and the footer. It doesn't close the
+// underlying writer.
+func (p *Writer) Close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+
+	footer := p.footer()
+	p.write(footer)
+	p.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	p.write(magic)
+
+	return p.err
+}
+
+func appendValue(page []byte, col Column, row int, value any) ([]byte, error) {
+	switch col.Type {
+	case Bool:
+		v, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not a bool", ErrValueType, col.Name)
+		}
+		// booleans are bit packed, the first value in the lowest bit
+		if row%8 == 0 {
+			page = append(page, 0)
+		}
+		if v {
+			page[len(page)-1] |= 1 << (row % 8)
+		}
+		return page, nil
+	case Int64:
+		v, ok := value.(int64)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not an int64", ErrValueType, col.Name)
+		}
+		return binary.LittleEndian.AppendUint64(page, uint64(v)), nil
+	case Timestamp:
+		v, ok := value.(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not a time", ErrValueType, col.Name)
+		}
+		return binary.LittleEndian.AppendUint64(page, uint64(v.UnixMilli())), nil
+	default:
+		v, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not a string", ErrValueType, col.Name)
+		}
+		page = binary.LittleEndian.AppendUint32(page, uint32(len(v)))
+		return append(page, v...), nil
+	}
+}
+
+// flush writes the buffered rows as a row group with a single data page
+// per column.
+func (p *Writer) flush() error {
+	if p.err != nil || p.rows == 0 {
+		return p.err
+	}
+
+	if p.offset == 0 {
+		p.write(magic)
+	}
+
+	group := rowGroup{numRows: int64(p.rows)}
+	for i := range p.columns {
+		header := pageHeader(len(p.pages[i]), p.rows)
+		chunk := columnChunk{
+			offset: p.offset,
+			size:   int64(len(header) + len(p.pages[i])),
+			values: int64(p.rows),
+		}
+		p.write(header)
+		p.write(p.pages[i])
+		group.size += chunk.size
+		group.chunks = append(group.chunks, chunk)
+		p.pages[i] = p.pages[i][:0]
+	}
+
+	p.rowGroups = append(p.rowGroups, group)
+	p.numRows += int64(p.rows)
+	p.rows = 0
+
+	return p.err
+}
+
+func (p *Writer) write(b []byte) {
+	if p.err != nil {
+		return
+	}
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	p.err = err
+}
+
+func pageHeader(size, values int) []byte {
+	var t thriftWriter
+	t.structBegin()
+	t.i32(1, pageData)
+	t.i32(2, int32(size))
+	t.i32(3, int32(size))
+	t.field(5, tStruct)
+	t.structBegin()
+	t.i32(1, int32(values))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.structEnd()
+	t.structEnd()
+
+	return t.buf.Bytes()
+}
+
+func (p *Writer) footer() []byte {
+	if p.offset == 0 {
+		// a file without rows still needs the leading magic
+		p.write(magic)
+	}
+
+	var t thriftWriter
+	t.structBegin()
+	t.i32(1, 1)
+
+	t.field(2, tList)
+	t.listBegin(len(p.columns)+1, tStruct)
+	t.structBegin()
+	t.string(4, "schema")
+	t.i32(5, int32(len(p.columns)))
+	t.structEnd()
+	for _, col := range p.columns {
+		t.structBegin()
+		t.i32(1, physicalType(col.Type))
+		t.i32(3, repetitionRequired)
+		t.string(4, col.Name)
+		switch col.Type {
+		case String:
+			t.i32(6, convertedUTF8)
+		case Timestamp:
+			t.i32(6, convertedTimestampMillis)
+		}
+		t.structEnd()
+	}
+
+	t.i64(3, p.numRows)
+
+	t.field(4, tList)
+	t.listBegin(len(p.rowGroups), tStruct)
+	for _, group := range p.rowGroups {
+		t.structBegin()
+		t.field(1, tList)
+		t.listBegin(len(group.chunks), tStruct)
+		for i, chunk := range group.chunks {
+			t.structBegin()
+			t.i64(2, chunk.offset)
+			t.field(3, tStruct)
+			t.structBegin()
+			t.i32(1, physicalType(p.columns[i].Type))
+			t.field(2, tList)
+			t.listBegin(1, tI32)
+			t.zigzag(encodingPlain)
+			t.field(3, tList)
+			t.listBegin(1, tBinary)
+			t.varint(uint64(len(p.columns[i].Name)))
+			t.buf.WriteString(p.columns[i].Name)
+			t.i32(4, codecUncompressed)
+			t.i64(5, chunk.values)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.i64(2, group.size)
+		t.i64(3, group.numRows)
+		t.structEnd()
+	}
+
+	t.string(6, "url-shortener")
+	t.structEnd()
+
+	return t.buf.Bytes()
+}
+
+func physicalType(typ int) int32 {
+	switch typ {
+	case Bool:
+		return typeBoolean
+	case Int64, Timestamp:
+		return typeInt64
+	default:
+		return typeByteArray
+	}
+}
@@ -0,0 +1,56 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{{Name: "id", Type: Int64}, {Name: "bot", Type: Bool}, {Name: "ip", Type: String},
+		{Name: "created", Type: Timestamp}}, 2)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, w.Write(int64(1), true, "203.0.113.7", created))
+	require.NoError(t, w.Write(int64(2), false, "", created))
+	require.NoError(t, w.Write(int64(3), true, "::1", created))
+	assert.ErrorIs(t, w.Write(int64(4)), ErrRowLength)
+	require.NoError(t, w.Close())
+
+	file := buf.Bytes()
+	require.True(t, bytes.HasPrefix(file, magic))
+	require.True(t, bytes.HasSuffix(file, magic))
+	footerLength := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := file[len(file)-8-footerLength : len(file)-8]
+	assert.Contains(t, string(footer), "created")
+
+	// the first page of the first row group holds the ids 1 and 2
+	header := pageHeader(16, 2)
+	page := file[len(magic)+len(header):]
+	assert.Equal(t, uint64(1), binary.LittleEndian.Uint64(page))
+	assert.Equal(t, uint64(2), binary.LittleEndian.Uint64(page[8:]))
+	// followed by the bit packed bools of the same rows
+	page = page[16+len(pageHeader(1, 2)):]
+	assert.Equal(t, byte(0b01), page[0])
+}
+
+func TestWriter_WrongType(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, []Column{{Name: "id", Type: Int64}, {Name: "ip", Type: String}}, 0)
+
+	assert.ErrorIs(t, w.Write(int64(1), 2), ErrValueType)
+	assert.ErrorIs(t, w.Write(int64(1), "x"), ErrValueType)
+}
+
+func TestWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewWriter(&buf, []Column{{Name: "id", Type: Int64}}, 0).Close())
+
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), magic))
+	assert.True(t, bytes.HasSuffix(buf.Bytes(), magic))
+}
@@ -0,0 +1,81 @@
+package parquet
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// click is how parquet-go reads the columns of TestWriter_ReadBack.
+type click struct {
+	ID      int64     `parquet:"id"`
+	Bot     bool      `parquet:"bot"`
+	IP      string    `parquet:"ip"`
+	Created time.Time `parquet:"created,timestamp(millisecond)"`
+}
+
+// TestWriter_ReadBack reads a file written by Writer with parquet-go, an
+// independent implementation of the format.
+func TestWriter_ReadBack(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 678e6, time.UTC)
+	want := []click{
+		{ID: 1, Bot: true, IP: "203.0.113.7", Created: created},
+		{ID: 2, Bot: false, IP: "", Created: created.Add(time.Hour)},
+		{ID: 3, Bot: true, IP: "2001:db8::1", Created: created.Add(48 * time.Hour)},
+		{ID: -4, Bot: false, IP: "ünïcödé", Created: time.UnixMilli(0).UTC()},
+		{ID: 5, Bot: true, IP: "198.51.100.1", Created: created},
+	}
+
+	var buf bytes.Buffer
+	// a row group size of 2 spreads the rows over three row groups, the last
+	// one partly filled
+	w := NewWriter(&buf, []Column{{Name: "id", Type: Int64}, {Name: "bot", Type: Bool}, {Name: "ip", Type: String},
+		{Name: "created", Type: Timestamp}}, 2)
+	for _, c := range want {
+		require.NoError(t, w.Write(c.ID, c.Bot, c.IP, c.Created))
+	}
+	require.NoError(t, w.Close())
+
+	file, err := pq.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(want)), file.NumRows())
+	assert.Len(t, file.RowGroups(), 3)
+
+	fields := file.Schema().Fields()
+	require.Len(t, fields, 4)
+	for i, name := range []string{"id", "bot", "ip", "created"} {
+		assert.Equal(t, name, fields[i].Name())
+		assert.True(t, fields[i].Required(), name)
+	}
+	assert.Equal(t, pq.Int64, fields[0].Type().Kind())
+	assert.Equal(t, pq.Boolean, fields[1].Type().Kind())
+	assert.Equal(t, pq.ByteArray, fields[2].Type().Kind())
+	assert.Equal(t, pq.String().Type(), fields[2].Type())
+	assert.Equal(t, pq.Int64, fields[3].Type().Kind())
+	assert.Equal(t, pq.Timestamp(pq.Millisecond).Type(), fields[3].Type())
+
+	got, err := pq.Read[click](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, got, len(want))
+	for i := range want {
+		assert.Equal(t, want[i].ID, got[i].ID)
+		assert.Equal(t, want[i].Bot, got[i].Bot)
+		assert.Equal(t, want[i].IP, got[i].IP)
+		assert.True(t, want[i].Created.Equal(got[i].Created), "%v != %v", want[i].Created, got[i].Created)
+	}
+}
+
+func TestWriter_ReadBackEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewWriter(&buf, []Column{{Name: "id", Type: Int64}}, 0).Close())
+
+	file, err := pq.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), file.NumRows())
+	require.Len(t, file.Schema().Fields(), 1)
+	assert.Equal(t, "id", file.Schema().Fields()[0].Name())
+}
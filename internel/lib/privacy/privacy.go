@@ -0,0 +1,90 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	// IPRaw stores visitor IPs as they are.
+	IPRaw = "raw"
+	// IPTruncate keeps the network of visitor IPs, the /24 of IPv4 and the
+	// /48 of IPv6 addresses.
+	IPTruncate = "truncate"
+	// IPHash replaces visitor IPs with a keyed hash. The same IP always gets
+	// the same hash, so its clicks can still be found and erased.
+	IPHash = "hash"
+)
+
+var (
+	ErrMode     = errors.New("ip mode must be raw, truncate or hash")
+	ErrNoSecret = errors.New("hashing ips needs a secret")
+)
+
+// Anonymizer turns visitor IPs into what is stored with clicks.
+type Anonymizer struct {
+	mode string
+	key  []byte
+}
+
+// New returns an Anonymizer for mode, secret keys the hashes of IPHash and
+// must stay the same for hashes to match.
+func New(mode, secret string) (*Anonymizer, error) {
+	const op = "lib.privacy.New"
+
+	switch mode {
+	case "":
+		mode = IPRaw
+	case IPRaw, IPTruncate:
+	case IPHash:
+		if secret == "" {
+			return nil, fmt.Errorf("%s: %w", op, ErrNoSecret)
+		}
+	default:
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrMode, mode)
+	}
+
+	return &Anonymizer{mode: mode, key: []byte(secret)}, nil
+}
+
+// Mode returns the mode of a.
+func (a *Anonymizer) Mode() string {
+	return a.mode
+}
+
+// IP returns what is stored for ip. Values that aren't IPs are dropped
+// unless IPs are stored raw.
+func (a *Anonymizer) IP(ip string) string {
+	if a.mode == IPRaw {
+		return ip
+	}
+
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ""
+	}
+
+	if a.mode == IPHash {
+		mac := hmac.New(sha256.New, a.key)
+		mac.Write([]byte(parsed.String()))
+		return hex.EncodeToString(mac.Sum(nil)[:16])
+	}
+
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// OptedOut reports whether the visitor asked not to be tracked with the DNT
+// or Sec-GPC header.
+func OptedOut(r *http.Request) bool {
+	return r.Header.Get("DNT") == "1" || r.Header.Get("Sec-GPC") == "1"
+}
@@ -0,0 +1,50 @@
+package privacy
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnonymizer_IP(t *testing.T) {
+	raw, err := New("", "")
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.7", raw.IP("203.0.113.7"))
+
+	truncate, err := New(IPTruncate, "")
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.0", truncate.IP("203.0.113.7"))
+	assert.Equal(t, "2001:db8:85a3::", truncate.IP("2001:db8:85a3:8d3:1319:8a2e:370:7348"))
+	assert.Equal(t, "", truncate.IP("unknown"))
+
+	hash, err := New(IPHash, "secret")
+	require.NoError(t, err)
+	assert.Len(t, hash.IP("203.0.113.7"), 32)
+	assert.Equal(t, hash.IP("203.0.113.7"), hash.IP(" 203.0.113.7"))
+	assert.NotEqual(t, hash.IP("203.0.113.7"), hash.IP("203.0.113.8"))
+
+	other, err := New(IPHash, "other")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash.IP("203.0.113.7"), other.IP("203.0.113.7"))
+
+	_, err = New(IPHash, "")
+	assert.ErrorIs(t, err, ErrNoSecret)
+	_, err = New("mask", "")
+	assert.ErrorIs(t, err, ErrMode)
+}
+
+func TestOptedOut(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	assert.False(t, OptedOut(r))
+
+	r.Header.Set("Sec-GPC", "1")
+	assert.True(t, OptedOut(r))
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("DNT", "0")
+	assert.False(t, OptedOut(r))
+	r.Header.Set("DNT", "1")
+	assert.True(t, OptedOut(r))
+}
@@ -0,0 +1,189 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+
+	DefaultSize   = 256
+	DefaultMargin = 4
+	MinSize       = 64
+	MaxSize       = 2048
+	MaxMargin     = 16
+)
+
+var (
+	ErrInvalidColor = errors.New("color must be hex RGB, RRGGBB or RRGGBBAA")
+	ErrInvalidLevel = errors.New("error correction level must be L, M, Q or H")
+)
+
+type Options struct {
+	// Size is the width and height of the image in pixels.
+	Size int
+	// Margin is the quiet zone around the code in modules.
+	Margin     int
+	Level      qrcode.RecoveryLevel
+	Foreground color.NRGBA
+	Background color.NRGBA
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Size:       DefaultSize,
+		Margin:     DefaultMargin,
+		Level:      qrcode.Medium,
+		Foreground: color.NRGBA{A: 0xff},
+		Background: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+// ParseLevel maps the L, M, Q and H error correction levels of the QR spec.
+func ParseLevel(s string) (qrcode.RecoveryLevel, error) {
+	switch strings.ToUpper(s) {
+	case "L":
+		return qrcode.Low, nil
+	case "M":
+		return qrcode.Medium, nil
+	case "Q":
+		return qrcode.High, nil
+	case "H":
+		return qrcode.Highest, nil
+	default:
+		return 0, ErrInvalidLevel
+	}
+}
+
+// ParseColor parses RGB, RRGGBB and RRGGBBAA hex colors, with or without #.
+func ParseColor(s string) (color.NRGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+	if len(s) != 8 {
+		return color.NRGBA{}, ErrInvalidColor
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, ErrInvalidColor
+	}
+
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// modules returns the code with the requested quiet zone, bitmap[y][x] is
+// true for dark modules.
+func modules(content string, opts Options) ([][]bool, error) {
+	code, err := qrcode.New(content, opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+
+	symbol := code.Bitmap()
+	n := len(symbol) + 2*opts.Margin
+
+	bitmap := make([][]bool, n)
+	for y := range bitmap {
+		bitmap[y] = make([]bool, n)
+		if sy := y - opts.Margin; sy >= 0 && sy < len(symbol) {
+			copy(bitmap[y][opts.Margin:], symbol[sy])
+		}
+	}
+
+	return bitmap, nil
+}
+
+// PNG renders content with whole pixels per module, centered in a
+// Size x Size image. Codes with more modules than Size pixels are drawn at one
+// pixel per module instead.
+func PNG(content string, opts Options) ([]byte, error) {
+	bitmap, err := modules(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(bitmap)
+	scale := max(opts.Size/n, 1)
+	size := max(opts.Size, n)
+	offset := (size - n*scale) / 2
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{opts.Background, opts.Foreground})
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for py := 0; py < scale; py++ {
+				start := img.PixOffset(offset+x*scale, offset+y*scale+py)
+				for px := 0; px < scale; px++ {
+					img.Pix[start+px] = 1
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SVG renders content as a scalable image Size pixels wide, one unit per
+// module with horizontal runs of dark modules merged into single rectangles.
+func SVG(content string, opts Options) ([]byte, error) {
+	bitmap, err := modules(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(bitmap)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" %s/>`, n, n, fill(opts.Background))
+	fmt.Fprintf(&buf, `<path %s d="`, fill(opts.Foreground))
+	for y, row := range bitmap {
+		for x := 0; x < n; x++ {
+			if !row[x] {
+				continue
+			}
+			run := 1
+			for x+run < n && row[x+run] {
+				run++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", x, y, run, run)
+			x += run
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+
+	return buf.Bytes(), nil
+}
+
+func fill(c color.NRGBA) string {
+	attr := fmt.Sprintf(`fill="#%02x%02x%02x"`, c.R, c.G, c.B)
+	if c.A != 0xff {
+		attr += fmt.Sprintf(` fill-opacity="%.3g"`, float64(c.A)/0xff)
+	}
+
+	return attr
+}
@@ -0,0 +1,77 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPNG(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Size = 300
+	opts.Foreground = color.NRGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xff}
+
+	data, err := PNG("http://localhost:8080/promo", opts)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 300, img.Bounds().Dx())
+	assert.Equal(t, 300, img.Bounds().Dy())
+
+	// the corner is quiet zone, the finder pattern starts right after the margin
+	assert.Equal(t, color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, color.NRGBAModel.Convert(img.At(0, 0)))
+	bitmap, err := modules("http://localhost:8080/promo", opts)
+	require.NoError(t, err)
+	scale := 300 / len(bitmap)
+	offset := (300 - len(bitmap)*scale) / 2
+	corner := offset + opts.Margin*scale
+	assert.Equal(t, opts.Foreground, color.NRGBAModel.Convert(img.At(corner, corner)))
+}
+
+func TestSVG(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Margin = 0
+	opts.Background = color.NRGBA{}
+
+	data, err := SVG("http://localhost:8080/promo", opts)
+	require.NoError(t, err)
+
+	var doc struct {
+		Width   string `xml:"width,attr"`
+		ViewBox string `xml:"viewBox,attr"`
+	}
+	require.NoError(t, xml.Unmarshal(data, &doc))
+	assert.Equal(t, "256", doc.Width)
+	// 27 bytes need a version 3 code at level M, 29 modules wide
+	assert.Equal(t, "0 0 29 29", doc.ViewBox)
+	assert.Contains(t, string(data), `fill-opacity="0"`)
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want color.NRGBA
+		err  bool
+	}{
+		{in: "#fff", want: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{in: "1a2b3c", want: color.NRGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0xff}},
+		{in: "1a2b3c80", want: color.NRGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0x80}},
+		{in: "red", err: true},
+		{in: "#12345", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseColor(tt.in)
+		if tt.err {
+			assert.ErrorIs(t, err, ErrInvalidColor, tt.in)
+			continue
+		}
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+}
@@ -1,22 +1,51 @@
 package random
 
 import (
-	"math/rand"
-	"time"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"math/big"
 )
 
+const Base62 = "ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+	"abcdefghijklmnopqrstuvwxyz" +
+	"0123456789"
+
 // NewRandomString generates random string with given size.
 func NewRandomString(size int) string {
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s, err := NewString(Base62, size)
+	if err != nil {
+		// crypto/rand only fails when the OS entropy source is unusable
+		panic(err)
+	}
+
+	return s
+}
 
-	chars := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
-		"abcdefghijklmnopqrstuvwxyz" +
-		"0123456789")
+// NewString generates a random string of size characters taken from alphabet.
+// It reads from crypto/rand, so concurrent callers never share a seed.
+func NewString(alphabet string, size int) (string, error) {
+	chars := []rune(alphabet)
+	max := big.NewInt(int64(len(chars)))
 
 	b := make([]rune, size)
 	for i := range b {
-		b[i] = chars[rnd.Intn(len(chars))]
+		n, err := cryptorand.Int(cryptorand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = chars[n.Int64()]
+	}
+
+	return string(b), nil
+}
+
+// NewToken returns a hex encoded token built from size cryptographically
+// secure random bytes.
+func NewToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := cryptorand.Read(b); err != nil {
+		return "", err
 	}
 
-	return string(b)
+	return hex.EncodeToString(b), nil
 }
@@ -0,0 +1,42 @@
+package shorturl
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Host normalizes a host taken from a request or user input:
+// the port and trailing dot are dropped and the name is lower-cased.
+func Host(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// QRParam marks visits from QR codes. It is dropped from the query of the
+// short link before that is passed on to the destination.
+const QRParam = "qr"
+
+// QR returns shortURL as encoded in QR codes, with the QRParam marker.
+func QR(shortURL string) string {
+	return shortURL + "?" + QRParam + "=1"
+}
+
+// Build returns the full short url for alias. Links on the default domain
+// are built from baseURL, links on a custom domain reuse the scheme of
+// baseURL with the domain host.
+func Build(baseURL, host, alias string) string {
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Scheme == "" {
+		base = &url.URL{Scheme: "https"}
+	}
+
+	if host != "" {
+		base = &url.URL{Scheme: base.Scheme, Host: host}
+	}
+
+	return base.JoinPath(alias).String()
+}
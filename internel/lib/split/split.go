@@ -0,0 +1,61 @@
+package split
+
+import (
+	"errors"
+	"fmt"
+	"url-shortner/internel/domain/entities/variant"
+)
+
+// MaxVariants is the most destinations a link can be split between.
+const MaxVariants = 10
+
+var (
+	ErrTooFewVariants  = errors.New("a split needs at least two variants")
+	ErrTooManyVariants = fmt.Errorf("a link can have at most %d variants", MaxVariants)
+)
+
+// Check rejects variant lists that can't be split, the weights and
+// destinations are validated with the rest of the request.
+func Check(variants []variant.Variant) error {
+	if len(variants) == 1 {
+		return ErrTooFewVariants
+	}
+	if len(variants) > MaxVariants {
+		return ErrTooManyVariants
+	}
+
+	return nil
+}
+
+// Pick chooses a variant with a probability proportional to its weight,
+// intn returns a random number in [0, n) like rand.Intn.
+func Pick(variants []variant.Variant, intn func(n int) int) variant.Variant {
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return variant.Variant{}
+	}
+
+	n := intn(total)
+	for _, v := range variants {
+		if n < v.Weight {
+			return v
+		}
+		n -= v.Weight
+	}
+
+	return variants[len(variants)-1]
+}
+
+// Find returns the variant with id.
+func Find(variants []variant.Variant, id int64) (variant.Variant, bool) {
+	for _, v := range variants {
+		if v.Id == id {
+			return v, true
+		}
+	}
+
+	return variant.Variant{}, false
+}
@@ -0,0 +1,41 @@
+package split
+
+import (
+	"testing"
+	"url-shortner/internel/domain/entities/variant"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPick(t *testing.T) {
+	variants := []variant.Variant{
+		{Id: 1, URL: "https://a.example", Weight: 1},
+		{Id: 2, URL: "https://b.example", Weight: 3},
+	}
+
+	counts := make(map[int64]int)
+	for n := 0; n < 4; n++ {
+		counts[Pick(variants, func(int) int { return n }).Id]++
+	}
+	assert.Equal(t, map[int64]int{1: 1, 2: 3}, counts)
+
+	assert.Equal(t, variant.Variant{}, Pick(nil, func(int) int { return 0 }))
+}
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check(nil))
+	assert.ErrorIs(t, Check(make([]variant.Variant, 1)), ErrTooFewVariants)
+	assert.NoError(t, Check(make([]variant.Variant, 2)))
+	assert.ErrorIs(t, Check(make([]variant.Variant, MaxVariants+1)), ErrTooManyVariants)
+}
+
+func TestFind(t *testing.T) {
+	variants := []variant.Variant{{Id: 1}, {Id: 2}}
+
+	v, ok := Find(variants, 2)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), v.Id)
+
+	_, ok = Find(variants, 3)
+	assert.False(t, ok)
+}
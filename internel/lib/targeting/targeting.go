@@ -0,0 +1,174 @@
+package targeting
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"url-shortner/internel/domain/entities/rule"
+
+	"github.com/mssola/useragent"
+)
+
+const (
+	OSIOS      = "ios"
+	OSAndroid  = "android"
+	OSWindows  = "windows"
+	OSMacOS    = "macos"
+	OSLinux    = "linux"
+	OSChromeOS = "chromeos"
+
+	DeviceMobile  = "mobile"
+	DeviceDesktop = "desktop"
+
+	BrowserChrome  = "chrome"
+	BrowserFirefox = "firefox"
+	BrowserSafari  = "safari"
+	BrowserEdge    = "edge"
+	BrowserOpera   = "opera"
+	BrowserIE      = "ie"
+
+	// Other is what visitors that aren't recognised are classified as.
+	Other = "other"
+
+	// MaxRules is the most rules a link can have.
+	MaxRules = 20
+)
+
+var (
+	ErrNoCondition    = errors.New("rule needs at least one condition")
+	ErrBadCountry     = errors.New("countries must be two letter ISO 3166-1 codes")
+	ErrBadContinent   = errors.New("continents must be one of AF, AN, AS, EU, NA, OC or SA")
+	ErrUnknownOS      = errors.New("os must be one of ios, android, windows, macos, linux, chromeos or other")
+	ErrUnknownDevice  = errors.New("device must be mobile or desktop")
+	ErrUnknownBrowser = errors.New("browser must be one of chrome, firefox, safari, edge, opera, ie or other")
+	ErrTooManyRules   = fmt.Errorf("a link can have at most %d rules", MaxRules)
+)
+
+var (
+	knownOS       = []string{OSIOS, OSAndroid, OSWindows, OSMacOS, OSLinux, OSChromeOS, Other}
+	knownDevices  = []string{DeviceMobile, DeviceDesktop}
+	knownBrowsers = []string{BrowserChrome, BrowserFirefox, BrowserSafari, BrowserEdge, BrowserOpera, BrowserIE, Other}
+	continents    = []string{"AF", "AN", "AS", "EU", "NA", "OC", "SA"}
+)
+
+// Visitor is what rules are matched against. Country and Continent are
+// empty when the location is unknown, rules with countries or continents
+// never match such visitors.
+type Visitor struct {
+	OS        string
+	Device    string
+	Browser   string
+	Country   string
+	Continent string
+}
+
+// FromUserAgent classifies a parsed User-Agent into the values rules use.
+func FromUserAgent(ua *useragent.UserAgent) Visitor {
+	v := Visitor{OS: Other, Device: DeviceDesktop, Browser: Other}
+	if ua.Mobile() {
+		v.Device = DeviceMobile
+	}
+
+	name := ua.OSInfo().Name
+	switch platform := ua.Platform(); {
+	case platform == "iPhone" || platform == "iPad" || platform == "iPod" || name == "iPhone OS":
+		v.OS = OSIOS
+	case name == "Android":
+		v.OS = OSAndroid
+	case name == "Windows" || strings.HasPrefix(name, "Windows "):
+		v.OS = OSWindows
+	case name == "Mac OS X" || platform == "Macintosh":
+		v.OS = OSMacOS
+	case strings.HasPrefix(name, "CrOS"):
+		v.OS = OSChromeOS
+	case name == "Linux" || strings.HasPrefix(name, "Linux "):
+		v.OS = OSLinux
+	}
+
+	browser, _ := ua.Browser()
+	switch browser {
+	case "Chrome", "Chromium", "Headless Chrome":
+		v.Browser = BrowserChrome
+	case "Firefox":
+		v.Browser = BrowserFirefox
+	case "Safari":
+		v.Browser = BrowserSafari
+	case "Edge":
+		v.Browser = BrowserEdge
+	case "Opera":
+		v.Browser = BrowserOpera
+	case "Internet Explorer":
+		v.Browser = BrowserIE
+	}
+
+	return v
+}
+
+// Match returns the first rule whose conditions all hold for v.
+func Match(rules []rule.Rule, v Visitor) (rule.Rule, bool) {
+	for _, r := range rules {
+		if matches(r.OS, v.OS) && matches(r.Device, v.Device) && matches(r.Browser, v.Browser) &&
+			matchesAny(r.Countries, v.Country) && matchesAny(r.Continents, v.Continent) {
+			return r, true
+		}
+	}
+
+	return rule.Rule{}, false
+}
+
+func matches(condition, value string) bool {
+	return condition == "" || condition == value
+}
+
+func matchesAny(conditions []string, value string) bool {
+	return len(conditions) == 0 || (value != "" && slices.Contains(conditions, value))
+}
+
+// Normalize lowercases the conditions of rules, uppercases their country and
+// continent codes and checks them. The destinations are validated with the
+// rest of the request.
+func Normalize(rules []rule.Rule) error {
+	if len(rules) > MaxRules {
+		return ErrTooManyRules
+	}
+
+	for i := range rules {
+		r := &rules[i]
+		r.OS = strings.ToLower(strings.TrimSpace(r.OS))
+		r.Device = strings.ToLower(strings.TrimSpace(r.Device))
+		r.Browser = strings.ToLower(strings.TrimSpace(r.Browser))
+
+		for j, country := range r.Countries {
+			r.Countries[j] = strings.ToUpper(strings.TrimSpace(country))
+			if !isCountryCode(r.Countries[j]) {
+				return fmt.Errorf("rule %d: %w", i+1, ErrBadCountry)
+			}
+		}
+		for j, continent := range r.Continents {
+			r.Continents[j] = strings.ToUpper(strings.TrimSpace(continent))
+			if !slices.Contains(continents, r.Continents[j]) {
+				return fmt.Errorf("rule %d: %w", i+1, ErrBadContinent)
+			}
+		}
+
+		if r.OS == "" && r.Device == "" && r.Browser == "" && len(r.Countries) == 0 && len(r.Continents) == 0 {
+			return fmt.Errorf("rule %d: %w", i+1, ErrNoCondition)
+		}
+		if r.OS != "" && !slices.Contains(knownOS, r.OS) {
+			return fmt.Errorf("rule %d: %w", i+1, ErrUnknownOS)
+		}
+		if r.Device != "" && !slices.Contains(knownDevices, r.Device) {
+			return fmt.Errorf("rule %d: %w", i+1, ErrUnknownDevice)
+		}
+		if r.Browser != "" && !slices.Contains(knownBrowsers, r.Browser) {
+			return fmt.Errorf("rule %d: %w", i+1, ErrUnknownBrowser)
+		}
+	}
+
+	return nil
+}
+
+func isCountryCode(code string) bool {
+	return len(code) == 2 && code[0] >= 'A' && code[0] <= 'Z' && code[1] >= 'A' && code[1] <= 'Z'
+}
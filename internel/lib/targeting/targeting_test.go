@@ -0,0 +1,110 @@
+package targeting
+
+import (
+	"testing"
+	"url-shortner/internel/domain/entities/rule"
+
+	"github.com/mssola/useragent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromUserAgent(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want Visitor
+	}{
+		{
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 16_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.0 Mobile/15E148 Safari/604.1",
+			want: Visitor{OS: OSIOS, Device: DeviceMobile, Browser: BrowserSafari},
+		},
+		{
+			ua:   "Mozilla/5.0 (iPad; CPU OS 16_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.0 Mobile/15E148 Safari/604.1",
+			want: Visitor{OS: OSIOS, Device: DeviceMobile, Browser: BrowserSafari},
+		},
+		{
+			ua:   "Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Mobile Safari/537.36",
+			want: Visitor{OS: OSAndroid, Device: DeviceMobile, Browser: BrowserChrome},
+		},
+		{
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Safari/537.36 Edg/116.0.1938.69",
+			want: Visitor{OS: OSWindows, Device: DeviceDesktop, Browser: BrowserEdge},
+		},
+		{
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.0 Safari/605.1.15",
+			want: Visitor{OS: OSMacOS, Device: DeviceDesktop, Browser: BrowserSafari},
+		},
+		{
+			ua:   "Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/117.0",
+			want: Visitor{OS: OSLinux, Device: DeviceDesktop, Browser: BrowserFirefox},
+		},
+		{
+			ua:   "Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/116.0.0.0 Safari/537.36",
+			want: Visitor{OS: OSChromeOS, Device: DeviceDesktop, Browser: BrowserChrome},
+		},
+		{
+			ua:   "curl/8.0",
+			want: Visitor{OS: Other, Device: DeviceDesktop, Browser: Other},
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, FromUserAgent(useragent.New(tt.ua)), tt.ua)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	rules := []rule.Rule{
+		{Id: 1, OS: OSIOS, URL: "https://apps.apple.com/app"},
+		{Id: 2, OS: OSAndroid, Browser: BrowserChrome, URL: "https://play.google.com/app"},
+		{Id: 3, Device: DeviceMobile, URL: "https://m.example.com"},
+	}
+
+	r, ok := Match(rules, Visitor{OS: OSIOS, Device: DeviceMobile, Browser: BrowserChrome})
+	require.True(t, ok)
+	assert.Equal(t, int64(1), r.Id)
+
+	r, ok = Match(rules, Visitor{OS: OSAndroid, Device: DeviceMobile, Browser: BrowserFirefox})
+	require.True(t, ok)
+	assert.Equal(t, int64(3), r.Id, "all conditions must hold")
+
+	_, ok = Match(rules, Visitor{OS: OSWindows, Device: DeviceDesktop, Browser: BrowserEdge})
+	assert.False(t, ok)
+}
+
+func TestMatch_Location(t *testing.T) {
+	rules := []rule.Rule{
+		{Id: 1, Countries: []string{"DE", "AT"}, Device: DeviceMobile, URL: "https://m.example.de"},
+		{Id: 2, Countries: []string{"DE", "AT"}, URL: "https://example.de"},
+		{Id: 3, Continents: []string{"EU"}, URL: "https://example.eu"},
+	}
+
+	r, ok := Match(rules, Visitor{Device: DeviceDesktop, Country: "AT", Continent: "EU"})
+	require.True(t, ok)
+	assert.Equal(t, int64(2), r.Id)
+
+	r, ok = Match(rules, Visitor{Device: DeviceDesktop, Country: "FR", Continent: "EU"})
+	require.True(t, ok)
+	assert.Equal(t, int64(3), r.Id)
+
+	_, ok = Match(rules, Visitor{Device: DeviceMobile})
+	assert.False(t, ok, "unknown locations match no location rule")
+}
+
+func TestNormalize(t *testing.T) {
+	rules := []rule.Rule{{OS: " iOS ", Device: "Mobile", URL: "https://a.example"}}
+	require.NoError(t, Normalize(rules))
+	assert.Equal(t, rule.Rule{OS: OSIOS, Device: DeviceMobile, URL: "https://a.example"}, rules[0])
+
+	assert.ErrorIs(t, Normalize([]rule.Rule{{URL: "https://a.example"}}), ErrNoCondition)
+	assert.ErrorIs(t, Normalize([]rule.Rule{{OS: "symbian"}}), ErrUnknownOS)
+	assert.ErrorIs(t, Normalize([]rule.Rule{{Device: "tv"}}), ErrUnknownDevice)
+	assert.ErrorIs(t, Normalize([]rule.Rule{{Browser: "lynx"}}), ErrUnknownBrowser)
+	assert.ErrorIs(t, Normalize([]rule.Rule{{Countries: []string{"DEU"}}}), ErrBadCountry)
+	assert.ErrorIs(t, Normalize([]rule.Rule{{Continents: []string{"XX"}}}), ErrBadContinent)
+
+	rules = []rule.Rule{{Countries: []string{"de"}, Continents: []string{" eu"}}}
+	require.NoError(t, Normalize(rules))
+	assert.Equal(t, []string{"DE"}, rules[0].Countries)
+	assert.Equal(t, []string{"EU"}, rules[0].Continents)
+	assert.ErrorIs(t, Normalize(make([]rule.Rule, MaxRules+1)), ErrTooManyRules)
+}
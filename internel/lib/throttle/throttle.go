@@ -0,0 +1,92 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter counts failures per key and blocks a key for the rest of the
+// window once it reached the limit.
+type Limiter struct {
+	max    int
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	checks  int
+}
+
+type entry struct {
+	failures int
+	start    time.Time
+}
+
+// sweepEvery is how many calls pass between removals of expired keys.
+const sweepEvery = 1024
+
+func New(max int, window time.Duration) *Limiter {
+	return &Limiter{
+		max:     max,
+		window:  window,
+		now:     time.Now,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Allow reports whether key may try again, and if not, how long it has to wait.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	e, ok := l.entries[key]
+	if ok && now.Sub(e.start) >= l.window {
+		delete(l.entries, key)
+		ok = false
+	}
+	if !ok || e.failures < l.max {
+		return true, 0
+	}
+
+	return false, e.start.Add(l.window).Sub(now)
+}
+
+// Fail records a failed attempt of key.
+func (l *Limiter) Fail(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	e, ok := l.entries[key]
+	if !ok || now.Sub(e.start) >= l.window {
+		e = &entry{start: now}
+		l.entries[key] = e
+	}
+	e.failures++
+}
+
+// Reset forgets the failures of key, e.g. after a successful attempt.
+func (l *Limiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.entries, key)
+}
+
+// sweep removes expired keys every sweepEvery calls, so keys that are never
+// tried again don't pile up.
+func (l *Limiter) sweep(now time.Time) {
+	l.checks++
+	if l.checks%sweepEvery != 0 {
+		return
+	}
+
+	for key, e := range l.entries {
+		if now.Sub(e.start) >= l.window {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := New(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	ok, _ := l.Allow("a")
+	assert.True(t, ok)
+
+	l.Fail("a")
+	l.Fail("a")
+	ok, wait := l.Allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, time.Minute, wait)
+
+	ok, _ = l.Allow("b")
+	assert.True(t, ok, "keys are limited separately")
+
+	now = now.Add(40 * time.Second)
+	_, wait = l.Allow("a")
+	assert.Equal(t, 20*time.Second, wait)
+
+	now = now.Add(20 * time.Second)
+	ok, _ = l.Allow("a")
+	assert.True(t, ok, "window expired")
+
+	l.Fail("a")
+	l.Fail("a")
+	l.Reset("a")
+	ok, _ = l.Allow("a")
+	assert.True(t, ok, "reset")
+}
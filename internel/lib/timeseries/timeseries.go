@@ -0,0 +1,214 @@
+package timeseries
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortner/internel/domain/entities/analytics"
+)
+
+const (
+	Hour  = "hour"
+	Day   = "day"
+	Week  = "week"
+	Month = "month"
+)
+
+const (
+	MaxBuckets = 1000
+	DefaultTop = 10
+	MaxTop     = 100
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	ErrInterval       = errors.New("interval must be hour, day, week or month")
+	ErrTimezone       = errors.New("unknown timezone")
+	ErrTime           = errors.New("time must be RFC 3339 or a date")
+	ErrRange          = errors.New("from must be before to")
+	ErrTooManyBuckets = fmt.Errorf("range has more than %d buckets", MaxBuckets)
+	ErrTop            = fmt.Errorf("top must be between 1 and %d", MaxTop)
+	ErrQRFilter       = errors.New("qr must be true or false")
+)
+
+// defaultBuckets is how many buckets are reported when from is not given.
+var defaultBuckets = map[string]int{Hour: 24, Day: 30, Week: 12, Month: 12}
+
+// ParseQuery reads a report query from the ?interval=, ?tz=, ?from=, ?to=,
+// ?top= and ?bots= parameters and a parameter per filter dimension. Times
+// are RFC 3339 or dates in tz, a date as to includes that day. The range is
+// widened to whole buckets, without from it ends with the bucket of to,
+// which defaults to now.
+func ParseQuery(values url.Values, now time.Time) (analytics.Query, error) {
+	query := analytics.Query{
+		Interval: Day,
+		Location: time.UTC,
+		Top:      DefaultTop,
+	}
+
+	if interval := values.Get("interval"); interval != "" {
+		if _, ok := defaultBuckets[interval]; !ok {
+			return analytics.Query{}, ErrInterval
+		}
+		query.Interval = interval
+	}
+
+	if tz := values.Get("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return analytics.Query{}, fmt.Errorf("%w: %s", ErrTimezone, tz)
+		}
+		query.Location = loc
+	}
+
+	if top := values.Get("top"); top != "" {
+		n, err := strconv.Atoi(top)
+		if err != nil || n < 1 || n > MaxTop {
+			return analytics.Query{}, ErrTop
+		}
+		query.Top = n
+	}
+
+	query.IncludeBots, _ = strconv.ParseBool(values.Get("bots"))
+
+	filters, err := ParseFilters(values)
+	if err != nil {
+		return analytics.Query{}, err
+	}
+	query.Filters = filters
+
+	to := now.In(query.Location)
+	if s := values.Get("to"); s != "" {
+		t, date, err := ParseTime(s, query.Location)
+		if err != nil {
+			return analytics.Query{}, err
+		}
+		if date {
+			t = t.AddDate(0, 0, 1)
+		}
+		// the bucket of to would be added for the instant itself
+		to = t.Add(-time.Nanosecond)
+	}
+
+	from := Truncate(to, query.Interval, query.Location)
+	from = step(from, query.Interval, 1-defaultBuckets[query.Interval])
+	if s := values.Get("from"); s != "" {
+		t, _, err := ParseTime(s, query.Location)
+		if err != nil {
+			return analytics.Query{}, err
+		}
+		from = t
+	}
+
+	bounds, err := Bounds(from, to, query.Interval, query.Location)
+	if err != nil {
+		return analytics.Query{}, err
+	}
+	query.Bounds = bounds
+
+	return query, nil
+}
+
+// ParseFilters reads the filter dimensions that are set, values are
+// normalized to how clicks store them.
+func ParseFilters(values url.Values) (map[string]string, error) {
+	filters := make(map[string]string)
+	for _, dimension := range analytics.FilterDimensions {
+		if !values.Has(dimension) {
+			continue
+		}
+
+		value := strings.TrimSpace(values.Get(dimension))
+		switch dimension {
+		case "country":
+			value = strings.ToUpper(value)
+		case "referrer", "language":
+			value = strings.ToLower(value)
+		case "qr":
+			viaQR, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, ErrQRFilter
+			}
+			value = "0"
+			if viaQR {
+				value = "1"
+			}
+		}
+		filters[dimension] = value
+	}
+
+	return filters, nil
+}
+
+// Bounds returns the starts of the buckets of interval in loc that cover
+// from up to and including to, followed by the end of the last one.
+func Bounds(from, to time.Time, interval string, loc *time.Location) ([]time.Time, error) {
+	if _, ok := defaultBuckets[interval]; !ok {
+		return nil, ErrInterval
+	}
+	if to.Before(from) {
+		return nil, ErrRange
+	}
+
+	bounds := []time.Time{Truncate(from, interval, loc)}
+	for !bounds[len(bounds)-1].After(to) {
+		if len(bounds) > MaxBuckets {
+			return nil, ErrTooManyBuckets
+		}
+		bounds = append(bounds, step(bounds[len(bounds)-1], interval, 1))
+	}
+
+	return bounds, nil
+}
+
+// Truncate returns the start of the bucket of interval in loc that t falls
+// into. Weeks start on Monday.
+func Truncate(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	year, month, day := t.Date()
+
+	switch interval {
+	case Hour:
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, loc)
+	case Week:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, loc)
+	case Month:
+		return time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	}
+}
+
+// step moves the bucket start t by n intervals. Days keep their wall clock
+// start across daylight saving changes, so they are not always 24 hours.
+func step(t time.Time, interval string, n int) time.Time {
+	switch interval {
+	case Hour:
+		return t.Add(time.Duration(n) * time.Hour)
+	case Week:
+		return t.AddDate(0, 0, 7*n)
+	case Month:
+		return t.AddDate(0, n, 0)
+	default:
+		return t.AddDate(0, 0, n)
+	}
+}
+
+// ParseTime reads s as RFC 3339 or as a date in loc, reporting which one.
+func ParseTime(s string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation(dateLayout, s, loc); err == nil {
+		return t, true, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: %s", ErrTime, s)
+	}
+
+	return t.In(loc), false, nil
+}
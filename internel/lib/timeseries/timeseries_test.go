@@ -0,0 +1,107 @@
+package timeseries
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBounds(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// the clocks go back an hour on 2026-10-25
+	from := time.Date(2026, 10, 24, 15, 0, 0, 0, berlin)
+	to := time.Date(2026, 10, 26, 1, 0, 0, 0, berlin)
+	bounds, err := Bounds(from, to, Day, berlin)
+	require.NoError(t, err)
+	require.Len(t, bounds, 4)
+	assert.Equal(t, time.Date(2026, 10, 24, 0, 0, 0, 0, berlin), bounds[0])
+	assert.Equal(t, 25*time.Hour, bounds[2].Sub(bounds[1]))
+	assert.Equal(t, time.Date(2026, 10, 27, 0, 0, 0, 0, berlin), bounds[3])
+
+	bounds, err = Bounds(from, to, Week, berlin)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 10, 19, 0, 0, 0, 0, berlin),
+		time.Date(2026, 10, 26, 0, 0, 0, 0, berlin),
+		time.Date(2026, 11, 2, 0, 0, 0, 0, berlin),
+	}, bounds)
+
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+	bounds, err = Bounds(time.Date(2026, 1, 1, 0, 10, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 40, 0, 0, time.UTC), Hour, kolkata)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 12, 31, 23, 30, 0, 0, time.UTC),
+		time.Date(2026, 1, 1, 0, 30, 0, 0, time.UTC),
+		time.Date(2026, 1, 1, 1, 30, 0, 0, time.UTC),
+	}, utc(bounds))
+
+	_, err = Bounds(to, from, Day, berlin)
+	assert.ErrorIs(t, err, ErrRange)
+	_, err = Bounds(from, from.AddDate(0, 0, MaxBuckets), Hour, berlin)
+	assert.ErrorIs(t, err, ErrTooManyBuckets)
+}
+
+func TestParseQuery(t *testing.T) {
+	now := time.Date(2026, 3, 15, 10, 30, 0, 0, time.UTC)
+
+	q, err := ParseQuery(url.Values{}, now)
+	require.NoError(t, err)
+	assert.Equal(t, Day, q.Interval)
+	assert.Equal(t, DefaultTop, q.Top)
+	assert.False(t, q.IncludeBots)
+	assert.Len(t, q.Bounds, 31)
+	assert.Equal(t, time.Date(2026, 2, 14, 0, 0, 0, 0, time.UTC), q.From())
+	assert.Equal(t, time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC), q.To())
+
+	q, err = ParseQuery(url.Values{
+		"interval": {"month"}, "tz": {"America/New_York"}, "from": {"2026-01-01"}, "to": {"2026-02-28"},
+		"top": {"3"}, "bots": {"true"},
+	}, now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, q.Top)
+	assert.True(t, q.IncludeBots)
+	assert.Equal(t, []time.Time{
+		time.Date(2026, 1, 1, 5, 0, 0, 0, time.UTC),
+		time.Date(2026, 2, 1, 5, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 1, 5, 0, 0, 0, time.UTC),
+	}, utc(q.Bounds))
+
+	q, err = ParseQuery(url.Values{"interval": {"hour"}, "to": {"2026-03-01T12:00:00Z"}}, now)
+	require.NoError(t, err)
+	assert.Len(t, q.Bounds, 25)
+	assert.Equal(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), q.To())
+
+	q, err = ParseQuery(url.Values{"country": {"de"}, "referrer": {"T.co"}, "qr": {"yes"}}, now)
+	assert.ErrorIs(t, err, ErrQRFilter)
+	q, err = ParseQuery(url.Values{"country": {"de"}, "referrer": {"T.co"}, "qr": {"true"}, "utm_source": {"News"}}, now)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"country": "DE", "referrer": "t.co", "qr": "1", "utm_source": "News"}, q.Filters)
+
+	for params, want := range map[string]error{
+		"interval=year":                 ErrInterval,
+		"tz=Mars/Olympus":               ErrTimezone,
+		"from=yesterday":                ErrTime,
+		"from=2026-04-01":               ErrRange,
+		"top=0":                         ErrTop,
+		"interval=hour&from=2020-01-01": ErrTooManyBuckets,
+	} {
+		values, err := url.ParseQuery(params)
+		require.NoError(t, err)
+		_, err = ParseQuery(values, now)
+		assert.ErrorIs(t, err, want, params)
+	}
+}
+
+func utc(times []time.Time) []time.Time {
+	out := make([]time.Time, len(times))
+	for i, t := range times {
+		out[i] = t.UTC()
+	}
+	return out
+}
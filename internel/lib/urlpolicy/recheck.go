@@ -0,0 +1,48 @@
+package urlpolicy
+
+import (
+	"fmt"
+	"url-shortner/internel/domain/entities/urlInfo"
+)
+
+type Store interface {
+	// GetURLDestinations returns every link with its url, rules, variants
+	// and disabled reason.
+	GetURLDestinations() ([]urlInfo.UrlInfo, error)
+	SetURLDisabled(id int64, reason string) error
+}
+
+// Recheck checks the destinations of all links again, links the policy
+// rejects are disabled with the reason and disabled links it accepts now are
+// enabled again.
+func Recheck(store Store, p *Policy) (disabled, enabled int, err error) {
+	const op = "lib.urlpolicy.Recheck"
+
+	links, err := store.GetURLDestinations()
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range links {
+		link := &links[i]
+
+		reason := ""
+		if err := p.CheckLink(link); err != nil {
+			reason = err.Error()
+		}
+		if reason == link.DisabledReason {
+			continue
+		}
+
+		if err := store.SetURLDisabled(link.Id, reason); err != nil {
+			return disabled, enabled, fmt.Errorf("%s: %w", op, err)
+		}
+		if reason == "" {
+			enabled++
+		} else {
+			disabled++
+		}
+	}
+
+	return disabled, enabled, nil
+}
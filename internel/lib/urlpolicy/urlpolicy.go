@@ -0,0 +1,288 @@
+package urlpolicy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"url-shortner/internel/domain/entities/urlInfo"
+)
+
+var (
+	ErrInvalidURL = errors.New("url is not valid")
+	ErrScheme     = errors.New("url scheme is not allowed")
+	ErrPrivate    = errors.New("url points to a private or loopback address")
+	ErrDenied     = errors.New("url domain is blocked")
+)
+
+// DefaultSchemes are the schemes allowed unless configured otherwise.
+var DefaultSchemes = []string{"http", "https"}
+
+type Options struct {
+	Schemes []string
+	// AllowList and DenyList are files of domains, either one per line or in
+	// hosts file format, lines starting with # are skipped. A domain also
+	// covers its subdomains. Allowed domains are exempt from the deny list
+	// and the private address check.
+	AllowList string
+	DenyList  string
+	// AllowPrivate accepts hosts that are private, loopback or link-local IP
+	// addresses and localhost.
+	AllowPrivate bool
+}
+
+// Policy decides which urls links may point to. The domain lists can be
+// reloaded while it is in use.
+type Policy struct {
+	schemes      map[string]struct{}
+	allowPrivate bool
+	allowPath    string
+	denyPath     string
+
+	mu       sync.RWMutex
+	allow    map[string]struct{}
+	deny     map[string]struct{}
+	modTimes [2]time.Time
+}
+
+func New(opts Options) (*Policy, error) {
+	const op = "lib.urlpolicy.New"
+
+	schemes := opts.Schemes
+	if len(schemes) == 0 {
+		schemes = DefaultSchemes
+	}
+
+	p := &Policy{
+		schemes:      make(map[string]struct{}, len(schemes)),
+		allowPrivate: opts.AllowPrivate,
+		allowPath:    opts.AllowList,
+		denyPath:     opts.DenyList,
+	}
+	for _, scheme := range schemes {
+		p.schemes[strings.ToLower(strings.TrimSpace(scheme))] = struct{}{}
+	}
+
+	if _, err := p.Reload(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+// Reload reads the domain lists again if their files changed since the last
+// load and reports whether they did. The old lists stay in use on errors.
+func (p *Policy) Reload() (bool, error) {
+	const op = "lib.urlpolicy.Reload"
+
+	modTimes, err := listModTimes(p.allowPath, p.denyPath)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	p.mu.RLock()
+	unchanged := modTimes == p.modTimes && p.allow != nil
+	p.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	allow, err := readDomainList(p.allowPath)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	deny, err := readDomainList(p.denyPath)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	p.mu.Lock()
+	p.allow, p.deny, p.modTimes = allow, deny, modTimes
+	p.mu.Unlock()
+
+	return true, nil
+}
+
+// Check returns why rawURL can't be used as a destination, nil when it can.
+func (p *Policy) Check(rawURL string) error {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ErrInvalidURL
+	}
+
+	if _, ok := p.schemes[strings.ToLower(u.Scheme)]; !ok {
+		return fmt.Errorf("%w: %q", ErrScheme, u.Scheme)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return ErrInvalidURL
+	}
+
+	p.mu.RLock()
+	allowed := matches(p.allow, host)
+	denied := matches(p.deny, host)
+	p.mu.RUnlock()
+
+	if allowed {
+		return nil
+	}
+	if !p.allowPrivate && isPrivate(host) {
+		return fmt.Errorf("%w: %s", ErrPrivate, host)
+	}
+	if denied {
+		return fmt.Errorf("%w: %s", ErrDenied, host)
+	}
+
+	return nil
+}
+
+// CheckLink checks all destinations of link, its url and those of its rules
+// and variants.
+func (p *Policy) CheckLink(link *urlInfo.UrlInfo) error {
+	if err := p.Check(link.Url); err != nil {
+		return err
+	}
+	for _, r := range link.Rules {
+		if err := p.Check(r.URL); err != nil {
+			return fmt.Errorf("rule: %w", err)
+		}
+	}
+	for _, v := range link.Variants {
+		if err := p.Check(v.URL); err != nil {
+			return fmt.Errorf("variant: %w", err)
+		}
+	}
+
+	return nil
+}
+
+var dialer = &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+// DialContext connects like a net.Dialer, for use as the DialContext of an
+// http.Transport. Unless private addresses or the host are allowed, it
+// refuses private, loopback and link-local addresses the host resolves to,
+// which Check can't see from the name alone.
+func (p *Policy) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	p.mu.RLock()
+	allowed := p.allowPrivate || matches(p.allow, host)
+	p.mu.RUnlock()
+	if allowed {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	guarded := *dialer
+	// Control runs for every address the host resolves to, right before
+	// connecting to it
+	guarded.Control = func(_, address string, _ syscall.RawConn) error {
+		ip, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		ip, _, _ = strings.Cut(ip, "%")
+		if isPrivate(ip) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrPrivate, host, ip)
+		}
+		return nil
+	}
+
+	return guarded.DialContext(ctx, network, addr)
+}
+
+// matches reports whether host or one of its parent domains is in list.
+func matches(list map[string]struct{}, host string) bool {
+	if len(list) == 0 {
+		return false
+	}
+
+	for {
+		if _, ok := list[host]; ok {
+			return true
+		}
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			return false
+		}
+		host = host[i+1:]
+	}
+}
+
+// isPrivate reports whether host names this machine or a private network.
+// Hosts are not resolved, only IP addresses, including the single number
+// form browsers accept, and localhost are recognized.
+func isPrivate(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		n, err := strconv.ParseUint(host, 0, 32)
+		if err != nil {
+			return false
+		}
+		ip = net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast()
+}
+
+func listModTimes(paths ...string) ([2]time.Time, error) {
+	var modTimes [2]time.Time
+	for i, path := range paths {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return modTimes, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+
+	return modTimes, nil
+}
+
+// readDomainList reads a file with a domain per line or in hosts file
+// format, where the domains follow an IP address.
+func readDomainList(path string) (map[string]struct{}, error) {
+	domains := make(map[string]struct{})
+	if path == "" {
+		return domains, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(strings.ToLower(line))
+		if len(fields) > 1 && net.ParseIP(fields[0]) != nil {
+			fields = fields[1:]
+		}
+		for _, domain := range fields {
+			domains[strings.TrimSuffix(domain, ".")] = struct{}{}
+		}
+	}
+
+	return domains, scanner.Err()
+}
@@ -0,0 +1,109 @@
+package urlpolicy
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	dir := t.TempDir()
+	deny := filepath.Join(dir, "deny.hosts")
+	allow := filepath.Join(dir, "allow.txt")
+	require.NoError(t, os.WriteFile(deny, []byte("# blocked\n0.0.0.0 evil.example phish.example\nbad.example\n"), 0o600))
+	require.NoError(t, os.WriteFile(allow, []byte("good.evil.example\nintranet.local # ok\n"), 0o600))
+
+	p, err := New(Options{AllowList: allow, DenyList: deny})
+	require.NoError(t, err)
+
+	tests := []struct {
+		url string
+		err error
+	}{
+		{url: "https://example.com/path", err: nil},
+		{url: "javascript:alert(1)", err: ErrScheme},
+		{url: "data:text/html,hi", err: ErrScheme},
+		{url: "ftp://example.com", err: ErrScheme},
+		{url: "https://evil.example", err: ErrDenied},
+		{url: "https://www.Phish.Example./login", err: ErrDenied},
+		{url: "http://bad.example:8080", err: ErrDenied},
+		{url: "https://good.evil.example", err: nil},
+		{url: "http://127.0.0.1/admin", err: ErrPrivate},
+		{url: "http://2130706433/", err: ErrPrivate},
+		{url: "http://[::1]:8080", err: ErrPrivate},
+		{url: "http://192.168.1.1", err: ErrPrivate},
+		{url: "http://169.254.169.254/latest", err: ErrPrivate},
+		{url: "http://localhost:3000", err: ErrPrivate},
+		{url: "http://intranet.local", err: nil},
+		{url: "https://", err: ErrInvalidURL},
+	}
+	for _, tt := range tests {
+		assert.ErrorIs(t, p.Check(tt.url), tt.err, tt.url)
+	}
+}
+
+func TestPolicy_Reload(t *testing.T) {
+	deny := filepath.Join(t.TempDir(), "deny.txt")
+	require.NoError(t, os.WriteFile(deny, []byte("evil.example\n"), 0o600))
+
+	p, err := New(Options{DenyList: deny, AllowPrivate: true})
+	require.NoError(t, err)
+	assert.NoError(t, p.Check("http://127.0.0.1"))
+
+	changed, err := p.Reload()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	require.NoError(t, os.WriteFile(deny, []byte("other.example\n"), 0o600))
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(deny, later, later))
+
+	changed, err = p.Reload()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.NoError(t, p.Check("https://evil.example"))
+	assert.ErrorIs(t, p.Check("https://other.example"), ErrDenied)
+
+	require.NoError(t, os.Remove(deny))
+	_, err = p.Reload()
+	assert.Error(t, err)
+	assert.ErrorIs(t, p.Check("https://other.example"), ErrDenied)
+}
+
+func TestPolicy_DialContext(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	allow := filepath.Join(t.TempDir(), "allow.txt")
+	require.NoError(t, os.WriteFile(allow, []byte("localhost\n"), 0o600))
+
+	strict, err := New(Options{})
+	require.NoError(t, err)
+	open, err := New(Options{AllowPrivate: true})
+	require.NoError(t, err)
+	allowed, err := New(Options{AllowList: allow})
+	require.NoError(t, err)
+
+	dial := func(p *Policy, host string) error {
+		conn, err := p.DialContext(context.Background(), "tcp", net.JoinHostPort(host, port))
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	assert.ErrorIs(t, dial(strict, "127.0.0.1"), ErrPrivate)
+	// names are checked by the address they resolve to
+	assert.ErrorIs(t, dial(strict, "localhost"), ErrPrivate)
+	assert.NoError(t, dial(open, "127.0.0.1"))
+	assert.NoError(t, dial(allowed, "localhost"))
+	assert.ErrorIs(t, dial(allowed, "127.0.0.1"), ErrPrivate)
+}
@@ -0,0 +1,62 @@
+package visitor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Hasher turns an IP address and user agent into an id that is the same for
+// a visitor during one UTC day. The salt of each day is derived from a
+// secret, so ids can't be linked across days or traced back to the IP
+// without it.
+type Hasher struct {
+	secret []byte
+
+	mu   sync.Mutex
+	day  string
+	salt []byte
+}
+
+// New uses secret for the daily salts, a random one when it is empty, which
+// makes ids change when the service restarts.
+func New(secret string) (*Hasher, error) {
+	const op = "lib.visitor.New"
+
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return &Hasher{secret: key}, nil
+}
+
+// Hash returns the id of the visitor with ip and userAgent on the day of now.
+func (h *Hasher) Hash(ip, userAgent string, now time.Time) string {
+	mac := hmac.New(sha256.New, h.daySalt(now.UTC().Format(time.DateOnly)))
+	mac.Write([]byte(ip))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userAgent))
+
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+func (h *Hasher) daySalt(day string) []byte {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if day != h.day {
+		mac := hmac.New(sha256.New, h.secret)
+		mac.Write([]byte(day))
+		h.day, h.salt = day, mac.Sum(nil)
+	}
+
+	return h.salt
+}
@@ -0,0 +1,29 @@
+package visitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasher_Hash(t *testing.T) {
+	h, err := New("secret")
+	require.NoError(t, err)
+
+	morning := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	evening := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	nextDay := time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)
+
+	id := h.Hash("203.0.113.7", "Firefox", morning)
+	assert.Len(t, id, 32)
+	assert.Equal(t, id, h.Hash("203.0.113.7", "Firefox", evening))
+	assert.NotEqual(t, id, h.Hash("203.0.113.7", "Firefox", nextDay))
+	assert.NotEqual(t, id, h.Hash("203.0.113.8", "Firefox", morning))
+	assert.NotEqual(t, id, h.Hash("203.0.113.7", "Chrome", morning))
+
+	other, err := New("other secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, id, other.Hash("203.0.113.7", "Firefox", morning))
+}
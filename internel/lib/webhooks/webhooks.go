@@ -0,0 +1,461 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/user"
+	"url-shortner/internel/domain/entities/webhook"
+	"url-shortner/internel/lib/clickstream"
+	"url-shortner/internel/lib/logger/sl"
+	"url-shortner/internel/lib/shorturl"
+)
+
+const (
+	DefaultMaxAttempts  = 8
+	DefaultBackoff      = 30 * time.Second
+	DefaultMaxBackoff   = 6 * time.Hour
+	DefaultTimeout      = 10 * time.Second
+	DefaultConcurrency  = 4
+	DefaultPollInterval = 10 * time.Second
+	DefaultBatchSize    = 100
+	DefaultClickBuffer  = 1024
+	DefaultUserAgent    = "url-shortener-webhook/1.0"
+)
+
+// Headers of every delivery. The signature is the hex HMAC-SHA256 of the
+// timestamp, a dot and the body, keyed with the secret of the webhook.
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+var ErrStatus = errors.New("webhook responded with a non 2xx status")
+
+type Store interface {
+	SaveWebhookDeliveries(owner webhook.Owner, event webhook.Event, payload string) (int64, error)
+	SaveWebhookDelivery(d *webhook.Delivery) (int64, error)
+	GetDueWebhookDeliveries(now time.Time, limit int) ([]webhook.Pending, error)
+	UpdateWebhookDelivery(d webhook.Delivery, nextAttempt time.Time) error
+}
+
+// Dialer connects to receivers, urlpolicy.Policy is one.
+type Dialer interface {
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+type Options struct {
+	// Dialer checks the addresses receivers resolve to at every delivery,
+	// their urls are only checked when webhooks are created. nil connects
+	// to any address.
+	Dialer Dialer
+	// MaxAttempts is how often a delivery is tried before it fails.
+	MaxAttempts int
+	// Backoff is the wait before the second attempt, it doubles with every
+	// further attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Timeout bounds each attempt.
+	Timeout time.Duration
+	// Concurrency is how many deliveries are sent at the same time.
+	Concurrency int
+	// PollInterval is how often due retries are looked for, new events are
+	// sent right away.
+	PollInterval time.Duration
+	BatchSize    int
+	// ClickBuffer is how many clicks can wait to be queued, clicks that
+	// arrive while it is full are dropped.
+	ClickBuffer int
+	UserAgent   string
+	// BaseURL builds the short urls of links in payloads.
+	BaseURL string
+}
+
+// Dispatcher queues events for the webhooks subscribed to them and delivers
+// them in the background. Deliveries are stored before they are sent, so
+// retries survive restarts. They are not ordered.
+type Dispatcher struct {
+	log    *slog.Logger
+	store  Store
+	client *http.Client
+	opts   Options
+	wake   chan struct{}
+	clicks chan clickEvent
+}
+
+// clickEvent is a click waiting to be queued for the webhooks of owner.
+type clickEvent struct {
+	owner webhook.Owner
+	click clickstream.Click
+}
+
+func New(log *slog.Logger, store Store, opts Options) *Dispatcher {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.ClickBuffer <= 0 {
+		opts.ClickBuffer = DefaultClickBuffer
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+
+	client := &http.Client{
+		Timeout: opts.Timeout,
+		// a redirect is an answer of the receiver, not a delivery
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	if opts.Dialer != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = opts.Dialer.DialContext
+		// a proxy would connect to the receiver instead of the dialer
+		transport.Proxy = nil
+		client.Transport = transport
+	}
+
+	return &Dispatcher{
+		log:    log,
+		store:  store,
+		client: client,
+		opts:   opts,
+		wake:   make(chan struct{}, 1),
+		clicks: make(chan clickEvent, opts.ClickBuffer),
+	}
+}
+
+// payload is the body of every delivery.
+type payload struct {
+	Event   webhook.Event `json:"event"`
+	Created time.Time     `json:"created_at"`
+	Data    any           `json:"data"`
+}
+
+// Notify queues event with data for the webhooks of owner subscribed to it.
+// Failures are logged, events are not worth failing the request that caused
+// them.
+func (d *Dispatcher) Notify(event webhook.Event, owner webhook.Owner, data any) {
+	const op = "lib.webhooks.Notify"
+
+	log := d.log.With(slog.String("op", op), slog.String("event", string(event)))
+
+	body, err := json.Marshal(payload{Event: event, Created: time.Now().UTC(), Data: data})
+	if err != nil {
+		log.Error("failed to encode webhook payload", sl.Err(err))
+		return
+	}
+
+	queued, err := d.store.SaveWebhookDeliveries(owner, event, string(body))
+	if err != nil {
+		log.Error("failed to queue webhook deliveries", sl.Err(err))
+		return
+	}
+
+	if queued > 0 {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// linkData is how links are sent. Callers only know the id of the owner,
+// so it replaces the user object.
+type linkData struct {
+	urlInfo.UrlInfo
+	// User hides the user of UrlInfo, it is always nil
+	User   *user.User `json:"user,omitempty"`
+	UserId int64      `json:"user_id"`
+}
+
+// NotifyLink queues event for the webhooks of link's owner with the link as
+// data.
+func (d *Dispatcher) NotifyLink(event webhook.Event, link urlInfo.UrlInfo) {
+	if link.ShortUrl == "" && d.opts.BaseURL != "" {
+		link.ShortUrl = shorturl.Build(d.opts.BaseURL, link.Domain, link.Alias)
+	}
+
+	d.Notify(event, owner(link), linkData{UrlInfo: link, UserId: link.User.ID})
+}
+
+// NotifyClick hands a link.clicked event for the webhooks of link's owner to
+// Run, which queues it. It never waits, so redirects don't pay for the
+// webhook lookup; clicks are dropped while Run is behind by ClickBuffer.
+func (d *Dispatcher) NotifyClick(link urlInfo.UrlInfo, click clickstream.Click) {
+	select {
+	case d.clicks <- clickEvent{owner: owner(link), click: click}:
+	default:
+		d.log.Warn("webhook click buffer is full, dropping click",
+			slog.String("op", "lib.webhooks.NotifyClick"), slog.Int64("url_id", click.UrlId))
+	}
+}
+
+// queueClicks queues the clicks handed over by NotifyClick until ctx is done,
+// then the ones still waiting.
+func (d *Dispatcher) queueClicks(ctx context.Context) {
+	for {
+		select {
+		case c := <-d.clicks:
+			d.Notify(webhook.EventLinkClicked, c.owner, c.click)
+		case <-ctx.Done():
+			for {
+				select {
+				case c := <-d.clicks:
+					d.Notify(webhook.EventLinkClicked, c.owner, c.click)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Noop is used where no events are wanted, it drops them.
+type Noop struct{}
+
+func (Noop) NotifyLink(webhook.Event, urlInfo.UrlInfo) {}
+
+func (Noop) NotifyClick(urlInfo.UrlInfo, clickstream.Click) {}
+
+func owner(link urlInfo.UrlInfo) webhook.Owner {
+	return webhook.Owner{UserId: link.User.ID, WorkspaceId: link.WorkspaceId}
+}
+
+// Run queues clicks, delivers queued events as they come and retries failed
+// ones when they are due, until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	const op = "lib.webhooks.Run"
+
+	log := d.log.With(slog.String("op", op))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.queueClicks(ctx)
+	}()
+	defer wg.Wait()
+
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			sent, err := d.DeliverDue(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Error("failed to deliver webhooks", sl.Err(err))
+			}
+			// a full batch means more may be due
+			if err != nil || sent < d.opts.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// DeliverDue tries the deliveries that are due once and returns how many it
+// tried, up to Concurrency at a time.
+func (d *Dispatcher) DeliverDue(ctx context.Context) (int, error) {
+	const op = "lib.webhooks.DeliverDue"
+
+	due, err := d.store.GetDueWebhookDeliveries(time.Now(), d.opts.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var (
+		mu       sync.Mutex
+		storeErr error
+		wg       sync.WaitGroup
+	)
+	sem := make(chan struct{}, d.opts.Concurrency)
+
+	for _, p := range due {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(p webhook.Pending) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			delivery := d.attempt(ctx, p)
+			if ctx.Err() != nil {
+				// a cancelled attempt says nothing about the receiver
+				return
+			}
+
+			var next time.Time
+			if delivery.Status == webhook.StatusPending {
+				next = time.Now().Add(d.Backoff(delivery.Attempts))
+			}
+			if err := d.store.UpdateWebhookDelivery(delivery, next); err != nil {
+				mu.Lock()
+				if storeErr == nil {
+					storeErr = err
+				}
+				mu.Unlock()
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	if storeErr != nil {
+		return len(due), fmt.Errorf("%s: %w", op, storeErr)
+	}
+
+	return len(due), ctx.Err()
+}
+
+// Ping sends a ping event to hook right away and stores the delivery without
+// retrying it.
+func (d *Dispatcher) Ping(ctx context.Context, hook webhook.Webhook) (webhook.Delivery, error) {
+	const op = "lib.webhooks.Ping"
+
+	body, err := json.Marshal(payload{
+		Event:   webhook.EventPing,
+		Created: time.Now().UTC(),
+		Data:    map[string]int64{"webhook_id": hook.ID},
+	})
+	if err != nil {
+		return webhook.Delivery{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// it is stored as failed until it went through, so it is never retried
+	p := webhook.Pending{
+		Delivery: webhook.Delivery{WebhookId: hook.ID, Event: webhook.EventPing, Payload: string(body), Status: webhook.StatusFailed},
+		URL:      hook.URL,
+		Secret:   hook.Secret,
+	}
+	p.ID, err = d.store.SaveWebhookDelivery(&p.Delivery)
+	if err != nil {
+		return webhook.Delivery{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	delivery := d.attempt(ctx, p)
+	if delivery.Status == webhook.StatusPending {
+		delivery.Status = webhook.StatusFailed
+	}
+
+	if err := d.store.UpdateWebhookDelivery(delivery, time.Time{}); err != nil {
+		return webhook.Delivery{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return delivery, nil
+}
+
+// attempt sends p once and returns its delivery with the outcome: delivered,
+// pending for another attempt or failed for good.
+func (d *Dispatcher) attempt(ctx context.Context, p webhook.Pending) webhook.Delivery {
+	delivery := p.Delivery
+	delivery.Attempts++
+
+	code, err := d.send(ctx, p)
+	delivery.StatusCode, delivery.Error = code, ""
+
+	switch {
+	case err == nil:
+		delivery.Status = webhook.StatusDelivered
+	case delivery.Attempts >= d.opts.MaxAttempts:
+		delivery.Status = webhook.StatusFailed
+		delivery.Error = err.Error()
+	default:
+		delivery.Status = webhook.StatusPending
+		delivery.Error = err.Error()
+	}
+
+	return delivery
+}
+
+func (d *Dispatcher) send(ctx context.Context, p webhook.Pending) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewBufferString(p.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", d.opts.UserAgent)
+	req.Header.Set(EventHeader, string(p.Event))
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(p.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, "sha256="+Sign(p.Secret, timestamp, []byte(p.Payload)))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// lets the connection be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("%w: %d", ErrStatus, resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Backoff is the wait after the given number of failed attempts.
+func (d *Dispatcher) Backoff(attempts int) time.Duration {
+	wait := d.opts.Backoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= d.opts.MaxBackoff {
+			return d.opts.MaxBackoff
+		}
+	}
+
+	return min(wait, d.opts.MaxBackoff)
+}
+
+// Sign returns the hex HMAC-SHA256 of the timestamp, a dot and body keyed
+// with secret, receivers compute it the same way to check deliveries.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
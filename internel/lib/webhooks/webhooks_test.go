@@ -0,0 +1,266 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/user"
+	"url-shortner/internel/domain/entities/webhook"
+	"url-shortner/internel/lib/clickstream"
+	"url-shortner/internel/lib/logger/handlers/slogdiscard"
+	"url-shortner/internel/lib/urlpolicy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memStore keeps deliveries in memory, every queued delivery goes to url.
+type memStore struct {
+	mu         sync.Mutex
+	url        string
+	deliveries []webhook.Delivery
+	next       []time.Time
+	owners     []webhook.Owner
+}
+
+func (s *memStore) SaveWebhookDeliveries(owner webhook.Owner, event webhook.Event, payload string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.owners = append(s.owners, owner)
+	s.deliveries = append(s.deliveries, webhook.Delivery{
+		ID: int64(len(s.deliveries) + 1), WebhookId: 1, Event: event, Payload: payload, Status: webhook.StatusPending,
+	})
+	s.next = append(s.next, time.Time{})
+
+	return 1, nil
+}
+
+func (s *memStore) SaveWebhookDelivery(d *webhook.Delivery) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d.ID = int64(len(s.deliveries) + 1)
+	s.deliveries = append(s.deliveries, *d)
+	s.next = append(s.next, time.Time{})
+
+	return d.ID, nil
+}
+
+func (s *memStore) GetDueWebhookDeliveries(now time.Time, limit int) ([]webhook.Pending, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []webhook.Pending
+	for i, d := range s.deliveries {
+		if d.Status == webhook.StatusPending && !s.next[i].After(now) && len(due) < limit {
+			due = append(due, webhook.Pending{Delivery: d, URL: s.url, Secret: "secret"})
+		}
+	}
+
+	return due, nil
+}
+
+func (s *memStore) UpdateWebhookDelivery(d webhook.Delivery, nextAttempt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries[d.ID-1] = d
+	s.next[d.ID-1] = nextAttempt
+
+	return nil
+}
+
+func (s *memStore) delivery(id int64) webhook.Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.deliveries[id-1]
+}
+
+// retryNow makes all pending deliveries due.
+func (s *memStore) retryNow() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.next {
+		s.next[i] = time.Time{}
+	}
+}
+
+func TestDispatcher_DeliverDue(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		failures = 2
+		received []*http.Request
+		bodies   [][]byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	store := &memStore{url: srv.URL}
+	d := New(slogdiscard.NewDiscardLogger(), store, Options{MaxAttempts: 5, Backoff: time.Minute, BaseURL: "http://sho.rt"})
+
+	d.NotifyLink(webhook.EventLinkCreated, urlInfo.UrlInfo{
+		Id: 7, Alias: "abc", Url: "https://example.com", WorkspaceId: 3,
+		User: user.User{ID: 2, Username: "bob", Password: "hash"},
+	})
+	assert.Equal(t, []webhook.Owner{{UserId: 2, WorkspaceId: 3}}, store.owners)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		store.retryNow()
+		sent, err := d.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+	}
+
+	delivery := store.delivery(1)
+	assert.Equal(t, webhook.StatusDelivered, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.StatusCode)
+	assert.Empty(t, delivery.Error)
+
+	// nothing is left to send
+	sent, err := d.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	require.Len(t, received, 3)
+	r := received[2]
+	assert.Equal(t, "link.created", r.Header.Get(EventHeader))
+	assert.Equal(t, "1", r.Header.Get(DeliveryHeader))
+
+	timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256="+Sign("secret", timestamp, bodies[2]), r.Header.Get(SignatureHeader))
+
+	var p struct {
+		Event string                     `json:"event"`
+		Data  map[string]json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(bodies[2], &p))
+	assert.Equal(t, "link.created", p.Event)
+	assert.JSONEq(t, `"http://sho.rt/abc"`, string(p.Data["short_url"]))
+	assert.JSONEq(t, `2`, string(p.Data["user_id"]))
+	assert.NotContains(t, p.Data, "user")
+}
+
+func TestDispatcher_GivesUp(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	store := &memStore{url: srv.URL}
+	d := New(slogdiscard.NewDiscardLogger(), store, Options{MaxAttempts: 2})
+
+	d.Notify(webhook.EventLinkDeleted, webhook.Owner{UserId: 1}, map[string]string{"alias": "abc"})
+
+	_, err := d.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, webhook.StatusPending, store.delivery(1).Status)
+
+	// the retry is not due yet
+	sent, err := d.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	store.retryNow()
+	_, err = d.DeliverDue(context.Background())
+	require.NoError(t, err)
+
+	delivery := store.delivery(1)
+	assert.Equal(t, webhook.StatusFailed, delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, delivery.StatusCode)
+	assert.Contains(t, delivery.Error, "500")
+}
+
+func TestDispatcher_Ping(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	store := &memStore{}
+	d := New(slogdiscard.NewDiscardLogger(), store, Options{})
+
+	delivery, err := d.Ping(context.Background(), webhook.Webhook{ID: 4, URL: srv.URL, Secret: "s"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), delivery.ID)
+	assert.Equal(t, webhook.EventPing, delivery.Event)
+	assert.Equal(t, webhook.StatusFailed, delivery.Status)
+	assert.Equal(t, http.StatusNotFound, delivery.StatusCode)
+}
+
+func TestDispatcher_RefusesPrivateReceivers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the receiver must not be reached")
+	}))
+	defer srv.Close()
+
+	// names are refused by the address they resolve to when delivering
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	store := &memStore{url: "http://localhost:" + port}
+	policy, err := urlpolicy.New(urlpolicy.Options{})
+	require.NoError(t, err)
+	d := New(slogdiscard.NewDiscardLogger(), store, Options{MaxAttempts: 1, Dialer: policy})
+
+	d.Notify(webhook.EventLinkDeleted, webhook.Owner{UserId: 1}, map[string]string{"alias": "abc"})
+
+	_, err = d.DeliverDue(context.Background())
+	require.NoError(t, err)
+
+	delivery := store.delivery(1)
+	assert.Equal(t, webhook.StatusFailed, delivery.Status)
+	assert.Contains(t, delivery.Error, urlpolicy.ErrPrivate.Error())
+}
+
+func TestDispatcher_NotifyClick(t *testing.T) {
+	store := &memStore{}
+	d := New(slogdiscard.NewDiscardLogger(), store, Options{ClickBuffer: 1})
+
+	link := urlInfo.UrlInfo{Id: 3, User: user.User{ID: 1}, WorkspaceId: 7}
+	d.NotifyClick(link, clickstream.Click{UrlId: 3})
+	// the buffer is full, so this one is dropped
+	d.NotifyClick(link, clickstream.Click{UrlId: 3})
+
+	// nothing is stored while the redirect waits
+	assert.Empty(t, store.owners)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.queueClicks(ctx)
+
+	assert.Equal(t, []webhook.Owner{{UserId: 1, WorkspaceId: 7}}, store.owners)
+	assert.Equal(t, webhook.EventLinkClicked, store.delivery(1).Event)
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := New(slogdiscard.NewDiscardLogger(), &memStore{}, Options{Backoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	assert.Equal(t, time.Second, d.Backoff(1))
+	assert.Equal(t, 2*time.Second, d.Backoff(2))
+	assert.Equal(t, 8*time.Second, d.Backoff(4))
+	assert.Equal(t, 10*time.Second, d.Backoff(5))
+	assert.Equal(t, 10*time.Second, d.Backoff(60))
+}
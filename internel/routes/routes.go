@@ -6,27 +6,78 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/go-chi/jwtauth/v5"
 	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"url-shortner/internel/config"
+	"url-shortner/internel/http-server/handlers/admin/linkExport"
+	"url-shortner/internel/http-server/handlers/admin/linkImport"
 	"url-shortner/internel/http-server/handlers/auth/login"
+	domainAll "url-shortner/internel/http-server/handlers/domain/all"
+	domainCreate "url-shortner/internel/http-server/handlers/domain/create"
+	domainDelete "url-shortner/internel/http-server/handlers/domain/delete"
+	domainUpdate "url-shortner/internel/http-server/handlers/domain/update"
+	domainVerify "url-shortner/internel/http-server/handlers/domain/verify"
 	"url-shortner/internel/http-server/handlers/redirect"
+	aliasAvailable "url-shortner/internel/http-server/handlers/url/alias/available"
+	aliasMetrics "url-shortner/internel/http-server/handlers/url/alias/metrics"
 	"url-shortner/internel/http-server/handlers/url/all"
+	urlAnalytics "url-shortner/internel/http-server/handlers/url/analytics"
+	"url-shortner/internel/http-server/handlers/url/broken"
+	"url-shortner/internel/http-server/handlers/url/bulk"
 	"url-shortner/internel/http-server/handlers/url/delete"
+	urlLive "url-shortner/internel/http-server/handlers/url/live"
+	"url-shortner/internel/http-server/handlers/url/qr"
 	"url-shortner/internel/http-server/handlers/url/redirectInfo"
+	redirectInfoExport "url-shortner/internel/http-server/handlers/url/redirectInfo/export"
 	"url-shortner/internel/http-server/handlers/url/save"
+	urlStats "url-shortner/internel/http-server/handlers/url/stats"
+	"url-shortner/internel/http-server/handlers/url/update"
+	webhookAll "url-shortner/internel/http-server/handlers/webhook/all"
+	webhookCreate "url-shortner/internel/http-server/handlers/webhook/create"
+	webhookDelete "url-shortner/internel/http-server/handlers/webhook/delete"
+	webhookDeliveries "url-shortner/internel/http-server/handlers/webhook/deliveries"
+	webhookPing "url-shortner/internel/http-server/handlers/webhook/ping"
+	workspaceAll "url-shortner/internel/http-server/handlers/workspace/all"
+	workspaceAnalytics "url-shortner/internel/http-server/handlers/workspace/analytics"
+	workspaceCreate "url-shortner/internel/http-server/handlers/workspace/create"
+	invitationAccept "url-shortner/internel/http-server/handlers/workspace/invitation/accept"
+	invitationCreate "url-shortner/internel/http-server/handlers/workspace/invitation/create"
+	workspaceLive "url-shortner/internel/http-server/handlers/workspace/live"
+	memberAll "url-shortner/internel/http-server/handlers/workspace/member/all"
+	memberDelete "url-shortner/internel/http-server/handlers/workspace/member/delete"
+	memberUpdate "url-shortner/internel/http-server/handlers/workspace/member/update"
+	workspaceStats "url-shortner/internel/http-server/handlers/workspace/stats"
+	"url-shortner/internel/http-server/middleware/admin"
+	"url-shortner/internel/http-server/middleware/requestMetrics"
+	"url-shortner/internel/lib/alias"
 	"url-shortner/internel/lib/auth/jwt"
+	"url-shortner/internel/lib/auth/linkpass"
+	"url-shortner/internel/lib/bots"
+	"url-shortner/internel/lib/clickstream"
+	"url-shortner/internel/lib/domainverify"
+	"url-shortner/internel/lib/geo"
+	"url-shortner/internel/lib/metrics"
+	"url-shortner/internel/lib/privacy"
+	"url-shortner/internel/lib/throttle"
+	"url-shortner/internel/lib/urlpolicy"
+	"url-shortner/internel/lib/visitor"
+	"url-shortner/internel/lib/webhooks"
 	"url-shortner/internel/storage/sqlite"
 )
 
-func New(log *slog.Logger, storage *sqlite.Storage) *chi.Mux {
+func New(log *slog.Logger, cfg *config.Config, storage *sqlite.Storage, aliases *alias.Allocator, policy *alias.Policy, cookies *linkpass.Cookies, geoResolver geo.Resolver, urlPolicy *urlpolicy.Policy, bots *bots.Detector, visitors *visitor.Hasher, ipAnonymizer *privacy.Anonymizer, clicks *clickstream.Hub, hooks *webhooks.Dispatcher, reg *metrics.Registry) *chi.Mux {
 	router := chi.NewRouter()
 
 	router.Use(middleware.RequestID)
+	router.Use(requestMetrics.New(reg))
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.URLFormat)
 
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"}, // replace with your allowed origins
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
@@ -41,16 +92,111 @@ func New(log *slog.Logger, storage *sqlite.Storage) *chi.Mux {
 		r.Use(jwtauth.Verifier(jwt.TokenAuth))
 		r.Use(jwtauth.Authenticator(jwt.TokenAuth))
 
-		r.Post("/", save.New(log, storage))
-		r.Delete("/{alias}", delete.New(log, storage))
-		r.Get("/", all.New(log, storage))
+		r.Post("/", save.New(log, storage, aliases, policy, urlPolicy, hooks, cfg.BaseURL))
+		r.Post("/bulk", bulk.New(log, storage, aliases, policy, urlPolicy, hooks, cfg.BaseURL, cfg.Bulk.MaxItems))
+		r.Put("/{alias}", update.New(log, storage, policy, urlPolicy, hooks))
+		r.Delete("/{alias}", delete.New(log, storage, policy, hooks))
+		r.Get("/{alias}/qr", qr.New(log, storage, policy, cfg.BaseURL))
+		r.Get("/{alias}/stats", urlStats.New(log, storage, policy))
+		r.Get("/{alias}/analytics", urlAnalytics.New(log, storage, policy))
+		r.Get("/broken", broken.New(log, storage, cfg.BaseURL, cfg.Health.FailureThreshold))
+		r.Get("/", all.New(log, storage, cfg.BaseURL))
 		r.Get("/redirect-info", redirectInfo.New(log, storage))
+		r.Get("/redirect-info/export", redirectInfoExport.New(log, storage, policy, cfg.Clicks.ExportWriteTimeout))
+		r.Get("/alias/metrics", aliasMetrics.New(aliases))
+		r.Get("/alias/{alias}/available", aliasAvailable.New(log, storage, policy))
 	})
 
-	router.Get("/{alias}", redirect.New(log, storage))
+	router.Route("/workspaces", func(r chi.Router) {
+		r.Use(jwtauth.Verifier(jwt.TokenAuth))
+		r.Use(jwtauth.Authenticator(jwt.TokenAuth))
+
+		r.Post("/", workspaceCreate.New(log, storage))
+		r.Get("/", workspaceAll.New(log, storage))
+		r.Get("/{id}/stats", workspaceStats.New(log, storage))
+		r.Get("/{id}/analytics", workspaceAnalytics.New(log, storage))
+		r.Get("/{id}/members", memberAll.New(log, storage))
+		r.Put("/{id}/members/{userId}", memberUpdate.New(log, storage))
+		r.Delete("/{id}/members/{userId}", memberDelete.New(log, storage))
+		r.Post("/{id}/invitations", invitationCreate.New(log, storage))
+		r.Post("/invitations/{token}/accept", invitationAccept.New(log, storage))
+	})
+
+	// browsers can't set headers on EventSource and WebSocket requests, so
+	// live streams also take the token from ?jwt=
+	router.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verify(jwt.TokenAuth, jwtauth.TokenFromHeader, jwtauth.TokenFromQuery))
+		r.Use(jwtauth.Authenticator(jwt.TokenAuth))
+
+		r.Get("/url/{alias}/live", urlLive.New(log, storage, policy, clicks))
+		r.Get("/workspaces/{id}/live", workspaceLive.New(log, storage, clicks))
+	})
+
+	router.Route("/domains", func(r chi.Router) {
+		r.Use(jwtauth.Verifier(jwt.TokenAuth))
+		r.Use(jwtauth.Authenticator(jwt.TokenAuth))
+
+		r.Post("/", domainCreate.New(log, storage, urlPolicy, cfg.BaseURL))
+		r.Get("/", domainAll.New(log, storage))
+		r.Put("/{id}", domainUpdate.New(log, storage, urlPolicy))
+		r.Delete("/{id}", domainDelete.New(log, storage))
+		r.Post("/{id}/verify", domainVerify.New(log, storage, domainverify.New(net.DefaultResolver), cfg.BaseURL))
+	})
+
+	router.Route("/webhooks", func(r chi.Router) {
+		r.Use(jwtauth.Verifier(jwt.TokenAuth))
+		r.Use(jwtauth.Authenticator(jwt.TokenAuth))
+
+		r.Post("/", webhookCreate.New(log, storage, urlPolicy))
+		r.Get("/", webhookAll.New(log, storage))
+		r.Delete("/{id}", webhookDelete.New(log, storage))
+		r.Get("/{id}/deliveries", webhookDeliveries.New(log, storage))
+		r.Post("/{id}/ping", webhookPing.New(log, storage, hooks))
+	})
+
+	router.Route("/admin", func(r chi.Router) {
+		r.Use(jwtauth.Verifier(jwt.TokenAuth))
+		r.Use(jwtauth.Authenticator(jwt.TokenAuth))
+		r.Use(admin.New(log, storage))
+
+		r.Post("/links/import", linkImport.New(log, storage, urlPolicy))
+		r.Get("/links/export", linkExport.New(log, storage, cfg.BaseURL))
+	})
+
+	attempts := throttle.New(cfg.LinkPassword.MaxAttempts, cfg.LinkPassword.Window)
+
+	redirectDurations := reg.Histogram("shortener_redirect_duration_seconds",
+		"How long resolving and answering a redirect took by status.", nil, "status")
+	redirectHandler := requestMetrics.Timed(redirectDurations, redirect.New(log, storage, policy, cookies, geoResolver, bots, visitors, ipAnonymizer, clicks, hooks, redirect.Options{
+		Countdown:       cfg.Preview.Countdown,
+		Status:          cfg.Redirect.Type,
+		CacheMaxAge:     cfg.Redirect.CacheMaxAge,
+		HonorDoNotTrack: cfg.Privacy.HonorDoNotTrack,
+	}))
+	router.Get("/{alias}", redirectHandler)
+	router.Head("/{alias}", redirectHandler)
+	router.Post("/{alias}", redirect.NewUnlock(log, storage, policy, cookies, attempts))
+	previewHandler := redirect.NewPreview(log, storage, policy, cookies, geoResolver)
+	router.Get("/{alias}/preview", previewHandler)
+	router.Head("/{alias}/preview", previewHandler)
+	router.Post("/{alias}/preview", redirect.NewUnlock(log, storage, policy, cookies, attempts))
+
+	reserveRoutes(router, policy)
 
 	return router
 }
 
+// reserveRoutes keeps aliases from shadowing the static first segment of
+// any registered route, e.g. /url or /domains.
+func reserveRoutes(router chi.Routes, policy *alias.Policy) {
+	_ = chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+		if segment != "" && !strings.ContainsAny(segment, "{*") {
+			policy.Reserve(segment)
+		}
+		return nil
+	})
+}
+
 func bindRepositories() {
 }
@@ -0,0 +1,225 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"url-shortner/internel/domain/entities/analytics"
+)
+
+// clickTimeLayout is how SQLite's CURRENT_TIMESTAMP stores created_at, in UTC.
+const clickTimeLayout = "2006-01-02 15:04:05"
+
+// breakdowns are the columns clicks are broken down by, with the expression
+// naming their values and the list of the report they go to.
+var breakdowns = []struct {
+	column string
+	name   string
+	list   func(report *analytics.Analytics) *[]analytics.Breakdown
+}{
+	{"country_code", "MAX(country)", func(a *analytics.Analytics) *[]analytics.Breakdown { return &a.Countries }},
+	{"browser", "''", func(a *analytics.Analytics) *[]analytics.Breakdown { return &a.Browsers }},
+	{"os", "''", func(a *analytics.Analytics) *[]analytics.Breakdown { return &a.OS }},
+	{"platform", "''", func(a *analytics.Analytics) *[]analytics.Breakdown { return &a.Platforms }},
+	{"referrer_host", "''", func(a *analytics.Analytics) *[]analytics.Breakdown { return &a.Referrers }},
+	{"referrer_path", "''", func(a *analytics.Analytics) *[]analytics.Breakdown { return &a.ReferrerPaths }},
+	{"language", "''", func(a *analytics.Analytics) *[]analytics.Breakdown { return &a.Languages }},
+	{"utm_source", "''", func(a *analytics.Analytics) *[]analytics.Breakdown { return &a.UTMSources }},
+	{"utm_medium", "''", func(a *analytics.Analytics) *[]analytics.Breakdown { return &a.UTMMediums }},
+	{"utm_campaign", "''", func(a *analytics.Analytics) *[]analytics.Breakdown { return &a.UTMCampaigns }},
+	{"utm_term", "''", func(a *analytics.Analytics) *[]analytics.Breakdown { return &a.UTMTerms }},
+	{"utm_content", "''", func(a *analytics.Analytics) *[]analytics.Breakdown { return &a.UTMContents }},
+}
+
+// filterColumns are the columns of the analytics.FilterDimensions.
+var filterColumns = map[string]string{
+	"country":       "country_code",
+	"browser":       "browser",
+	"os":            "os",
+	"platform":      "platform",
+	"referrer":      "referrer_host",
+	"referrer_path": "referrer_path",
+	"language":      "language",
+	"qr":            "via_qr",
+	"utm_source":    "utm_source",
+	"utm_medium":    "utm_medium",
+	"utm_campaign":  "utm_campaign",
+	"utm_term":      "utm_term",
+	"utm_content":   "utm_content",
+}
+
+// GetURLAnalytics returns the clicks of the link with urlId selected by query.
+func (s *Storage) GetURLAnalytics(urlId int64, query analytics.Query) (analytics.Analytics, error) {
+	const op = "storage.sqlite.GetURLAnalytics"
+	defer s.observe(op, time.Now())
+
+	report, err := s.clickAnalytics("url_id = ?", urlId, query)
+	if err != nil {
+		return analytics.Analytics{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return report, nil
+}
+
+// GetWorkspaceAnalytics returns the clicks of all links of a workspace
+// selected by query.
+func (s *Storage) GetWorkspaceAnalytics(workspaceId int64, query analytics.Query) (analytics.Analytics, error) {
+	const op = "storage.sqlite.GetWorkspaceAnalytics"
+	defer s.observe(op, time.Now())
+
+	report, err := s.clickAnalytics("url_id IN (SELECT id FROM url WHERE workspace_id = ?)", workspaceId, query)
+	if err != nil {
+		return analytics.Analytics{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return report, nil
+}
+
+// clickAnalytics counts the clicks matching scope, a condition on
+// url_redirection_info with a single parameter id, and the query.
+func (s *Storage) clickAnalytics(scope string, id int64, query analytics.Query) (analytics.Analytics, error) {
+	report := analytics.Analytics{
+		Interval: query.Interval,
+		Timezone: query.Location.String(),
+		From:     query.From(),
+		To:       query.To(),
+		Series:   make([]analytics.Bucket, 0, len(query.Bounds)-1),
+	}
+
+	where, args, err := clickConditions(scope, id, query)
+	if err != nil {
+		return analytics.Analytics{}, err
+	}
+
+	buckets := make([][2]string, 0, len(query.Bounds)-1)
+	for i := 1; i < len(query.Bounds); i++ {
+		buckets = append(buckets, [2]string{clickTime(query.Bounds[i-1]), clickTime(query.Bounds[i])})
+	}
+	bucketsJSON, err := json.Marshal(buckets)
+	if err != nil {
+		return analytics.Analytics{}, err
+	}
+
+	// buckets are passed in instead of formatting created_at, which only
+	// works for whole hour offsets without daylight saving changes
+	rows, err := s.Db.Query(`
+		WITH
+			buckets(i, start, end) AS (
+				SELECT key, json_extract(value, '$[0]'), json_extract(value, '$[1]') FROM json_each(?)
+			),
+			clicks AS (
+				SELECT created_at, visitor_hash FROM url_redirection_info WHERE `+where+`
+			)
+		SELECT
+			COUNT(c.created_at),
+			COUNT(DISTINCT NULLIF(c.visitor_hash, ''))
+		FROM
+			buckets b
+		LEFT JOIN
+			clicks c
+		ON
+			c.created_at >= b.start AND c.created_at < b.end
+		GROUP BY
+			b.i
+		ORDER BY
+			b.i`, append([]any{string(bucketsJSON)}, args...)...)
+	if err != nil {
+		return analytics.Analytics{}, err
+	}
+	defer rows.Close()
+
+	for i := 0; rows.Next(); i++ {
+		bucket := analytics.Bucket{Start: query.Bounds[i]}
+		if err := rows.Scan(&bucket.Clicks, &bucket.UniqueClicks); err != nil {
+			return analytics.Analytics{}, err
+		}
+		report.Series = append(report.Series, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return analytics.Analytics{}, err
+	}
+
+	err = s.Db.QueryRow(`
+		SELECT
+			COUNT(*),
+			COUNT(DISTINCT NULLIF(visitor_hash, '')),
+			COALESCE(SUM(via_qr), 0)
+		FROM
+			url_redirection_info
+		WHERE
+			`+where, args...).Scan(&report.Clicks, &report.UniqueClicks, &report.QRClicks)
+	if err != nil {
+		return analytics.Analytics{}, err
+	}
+
+	for _, b := range breakdowns {
+		list, err := s.clickBreakdown(b.column, b.name, where, args, query.Top)
+		if err != nil {
+			return analytics.Analytics{}, err
+		}
+		*b.list(&report) = list
+	}
+
+	return report, nil
+}
+
+// clickConditions returns the condition selecting the clicks of a report
+// and its arguments.
+func clickConditions(scope string, id int64, query analytics.Query) (string, []any, error) {
+	conditions := []string{scope, "created_at >= ?", "created_at < ?", "(? OR is_bot = 0)"}
+	args := []any{id, clickTime(query.From()), clickTime(query.To()), query.IncludeBots}
+
+	for _, dimension := range analytics.FilterDimensions {
+		value, ok := query.Filters[dimension]
+		if !ok {
+			continue
+		}
+		column, ok := filterColumns[dimension]
+		if !ok {
+			return "", nil, fmt.Errorf("no column for filter %q", dimension)
+		}
+		conditions = append(conditions, column+" = ?")
+		args = append(args, value)
+	}
+
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// clickBreakdown returns the top values of column with the most clicks.
+func (s *Storage) clickBreakdown(column, name, where string, args []any, top int) ([]analytics.Breakdown, error) {
+	rows, err := s.Db.Query(`
+		SELECT
+			`+column+`,
+			`+name+`,
+			COUNT(*) AS clicks,
+			COUNT(DISTINCT NULLIF(visitor_hash, ''))
+		FROM
+			url_redirection_info
+		WHERE
+			`+where+`
+		GROUP BY
+			`+column+`
+		ORDER BY
+			clicks DESC, 1
+		LIMIT ?`, append(args[:len(args):len(args)], top)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]analytics.Breakdown, 0)
+	for rows.Next() {
+		var b analytics.Breakdown
+		if err := rows.Scan(&b.Value, &b.Name, &b.Clicks, &b.UniqueClicks); err != nil {
+			return nil, err
+		}
+		list = append(list, b)
+	}
+
+	return list, rows.Err()
+}
+
+func clickTime(t time.Time) string {
+	return t.UTC().Format(clickTimeLayout)
+}
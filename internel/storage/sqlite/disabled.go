@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+	"url-shortner/internel/domain/entities/rule"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/variant"
+	"url-shortner/internel/storage"
+)
+
+// GetURLDestinations returns every link with the urls it can redirect to,
+// only the ids, aliases, urls and disabled reasons are set.
+func (s *Storage) GetURLDestinations() ([]urlInfo.UrlInfo, error) {
+	const op = "storage.sqlite.GetURLDestinations"
+	defer s.observe(op, time.Now())
+
+	rows, err := s.Db.Query("SELECT id, alias, url, disabled_reason FROM url ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var links []urlInfo.UrlInfo
+	index := make(map[int64]int)
+	for rows.Next() {
+		var link urlInfo.UrlInfo
+		if err := rows.Scan(&link.Id, &link.Alias, &link.Url, &link.DisabledReason); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		index[link.Id] = len(links)
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.eachDestination("url_rules", func(urlId int64, url string) {
+		if i, ok := index[urlId]; ok {
+			links[i].Rules = append(links[i].Rules, rule.Rule{URL: url})
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.eachDestination("url_variants", func(urlId int64, url string) {
+		if i, ok := index[urlId]; ok {
+			links[i].Variants = append(links[i].Variants, variant.Variant{URL: url})
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
+// eachDestination calls fn with the url_id and url of every row of table.
+func (s *Storage) eachDestination(table string, fn func(urlId int64, url string)) error {
+	rows, err := s.Db.Query("SELECT url_id, url FROM " + table + " ORDER BY url_id, position")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var urlId int64
+		var url string
+		if err := rows.Scan(&urlId, &url); err != nil {
+			return err
+		}
+		fn(urlId, url)
+	}
+
+	return rows.Err()
+}
+
+// SetURLDisabled stops the link with id from redirecting, an empty reason
+// enables it again.
+func (s *Storage) SetURLDisabled(id int64, reason string) error {
+	const op = "storage.sqlite.SetURLDisabled"
+	defer s.observe(op, time.Now())
+
+	res, err := s.Db.Exec(`
+		UPDATE url
+		SET disabled_reason = ?,
+			disabled_at = CASE WHEN ? = '' THEN NULL ELSE CURRENT_TIMESTAMP END
+		WHERE id = ?`, reason, reason, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrURLNotFound
+	}
+
+	return nil
+}
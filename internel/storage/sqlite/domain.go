@@ -0,0 +1,212 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"time"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/storage"
+)
+
+// SaveDomain adds an unverified domain, storage.ErrDomainExists is returned
+// when the host is verified by someone already or claimed by the same owner.
+func (s *Storage) SaveDomain(domain *domain.Domain) (int64, error) {
+	const op = "storage.sqlite.SaveDomain"
+	defer s.observe(op, time.Now())
+
+	res, err := s.Db.Exec(`
+		INSERT INTO domains(host, user_id, workspace_id, fallback_url, verify_token)
+		SELECT ?, ?, ?, ?, ?
+		WHERE NOT EXISTS (SELECT 1 FROM domains WHERE host = ? AND verified_at IS NOT NULL)`,
+		domain.Host, domain.UserId, nullInt64(domain.WorkspaceId), domain.FallbackUrl, domain.VerifyToken, domain.Host)
+	if err != nil {
+		var liteErr *sqlite.Error
+		if errors.As(err, &liteErr) && liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return 0, storage.ErrDomainExists
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if inserted == 0 {
+		return 0, storage.ErrDomainExists
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s, failed to get last insert id: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// VerifyDomain marks the domain as verified, storage.ErrDomainExists is
+// returned when another claim of its host was verified first.
+func (s *Storage) VerifyDomain(id int64) error {
+	const op = "storage.sqlite.VerifyDomain"
+	defer s.observe(op, time.Now())
+
+	res, err := s.Db.Exec("UPDATE domains SET verified_at = COALESCE(verified_at, CURRENT_TIMESTAMP) WHERE id = ?", id)
+	if err != nil {
+		var liteErr *sqlite.Error
+		if errors.As(err, &liteErr) && liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return storage.ErrDomainExists
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if updated == 0 {
+		return storage.ErrDomainNotFound
+	}
+
+	return nil
+}
+
+const domainColumns = `
+		SELECT
+			id,
+			host,
+			user_id,
+			COALESCE(workspace_id, 0),
+			fallback_url,
+			created_at,
+			verify_token,
+			verified_at IS NOT NULL
+		FROM
+			domains`
+
+func scanDomain(row interface{ Scan(...any) error }) (domain.Domain, error) {
+	var d domain.Domain
+	err := row.Scan(&d.ID, &d.Host, &d.UserId, &d.WorkspaceId, &d.FallbackUrl, &d.Created, &d.VerifyToken, &d.Verified)
+
+	return d, err
+}
+
+func (s *Storage) GetDomain(id int64) (domain.Domain, error) {
+	const op = "storage.sqlite.GetDomain"
+	defer s.observe(op, time.Now())
+
+	d, err := scanDomain(s.Db.QueryRow(domainColumns+" WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Domain{}, storage.ErrDomainNotFound
+	}
+	if err != nil {
+		return domain.Domain{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return d, nil
+}
+
+// GetDomainByHost returns the verified domain of host, unverified claims
+// don't serve links.
+func (s *Storage) GetDomainByHost(host string) (domain.Domain, error) {
+	defer s.observe("storage.sqlite.GetDomainByHost", time.Now())
+
+	return getDomainByHost(s.Db, host)
+}
+
+func getDomainByHost(q querier, host string) (domain.Domain, error) {
+	const op = "storage.sqlite.GetDomainByHost"
+
+	d, err := scanDomain(q.QueryRow(domainColumns+" WHERE host = ? AND verified_at IS NOT NULL", host))
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Domain{}, storage.ErrDomainNotFound
+	}
+	if err != nil {
+		return domain.Domain{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return d, nil
+}
+
+// GetUserDomains returns personal domains of the user and domains of every
+// workspace the user is a member of.
+func (s *Storage) GetUserDomains(userId int64) ([]domain.Domain, error) {
+	const op = "storage.sqlite.GetUserDomains"
+	defer s.observe(op, time.Now())
+
+	rows, err := s.Db.Query(domainColumns+`
+		WHERE
+			(workspace_id IS NULL AND user_id = ?)
+			OR workspace_id IN (SELECT workspace_id FROM workspace_members WHERE user_id = ?)
+		ORDER BY
+			host`, userId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	domains := make([]domain.Domain, 0)
+
+	for rows.Next() {
+		d, err := scanDomain(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		domains = append(domains, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return domains, nil
+}
+
+func (s *Storage) UpdateDomainFallback(id int64, fallbackUrl string) error {
+	const op = "storage.sqlite.UpdateDomainFallback"
+	defer s.observe(op, time.Now())
+
+	res, err := s.Db.Exec("UPDATE domains SET fallback_url = ? WHERE id = ?", fallbackUrl, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	} else if affectedRows == 0 {
+		return storage.ErrDomainNotFound
+	}
+
+	return nil
+}
+
+// DeleteDomain removes a domain that no longer has links, otherwise
+// storage.ErrDomainInUse is returned.
+func (s *Storage) DeleteDomain(id int64) error {
+	const op = "storage.sqlite.DeleteDomain"
+	defer s.observe(op, time.Now())
+
+	var links int
+	if err := s.Db.QueryRow("SELECT COUNT(*) FROM url WHERE domain_id = ?", id).Scan(&links); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if links > 0 {
+		return storage.ErrDomainInUse
+	}
+
+	res, err := s.Db.Exec("DELETE FROM domains WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	} else if affectedRows == 0 {
+		return storage.ErrDomainNotFound
+	}
+
+	return nil
+}
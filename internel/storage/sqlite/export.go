@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"url-shortner/internel/domain/entities/analytics"
+	"url-shortner/internel/domain/entities/redirectInfo"
+)
+
+// ExportRedirectInfo calls fn with every click selected by filter in the
+// order they were recorded, rows are read as fn consumes them.
+func (s *Storage) ExportRedirectInfo(ctx context.Context, filter redirectInfo.Filter, fn func(redirectInfo.RedirectInfo) error) error {
+	const op = "storage.sqlite.ExportRedirectInfo"
+	defer s.observe(op, time.Now())
+
+	var conditions []string
+	var args []any
+	if filter.UrlId != 0 {
+		conditions = append(conditions, "ri.url_id = ?")
+		args = append(args, filter.UrlId)
+	}
+	if filter.WorkspaceId != 0 {
+		conditions = append(conditions, "u.workspace_id = ?")
+		args = append(args, filter.WorkspaceId)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "ri.created_at >= ?")
+		args = append(args, clickTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "ri.created_at < ?")
+		args = append(args, clickTime(filter.To))
+	}
+	if filter.ExcludeBots {
+		conditions = append(conditions, "ri.is_bot = 0")
+	}
+	for _, dimension := range analytics.FilterDimensions {
+		value, ok := filter.Dimensions[dimension]
+		if !ok {
+			continue
+		}
+		conditions = append(conditions, "ri."+filterColumns[dimension]+" = ?")
+		args = append(args, value)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := s.Db.QueryContext(ctx, `
+		SELECT
+			ri.id,
+			COALESCE(u.alias, ''),
+			ri.created_at,
+			ri.ip,
+			ri.country_code,
+			ri.country,
+			ri.city,
+			ri.os,
+			ri.platform,
+			ri.browser,
+			COALESCE(ri.rule_id, 0),
+			COALESCE(ri.variant_id, 0),
+			ri.is_bot,
+			ri.referrer_host,
+			ri.referrer_path,
+			ri.language,
+			ri.via_qr,
+			ri.utm_source,
+			ri.utm_medium,
+			ri.utm_campaign,
+			ri.utm_term,
+			ri.utm_content
+		FROM
+			url_redirection_info ri
+		LEFT JOIN
+			url u
+		ON
+			ri.url_id = u.id
+		`+where+`
+		ORDER BY
+			ri.id`, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var click redirectInfo.RedirectInfo
+		err := rows.Scan(&click.Id, &click.Alias, &click.Created, &click.Ip, &click.CountryCode, &click.Country, &click.City,
+			&click.Os, &click.Platform, &click.Browser, &click.RuleId, &click.VariantId, &click.IsBot, &click.ReferrerHost,
+			&click.ReferrerPath, &click.Language, &click.ViaQR, &click.UTM.Source, &click.UTM.Medium, &click.UTM.Campaign,
+			&click.UTM.Term, &click.UTM.Content)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err := fn(click); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/user"
+)
+
+// SaveURLHealth stores the outcome of a check of the link with urlId, failed
+// checks add to the failures of the previous ones and successful ones reset
+// them.
+func (s *Storage) SaveURLHealth(urlId int64, health urlInfo.Health, ok bool) error {
+	const op = "storage.sqlite.SaveURLHealth"
+	defer s.observe(op, time.Now())
+
+	chain := ""
+	if len(health.RedirectChain) > 0 {
+		data, err := json.Marshal(health.RedirectChain)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		chain = string(data)
+	}
+
+	failures := 1
+	if ok {
+		failures = 0
+	}
+
+	_, err := s.Db.Exec(`
+		INSERT INTO url_health(url_id, status, latency_ms, redirect_chain, error, failures, checked_at)
+		VALUES(?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(url_id) DO UPDATE SET
+			status = excluded.status,
+			latency_ms = excluded.latency_ms,
+			redirect_chain = excluded.redirect_chain,
+			error = excluded.error,
+			failures = CASE WHEN excluded.failures = 0 THEN 0 ELSE url_health.failures + 1 END,
+			checked_at = excluded.checked_at`,
+		urlId, health.Status, health.LatencyMs, chain, health.Error, failures)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetBrokenURLs returns the links that failed at least minFailures checks in
+// a row, most failures first. A workspaceId limits them to the workspace, a
+// userId to the user's own links and 0 for both returns all of them.
+func (s *Storage) GetBrokenURLs(workspaceId, userId int64, minFailures int) ([]urlInfo.UrlInfo, error) {
+	const op = "storage.sqlite.GetBrokenURLs"
+	defer s.observe(op, time.Now())
+
+	rows, err := s.Db.Query(`
+		SELECT
+			u.id,
+			u.alias,
+			u.url,
+			us.id,
+			us.username,
+			COALESCE(u.workspace_id, 0),
+			COALESCE(d.host, ''),
+			h.status,
+			h.latency_ms,
+			h.redirect_chain,
+			h.error,
+			h.failures,
+			h.checked_at
+		FROM
+			url_health h
+		INNER JOIN
+			url u
+		ON
+			h.url_id = u.id
+		INNER JOIN
+			users us
+		ON
+			u.user_id = us.id
+		LEFT JOIN
+			domains d
+		ON
+			u.domain_id = d.id
+		WHERE
+			h.failures >= ?
+			AND (? = 0 OR u.workspace_id = ?)
+			AND (? = 0 OR u.user_id = ?)
+		ORDER BY
+			h.failures DESC, u.id`,
+		minFailures, workspaceId, workspaceId, userId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	links := make([]urlInfo.UrlInfo, 0)
+	for rows.Next() {
+		var link urlInfo.UrlInfo
+		var owner user.User
+		var health urlInfo.Health
+		var chain string
+		err := rows.Scan(&link.Id, &link.Alias, &link.Url, &owner.ID, &owner.Username, &link.WorkspaceId, &link.Domain,
+			&health.Status, &health.LatencyMs, &chain, &health.Error, &health.Failures, &health.CheckedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if chain != "" {
+			if err := json.Unmarshal([]byte(chain), &health.RedirectChain); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		link.User = owner
+		link.Health = &health
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DeleteRedirectInfoByIP deletes the clicks stored with any of ips and
+// returns how many there were.
+func (s *Storage) DeleteRedirectInfoByIP(ips ...string) (int64, error) {
+	const op = "storage.sqlite.DeleteRedirectInfoByIP"
+	defer s.observe(op, time.Now())
+
+	if len(ips) == 0 {
+		return 0, nil
+	}
+
+	args := make([]any, len(ips))
+	for i, ip := range ips {
+		args[i] = ip
+	}
+
+	res, err := s.Db.Exec(`
+		DELETE FROM url_redirection_info
+		WHERE ip IN (?`+strings.Repeat(", ?", len(ips)-1)+`)`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
+
+// AnonymizeRedirectInfoIPs replaces the stored IP of every click with what
+// anonymize returns for it and returns how many clicks changed.
+func (s *Storage) AnonymizeRedirectInfoIPs(anonymize func(ip string) string) (int64, error) {
+	const op = "storage.sqlite.AnonymizeRedirectInfoIPs"
+	defer s.observe(op, time.Now())
+
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT DISTINCT ip FROM url_redirection_info WHERE ip != ''")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var ips []string
+	for rows.Next() {
+		var ip string
+		if err := rows.Scan(&ip); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		ips = append(ips, ip)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var changed int64
+	for _, ip := range ips {
+		anonymized := anonymize(ip)
+		if anonymized == ip {
+			continue
+		}
+
+		res, err := tx.Exec("UPDATE url_redirection_info SET ip = ? WHERE ip = ?", anonymized, ip)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		changed += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return changed, nil
+}
@@ -10,20 +10,30 @@ import (
 	sqlite3 "modernc.org/sqlite/lib"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+	"url-shortner/internel/domain/entities/domain"
 	"url-shortner/internel/domain/entities/redirectInfo"
+	"url-shortner/internel/domain/entities/rule"
 	"url-shortner/internel/domain/entities/urlInfo"
 	"url-shortner/internel/domain/entities/user"
+	"url-shortner/internel/domain/entities/variant"
 	"url-shortner/internel/storage"
 )
 
 type Storage struct {
 	Db *sql.DB
+
+	observer func(op string, took time.Duration)
 }
 
 func New(storagePath string) (*Storage, error) {
 	const op = "storage.sqlite.New"
 
-	db, err := sql.Open("sqlite", storagePath)
+	// background writers like the webhook dispatcher share the database with
+	// requests, a busy timeout makes writers wait for each other instead of
+	// failing with SQLITE_BUSY
+	db, err := sql.Open("sqlite", withBusyTimeout(storagePath))
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -31,16 +41,70 @@ func New(storagePath string) (*Storage, error) {
 	return &Storage{Db: db}, nil
 }
 
-func (s *Storage) SaveURL(urlToSave, alias string, userId float64) (int64, error) {
-	const op = "storage.sqlite.SaveURL"
+// busyTimeout is how long a writer waits for the database lock, in
+// milliseconds.
+const busyTimeout = 5000
 
-	stmt, err := s.Db.Prepare("INSERT INTO url(url, alias, user_id) VALUES(?, ?, ?)")
-	defer stmt.Close()
+func withBusyTimeout(storagePath string) string {
+	separator := "?"
+	if strings.Contains(storagePath, "?") {
+		separator = "&"
+	}
+
+	return fmt.Sprintf("%s%s_pragma=busy_timeout(%d)", storagePath, separator, busyTimeout)
+}
+
+// Observe has fn told how long each operation took, named by its op. It has
+// to be called before the storage is used.
+func (s *Storage) Observe(fn func(op string, took time.Duration)) {
+	s.observer = fn
+}
+
+func (s *Storage) observe(op string, start time.Time) {
+	if s.observer != nil {
+		s.observer(op, time.Since(start))
+	}
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func (s *Storage) SaveURL(urlInfo *urlInfo.UrlInfo) (int64, error) {
+	defer s.observe("storage.sqlite.SaveURL", time.Now())
+
+	if len(urlInfo.Rules) == 0 && len(urlInfo.Variants) == 0 {
+		return saveURL(s.Db, urlInfo)
+	}
+
+	// the link, its rules and variants are stored together
+	tx, err := s.Db.Begin()
 	if err != nil {
-		return 0, fmt.Errorf("%s, %w", op, err)
+		return 0, fmt.Errorf("storage.sqlite.SaveURL: %w", err)
 	}
+	defer tx.Rollback()
 
-	res, err := stmt.Exec(urlToSave, alias, userId)
+	id, err := saveURL(tx, urlInfo)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func saveURL(q querier, urlInfo *urlInfo.UrlInfo) (int64, error) {
+	const op = "storage.sqlite.SaveURL"
+
+	res, err := q.Exec(`
+		INSERT INTO url(url, alias, user_id, workspace_id, domain_id, created_at, title, interstitial, password_hash,
+			sticky_variants, query_mode, redirect_type, utm_source, utm_medium, utm_campaign, utm_term, utm_content)
+		VALUES(?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), CURRENT_TIMESTAMP), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		append([]any{urlInfo.Url, urlInfo.Alias, urlInfo.User.ID, nullInt64(urlInfo.WorkspaceId), nullInt64(urlInfo.DomainId),
+			urlInfo.Created, urlInfo.Title, urlInfo.Interstitial, urlInfo.PasswordHash, urlInfo.StickyVariants, urlInfo.QueryMode,
+			urlInfo.RedirectType}, utmColumns(urlInfo.UTM)...)...)
 	if err != nil {
 		var liteErr *sqlite.Error
 		if errors.As(err, &liteErr) {
@@ -55,41 +119,414 @@ func (s *Storage) SaveURL(urlToSave, alias string, userId float64) (int64, error
 		return 0, fmt.Errorf("%s, failed to get last insert id: %w", op, err)
 	}
 
+	if err := saveRules(q, id, urlInfo.Rules); err != nil {
+		return 0, fmt.Errorf("%s, %w", op, err)
+	}
+	if err := saveVariants(q, id, urlInfo.Variants); err != nil {
+		return 0, fmt.Errorf("%s, %w", op, err)
+	}
+
 	return id, nil
 }
 
-func (s *Storage) GetURL(alias string) (string, error) {
+// utmColumns are the values of the utm_source to utm_content columns.
+func utmColumns(utm *urlInfo.UTM) []any {
+	if utm == nil {
+		utm = &urlInfo.UTM{}
+	}
+
+	return []any{utm.Source, utm.Medium, utm.Campaign, utm.Term, utm.Content}
+}
+
+// saveRules stores rules of the link in their order, rules keep their id
+// when they have one.
+func saveRules(q querier, urlId int64, rules []rule.Rule) error {
+	for i := range rules {
+		r := &rules[i]
+		err := q.QueryRow(`
+			INSERT INTO url_rules(id, url_id, position, os, device, browser, countries, continents, url)
+			VALUES(NULLIF(?, 0), ?, ?, ?, ?, ?, ?, ?, ?)
+			RETURNING id`,
+			r.Id, urlId, i, r.OS, r.Device, r.Browser, strings.Join(r.Countries, ","), strings.Join(r.Continents, ","),
+			r.URL).Scan(&r.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveVariants stores the variants of the link in their order, variants keep
+// their id when they have one.
+func saveVariants(q querier, urlId int64, variants []variant.Variant) error {
+	for i := range variants {
+		v := &variants[i]
+		err := q.QueryRow(`
+			INSERT INTO url_variants(id, url_id, position, url, weight)
+			VALUES(NULLIF(?, 0), ?, ?, ?, ?)
+			RETURNING id`,
+			v.Id, urlId, i, v.URL, v.Weight).Scan(&v.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getVariants(q querier, urlId int64) ([]variant.Variant, error) {
+	rows, err := q.Query(`
+		SELECT id, url, weight
+		FROM url_variants
+		WHERE url_id = ?
+		ORDER BY position`, urlId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variants []variant.Variant
+	for rows.Next() {
+		var v variant.Variant
+		if err := rows.Scan(&v.Id, &v.URL, &v.Weight); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+
+	return variants, rows.Err()
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, ",")
+}
+
+func getRules(q querier, urlId int64) ([]rule.Rule, error) {
+	rows, err := q.Query(`
+		SELECT id, os, device, browser, countries, continents, url
+		FROM url_rules
+		WHERE url_id = ?
+		ORDER BY position`, urlId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []rule.Rule
+	for rows.Next() {
+		var r rule.Rule
+		var countries, continents string
+		if err := rows.Scan(&r.Id, &r.OS, &r.Device, &r.Browser, &countries, &continents, &r.URL); err != nil {
+			return nil, err
+		}
+		r.Countries = splitList(countries)
+		r.Continents = splitList(continents)
+		rules = append(rules, r)
+	}
+
+	return rules, rows.Err()
+}
+
+// NextAliasSequence increments and returns the counter used by id based
+// alias generators.
+func (s *Storage) NextAliasSequence() (int64, error) {
+	defer s.observe("storage.sqlite.NextAliasSequence", time.Now())
+
+	return nextAliasSequence(s.Db)
+}
+
+func nextAliasSequence(q querier) (int64, error) {
+	const op = "storage.sqlite.NextAliasSequence"
+
+	var value int64
+	err := q.QueryRow("UPDATE alias_sequence SET value = value + 1 WHERE id = 1 RETURNING value").Scan(&value)
+	if err != nil {
+		return 0, fmt.Errorf("%s, %w", op, err)
+	}
+
+	return value, nil
+}
+
+// urlTx is a storage.URLTx backed by a database transaction. A failed insert
+// only undoes its own statement, so the transaction stays usable after
+// storage.ErrURLExists.
+type urlTx struct {
+	tx *sql.Tx
+}
+
+func (s *Storage) BeginURLTx() (storage.URLTx, error) {
+	const op = "storage.sqlite.BeginURLTx"
+
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &urlTx{tx: tx}, nil
+}
+
+func (t *urlTx) SaveURL(urlInfo *urlInfo.UrlInfo) (int64, error) {
+	return saveURL(t.tx, urlInfo)
+}
+
+func (t *urlTx) GetUser(userName string) (user.User, error) {
+	return getUser(t.tx, userName)
+}
+
+func (t *urlTx) GetDomainByHost(host string) (domain.Domain, error) {
+	return getDomainByHost(t.tx, host)
+}
+
+func (t *urlTx) GetURL(domainId int64, alias string) (urlInfo.UrlInfo, error) {
+	return getURL(t.tx, domainId, alias)
+}
+
+func (t *urlTx) UpdateURL(urlInfo *urlInfo.UrlInfo) error {
+	return updateURL(t.tx, urlInfo)
+}
+
+func (t *urlTx) NextAliasSequence() (int64, error) {
+	return nextAliasSequence(t.tx)
+}
+
+func (t *urlTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *urlTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// GetURL returns the link stored under alias on the domain,
+// domainId 0 stands for the default domain.
+func (s *Storage) GetURL(domainId int64, alias string) (urlInfo.UrlInfo, error) {
+	defer s.observe("storage.sqlite.GetURL", time.Now())
+
+	return getURL(s.Db, domainId, alias)
+}
+
+func getURL(q querier, domainId int64, alias string) (urlInfo.UrlInfo, error) {
 	const op = "storage.sqlite.GetURL"
 
-	stmt, err := s.Db.Prepare("SELECT url FROM url WHERE alias = ?")
+	info := urlInfo.UrlInfo{Alias: alias, DomainId: domainId}
+	var utm urlInfo.UTM
+	err := q.QueryRow(`
+		SELECT 
+			u.id, 
+			u.url, 
+			u.user_id, 
+			COALESCE(us.username, ''),
+			COALESCE(u.workspace_id, 0),
+			u.title,
+			u.interstitial,
+			u.password_hash,
+			u.sticky_variants,
+			u.query_mode,
+			u.redirect_type,
+			u.disabled_reason,
+			u.utm_source,
+			u.utm_medium,
+			u.utm_campaign,
+			u.utm_term,
+			u.utm_content
+		FROM 
+			url u
+		LEFT JOIN 
+			users us 
+		ON 
+			u.user_id = us.id 
+		WHERE 
+			COALESCE(u.domain_id, 0) = ? AND u.alias = ?`,
+		domainId, alias).Scan(&info.Id, &info.Url, &info.User.ID, &info.User.Username, &info.WorkspaceId,
+		&info.Title, &info.Interstitial, &info.PasswordHash, &info.StickyVariants, &info.QueryMode, &info.RedirectType,
+		&info.DisabledReason, &utm.Source, &utm.Medium, &utm.Campaign, &utm.Term, &utm.Content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return urlInfo.UrlInfo{}, storage.ErrURLNotFound
+	}
+	if err != nil {
+		return urlInfo.UrlInfo{}, fmt.Errorf("%s, %w", op, err)
+	}
+
+	if utm != (urlInfo.UTM{}) {
+		info.UTM = &utm
+	}
+
+	info.Rules, err = getRules(q, info.Id)
+	if err != nil {
+		return urlInfo.UrlInfo{}, fmt.Errorf("%s, %w", op, err)
+	}
+	info.Variants, err = getVariants(q, info.Id)
+	if err != nil {
+		return urlInfo.UrlInfo{}, fmt.Errorf("%s, %w", op, err)
+	}
+
+	return info, nil
+}
+
+func (s *Storage) UpdateURL(urlInfo *urlInfo.UrlInfo) error {
+	defer s.observe("storage.sqlite.UpdateURL", time.Now())
+
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return fmt.Errorf("storage.sqlite.UpdateURL: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateURL(tx, urlInfo); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// updateURL replaces the target, owner, creation date and settings of the
+// link with urlInfo.Id.
+func updateURL(q querier, urlInfo *urlInfo.UrlInfo) error {
+	const op = "storage.sqlite.UpdateURL"
+
+	res, err := q.Exec(`
+		UPDATE url
+		SET url = ?, user_id = ?, workspace_id = ?, created_at = COALESCE(NULLIF(?, ''), created_at),
+			title = ?, interstitial = ?, password_hash = ?, sticky_variants = ?, query_mode = ?, redirect_type = ?,
+			utm_source = ?, utm_medium = ?, utm_campaign = ?, utm_term = ?, utm_content = ?,
+			disabled_reason = ?, disabled_at = CASE WHEN ? = '' THEN NULL ELSE COALESCE(disabled_at, CURRENT_TIMESTAMP) END
+		WHERE id = ?`,
+		append(append([]any{urlInfo.Url, urlInfo.User.ID, nullInt64(urlInfo.WorkspaceId), urlInfo.Created,
+			urlInfo.Title, urlInfo.Interstitial, urlInfo.PasswordHash, urlInfo.StickyVariants, urlInfo.QueryMode,
+			urlInfo.RedirectType}, utmColumns(urlInfo.UTM)...),
+			urlInfo.DisabledReason, urlInfo.DisabledReason, urlInfo.Id)...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	} else if affectedRows == 0 {
+		return storage.ErrURLNotFound
+	}
+
+	if _, err := q.Exec("DELETE FROM url_rules WHERE url_id = ?", urlInfo.Id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := saveRules(q, urlInfo.Id, urlInfo.Rules); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := q.Exec("DELETE FROM url_variants WHERE url_id = ?", urlInfo.Id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := saveVariants(q, urlInfo.Id, urlInfo.Variants); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) AliasExists(domainId int64, alias string) (bool, error) {
+	const op = "storage.sqlite.AliasExists"
+	defer s.observe(op, time.Now())
+
+	var exists bool
+	err := s.Db.QueryRow("SELECT EXISTS(SELECT 1 FROM url WHERE COALESCE(domain_id, 0) = ? AND alias = ?)",
+		domainId, alias).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return exists, nil
+}
+
+// GetUserUrls returns personal links of the user and links of every
+// workspace the user is a member of.
+func (s *Storage) GetUserUrls(userId, start, length int64) ([]urlInfo.UrlInfo, error) {
+	const op = "storage.sqlite.GetUserUrls"
+	defer s.observe(op, time.Now())
+	query := `
+		SELECT 
+			u.alias, 
+			u.url, 
+			us.id, 
+			us.username,
+			COALESCE(u.workspace_id, 0),
+			COALESCE(d.host, ''),
+			u.disabled_reason
+		FROM 
+			url u
+		INNER JOIN 
+			users us 
+		ON 
+			u.user_id = us.id 
+		LEFT JOIN 
+			domains d 
+		ON 
+			u.domain_id = d.id 
+		WHERE
+			(u.workspace_id IS NULL AND u.user_id = ?)
+			OR u.workspace_id IN (SELECT workspace_id FROM workspace_members WHERE user_id = ?)
+		ORDER BY
+			u.id
+		LIMIT ? OFFSET ?`
+	stmt, err := s.Db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 	defer stmt.Close()
+
+	rows, err := stmt.Query(userId, userId, length, start-1)
 	if err != nil {
-		return "", fmt.Errorf("%s, %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	defer rows.Close()
 
-	var url string
-	err = stmt.QueryRow(alias).Scan(&url)
-	if errors.Is(err, sql.ErrNoRows) {
-		return "", storage.ErrURLNotFound
+	var urls []urlInfo.UrlInfo
+
+	for rows.Next() {
+		var urlInfo urlInfo.UrlInfo
+		var user user.User
+		err := rows.Scan(&urlInfo.Alias, &urlInfo.Url, &user.ID, &user.Username, &urlInfo.WorkspaceId, &urlInfo.Domain, &urlInfo.DisabledReason)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		urlInfo.User = user
+		urls = append(urls, urlInfo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return url, nil
+	return urls, nil
 }
 
-func (s *Storage) GetAllUrl(start, length int64) ([]urlInfo.UrlInfo, error) {
-	const op = "storage.sqlite.GetAllUrl"
+func (s *Storage) GetAllWorkspaceUrl(workspaceId, start, length int64) ([]urlInfo.UrlInfo, error) {
+	const op = "storage.sqlite.GetAllWorkspaceUrl"
+	defer s.observe(op, time.Now())
 	query := `
 		SELECT 
 			u.alias, 
 			u.url, 
 			us.id, 
-			us.username
+			us.username,
+			COALESCE(d.host, ''),
+			u.disabled_reason
 		FROM 
 			url u
 		INNER JOIN 
 			users us 
 		ON 
 			u.user_id = us.id 
+		LEFT JOIN 
+			domains d 
+		ON 
+			u.domain_id = d.id 
+		WHERE 
+			u.workspace_id = ?
 		LIMIT ? OFFSET ?`
 	stmt, err := s.Db.Prepare(query)
 	if err != nil {
@@ -97,7 +534,7 @@ func (s *Storage) GetAllUrl(start, length int64) ([]urlInfo.UrlInfo, error) {
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query(length, start-1)
+	rows, err := stmt.Query(workspaceId, length, start-1)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -108,11 +545,12 @@ func (s *Storage) GetAllUrl(start, length int64) ([]urlInfo.UrlInfo, error) {
 	for rows.Next() {
 		var urlInfo urlInfo.UrlInfo
 		var user user.User
-		err := rows.Scan(&urlInfo.Alias, &urlInfo.Url, &user.ID, &user.Username)
+		err := rows.Scan(&urlInfo.Alias, &urlInfo.Url, &user.ID, &user.Username, &urlInfo.Domain, &urlInfo.DisabledReason)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 		urlInfo.User = user
+		urlInfo.WorkspaceId = workspaceId
 		urls = append(urls, urlInfo)
 	}
 
@@ -125,16 +563,36 @@ func (s *Storage) GetAllUrl(start, length int64) ([]urlInfo.UrlInfo, error) {
 
 func (s *Storage) GetAllRedirectInfo(start, length int64) ([]redirectInfo.RedirectInfo, error) {
 	const op = "storage.sqlite.GetAllRedirectInfo"
+	defer s.observe(op, time.Now())
 	query := `
 		SELECT 
-			id,
-			ip,
-			os,
-			platform,
-			browser,
-			created_at
+			ri.id,
+			COALESCE(u.alias, ''),
+			ri.ip,
+			ri.os,
+			ri.platform,
+			ri.browser,
+			ri.created_at,
+			ri.country_code,
+			ri.country,
+			ri.city,
+			COALESCE(ri.rule_id, 0),
+			COALESCE(ri.variant_id, 0),
+			ri.referrer_host,
+			ri.referrer_path,
+			ri.language,
+			ri.via_qr,
+			ri.utm_source,
+			ri.utm_medium,
+			ri.utm_campaign,
+			ri.utm_term,
+			ri.utm_content
 		FROM 
-			url_redirection_info
+			url_redirection_info ri
+		LEFT JOIN 
+			url u 
+		ON 
+			ri.url_id = u.id
 		LIMIT ? OFFSET ?`
 	stmt, err := s.Db.Prepare(query)
 	if err != nil {
@@ -152,7 +610,10 @@ func (s *Storage) GetAllRedirectInfo(start, length int64) ([]redirectInfo.Redire
 
 	for rows.Next() {
 		var urlInfo redirectInfo.RedirectInfo
-		err := rows.Scan(&urlInfo.Id, &urlInfo.Ip, &urlInfo.Os, &urlInfo.Platform, &urlInfo.Browser, &urlInfo.Created)
+		err := rows.Scan(&urlInfo.Id, &urlInfo.Alias, &urlInfo.Ip, &urlInfo.Os, &urlInfo.Platform, &urlInfo.Browser, &urlInfo.Created,
+			&urlInfo.CountryCode, &urlInfo.Country, &urlInfo.City, &urlInfo.RuleId, &urlInfo.VariantId, &urlInfo.ReferrerHost,
+			&urlInfo.ReferrerPath, &urlInfo.Language, &urlInfo.ViaQR, &urlInfo.UTM.Source, &urlInfo.UTM.Medium, &urlInfo.UTM.Campaign,
+			&urlInfo.UTM.Term, &urlInfo.UTM.Content)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
@@ -166,16 +627,29 @@ func (s *Storage) GetAllRedirectInfo(start, length int64) ([]redirectInfo.Redire
 	return infos, nil
 }
 
-func (s *Storage) DeleteURL(alias string) error {
+// DeleteURL removes the link together with its rules, variants, health and
+// clicks, so stats and exports don't count links that are gone.
+func (s *Storage) DeleteURL(domainId int64, alias string) error {
 	const op = "storage.sqlite.DeleteURL"
+	defer s.observe(op, time.Now())
 
-	stmt, err := s.Db.Prepare("DELETE FROM url WHERE alias = ?")
-	defer stmt.Close()
+	tx, err := s.Db.Begin()
 	if err != nil {
 		return fmt.Errorf("%s, %w", op, err)
 	}
+	defer tx.Rollback()
 
-	res, err := stmt.Exec(alias)
+	for _, table := range []string{"url_rules", "url_variants", "url_health", "url_redirection_info"} {
+		_, err := tx.Exec(`
+			DELETE FROM `+table+`
+			WHERE url_id IN (SELECT id FROM url WHERE COALESCE(domain_id, 0) = ? AND alias = ?)`,
+			domainId, alias)
+		if err != nil {
+			return fmt.Errorf("%s, %w", op, err)
+		}
+	}
+
+	res, err := tx.Exec("DELETE FROM url WHERE COALESCE(domain_id, 0) = ? AND alias = ?", domainId, alias)
 	if err != nil {
 		return fmt.Errorf("%s, %w", op, err)
 	}
@@ -187,19 +661,32 @@ func (s *Storage) DeleteURL(alias string) error {
 		return storage.ErrIdNotFound
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+
 	return nil
 }
 
 func (s *Storage) SaveRedirectInfo(redirectInfo *redirectInfo.RedirectInfo) error {
 	const op = "storage.sqlite.SaveRedirectInfo"
+	defer s.observe(op, time.Now())
 
-	stmt, err := s.Db.Prepare("INSERT INTO url_redirection_info (ip, os, platform, browser) VALUES(?, ?, ?, ?)")
+	stmt, err := s.Db.Prepare(`
+		INSERT INTO url_redirection_info (url_id, ip, os, platform, browser, country_code, country, city, rule_id,
+			variant_id, is_bot, visitor_hash, referrer_host, referrer_path, language, via_qr, utm_source, utm_medium,
+			utm_campaign, utm_term, utm_content)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	defer stmt.Close()
 	if err != nil {
 		return fmt.Errorf("%s, %w", op, err)
 	}
 
-	_, err = stmt.Exec(redirectInfo.Ip, redirectInfo.Os, redirectInfo.Platform, redirectInfo.Browser)
+	args := []any{nullInt64(redirectInfo.UrlId), redirectInfo.Ip, redirectInfo.Os, redirectInfo.Platform, redirectInfo.Browser,
+		redirectInfo.CountryCode, redirectInfo.Country, redirectInfo.City, nullInt64(redirectInfo.RuleId),
+		nullInt64(redirectInfo.VariantId), redirectInfo.IsBot, redirectInfo.VisitorHash, redirectInfo.ReferrerHost,
+		redirectInfo.ReferrerPath, redirectInfo.Language, redirectInfo.ViaQR}
+	_, err = stmt.Exec(append(args, utmColumns(&redirectInfo.UTM)...)...)
 
 	if err != nil {
 		return fmt.Errorf("%s, %w", op, err)
@@ -209,16 +696,16 @@ func (s *Storage) SaveRedirectInfo(redirectInfo *redirectInfo.RedirectInfo) erro
 }
 
 func (s *Storage) GetUser(userName string) (user.User, error) {
-	const op = "storage.sqlite.GetUser"
+	defer s.observe("storage.sqlite.GetUser", time.Now())
 
-	stmt, err := s.Db.Prepare("SELECT id, username, password FROM users WHERE username = ?")
-	defer stmt.Close()
-	if err != nil {
-		return user.User{}, fmt.Errorf("%s, %w", op, err)
-	}
+	return getUser(s.Db, userName)
+}
+
+func getUser(q querier, userName string) (user.User, error) {
+	const op = "storage.sqlite.GetUser"
 
 	var userEntity user.User
-	err = stmt.QueryRow(userName).Scan(&userEntity.ID, &userEntity.Username, &userEntity.Password)
+	err := q.QueryRow("SELECT id, username, password FROM users WHERE username = ?", userName).Scan(&userEntity.ID, &userEntity.Username, &userEntity.Password)
 	if errors.Is(err, sql.ErrNoRows) {
 		return user.User{}, storage.UserNotFound
 	}
@@ -229,6 +716,74 @@ func (s *Storage) GetUser(userName string) (user.User, error) {
 	return userEntity, nil
 }
 
+func (s *Storage) IsAdmin(userId int64) (bool, error) {
+	const op = "storage.sqlite.IsAdmin"
+	defer s.observe(op, time.Now())
+
+	var isAdmin bool
+	err := s.Db.QueryRow("SELECT is_admin FROM users WHERE id = ?", userId).Scan(&isAdmin)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, storage.UserNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return isAdmin, nil
+}
+
+// ExportURLs calls fn with every link, ordered by id, together with its
+// owner's username and domain host.
+func (s *Storage) ExportURLs(fn func(urlInfo.UrlInfo) error) error {
+	const op = "storage.sqlite.ExportURLs"
+	defer s.observe(op, time.Now())
+
+	rows, err := s.Db.Query(`
+		SELECT
+			u.id,
+			u.alias,
+			u.url,
+			us.id,
+			us.username,
+			COALESCE(u.workspace_id, 0),
+			COALESCE(d.host, ''),
+			COALESCE(u.created_at, '')
+		FROM
+			url u
+		INNER JOIN
+			users us
+		ON
+			u.user_id = us.id
+		LEFT JOIN
+			domains d
+		ON
+			u.domain_id = d.id
+		ORDER BY
+			u.id`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var link urlInfo.UrlInfo
+		err := rows.Scan(&link.Id, &link.Alias, &link.Url, &link.User.ID, &link.User.Username,
+			&link.WorkspaceId, &link.Domain, &link.Created)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err := fn(link); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
 func (s *Storage) CloseConnection() {
 	err := s.Db.Close()
 	if err != nil {
@@ -246,18 +801,54 @@ func (s *Storage) RunMigrations(log *slog.Logger) error {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	// Migrations that alter existing tables can't be re-run,
+	// so every applied file is recorded and skipped afterwards.
+	_, err = s.Db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations
+		(
+			name VARCHAR(255) PRIMARY KEY,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	for _, file := range migrationFiles {
+		name := filepath.Base(file)
+
+		var applied int
+		err := s.Db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE name = ?", name).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if applied > 0 {
+			continue
+		}
+
 		migration, err := os.ReadFile(file)
 		if err != nil {
 			return fmt.Errorf("%s: %w", op, err)
 		}
 
-		_, err = s.Db.Exec(string(migration))
+		tx, err := s.Db.Begin()
 		if err != nil {
 			return fmt.Errorf("%s: %w", op, err)
 		}
 
-		log.Info("Executed migration: ", file)
+		if _, err = tx.Exec(string(migration)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("%s: %s: %w", op, name, err)
+		}
+
+		if _, err = tx.Exec("INSERT INTO schema_migrations(name) VALUES (?)", name); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Info("Executed migration", slog.String("file", file))
 	}
 
 	return nil
@@ -265,6 +856,7 @@ func (s *Storage) RunMigrations(log *slog.Logger) error {
 
 func (s *Storage) Query(query string, args ...interface{}) ([]map[string]interface{}, error) {
 	const op = "storage.sqlite.Query"
+	defer s.observe(op, time.Now())
 
 	rows, err := s.Db.Query(query, args...)
 	if err != nil {
@@ -304,3 +896,8 @@ func (s *Storage) Query(query string, args ...interface{}) ([]map[string]interfa
 
 	return results, nil
 }
+
+// nullInt64 stores zero ids as NULL so optional foreign keys stay empty.
+func nullInt64(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
@@ -0,0 +1,144 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+	"url-shortner/internel/domain/entities/urlInfo"
+)
+
+// GetURLStats counts the clicks of the link with urlId per rule, variant and
+// country. Clicks of bots are left out unless includeBots is set.
+func (s *Storage) GetURLStats(urlId int64, includeBots bool) (urlInfo.Stats, error) {
+	const op = "storage.sqlite.GetURLStats"
+	defer s.observe(op, time.Now())
+
+	stats := urlInfo.Stats{
+		Rules:     make([]urlInfo.RuleStats, 0),
+		Variants:  make([]urlInfo.VariantStats, 0),
+		Countries: make([]urlInfo.CountryStats, 0),
+	}
+
+	err := s.Db.QueryRow(`
+		SELECT
+			u.alias,
+			COUNT(ri.id),
+			COUNT(DISTINCT NULLIF(ri.visitor_hash, '')),
+			(SELECT COUNT(*) FROM url_redirection_info WHERE url_id = u.id AND is_bot = 1)
+		FROM
+			url u
+		LEFT JOIN
+			url_redirection_info ri
+		ON
+			ri.url_id = u.id AND (? OR ri.is_bot = 0)
+		WHERE
+			u.id = ?
+		GROUP BY
+			u.id`, includeBots, urlId).Scan(&stats.Alias, &stats.Clicks, &stats.UniqueClicks, &stats.BotClicks)
+	if err != nil {
+		return urlInfo.Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.Db.Query(`
+		SELECT
+			COALESCE(ri.rule_id, 0),
+			CASE WHEN ri.rule_id IS NULL THEN u.url ELSE COALESCE(r.url, '') END,
+			COUNT(*) AS clicks,
+			COUNT(DISTINCT NULLIF(ri.visitor_hash, ''))
+		FROM
+			url_redirection_info ri
+		INNER JOIN
+			url u
+		ON
+			ri.url_id = u.id
+		LEFT JOIN
+			url_rules r
+		ON
+			ri.rule_id = r.id
+		WHERE
+			ri.url_id = ? AND (? OR ri.is_bot = 0)
+		GROUP BY
+			COALESCE(ri.rule_id, 0)
+		ORDER BY
+			clicks DESC, 1`, urlId, includeBots)
+	if err != nil {
+		return urlInfo.Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rule urlInfo.RuleStats
+		if err := rows.Scan(&rule.RuleId, &rule.Url, &rule.Clicks, &rule.UniqueClicks); err != nil {
+			return urlInfo.Stats{}, fmt.Errorf("%s: %w", op, err)
+		}
+		stats.Rules = append(stats.Rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return urlInfo.Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err = s.Db.Query(`
+		SELECT
+			ri.variant_id,
+			COALESCE(v.url, ''),
+			COUNT(*) AS clicks,
+			COUNT(DISTINCT NULLIF(ri.visitor_hash, ''))
+		FROM
+			url_redirection_info ri
+		LEFT JOIN
+			url_variants v
+		ON
+			ri.variant_id = v.id
+		WHERE
+			ri.url_id = ? AND ri.variant_id IS NOT NULL AND (? OR ri.is_bot = 0)
+		GROUP BY
+			ri.variant_id
+		ORDER BY
+			clicks DESC, 1`, urlId, includeBots)
+	if err != nil {
+		return urlInfo.Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v urlInfo.VariantStats
+		if err := rows.Scan(&v.VariantId, &v.Url, &v.Clicks, &v.UniqueClicks); err != nil {
+			return urlInfo.Stats{}, fmt.Errorf("%s: %w", op, err)
+		}
+		stats.Variants = append(stats.Variants, v)
+	}
+	if err := rows.Err(); err != nil {
+		return urlInfo.Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err = s.Db.Query(`
+		SELECT
+			country_code,
+			MAX(country),
+			COUNT(*) AS clicks,
+			COUNT(DISTINCT NULLIF(visitor_hash, ''))
+		FROM
+			url_redirection_info
+		WHERE
+			url_id = ? AND (? OR is_bot = 0)
+		GROUP BY
+			country_code
+		ORDER BY
+			clicks DESC, country_code`, urlId, includeBots)
+	if err != nil {
+		return urlInfo.Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var country urlInfo.CountryStats
+		if err := rows.Scan(&country.CountryCode, &country.Country, &country.Clicks, &country.UniqueClicks); err != nil {
+			return urlInfo.Stats{}, fmt.Errorf("%s: %w", op, err)
+		}
+		stats.Countries = append(stats.Countries, country)
+	}
+	if err := rows.Err(); err != nil {
+		return urlInfo.Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,364 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"url-shortner/internel/domain/entities/webhook"
+	"url-shortner/internel/storage"
+)
+
+func (s *Storage) SaveWebhook(hook *webhook.Webhook) (int64, error) {
+	const op = "storage.sqlite.SaveWebhook"
+	defer s.observe(op, time.Now())
+
+	res, err := s.Db.Exec("INSERT INTO webhooks(user_id, workspace_id, url, secret, events) VALUES (?, ?, ?, ?, ?)",
+		hook.UserId, nullInt64(hook.WorkspaceId), hook.URL, hook.Secret, joinEvents(hook.Events))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s, failed to get last insert id: %w", op, err)
+	}
+
+	return id, nil
+}
+
+const webhookColumns = `
+		SELECT
+			id,
+			user_id,
+			COALESCE(workspace_id, 0),
+			url,
+			secret,
+			events,
+			created_at
+		FROM
+			webhooks`
+
+func scanWebhook(row interface{ Scan(...any) error }) (webhook.Webhook, error) {
+	var (
+		h      webhook.Webhook
+		events string
+	)
+	err := row.Scan(&h.ID, &h.UserId, &h.WorkspaceId, &h.URL, &h.Secret, &events, &h.Created)
+	h.Events = splitEvents(events)
+
+	return h, err
+}
+
+func joinEvents(events []webhook.Event) string {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = string(e)
+	}
+
+	return strings.Join(names, ",")
+}
+
+func splitEvents(s string) []webhook.Event {
+	events := make([]webhook.Event, 0)
+	for _, name := range strings.Split(s, ",") {
+		if name != "" {
+			events = append(events, webhook.Event(name))
+		}
+	}
+
+	return events
+}
+
+func (s *Storage) GetWebhook(id int64) (webhook.Webhook, error) {
+	const op = "storage.sqlite.GetWebhook"
+	defer s.observe(op, time.Now())
+
+	h, err := scanWebhook(s.Db.QueryRow(webhookColumns+" WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return webhook.Webhook{}, storage.ErrWebhookNotFound
+	}
+	if err != nil {
+		return webhook.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return h, nil
+}
+
+// GetUserWebhooks returns the personal webhooks of the user and the webhooks
+// of every workspace the user administers.
+func (s *Storage) GetUserWebhooks(userId int64) ([]webhook.Webhook, error) {
+	const op = "storage.sqlite.GetUserWebhooks"
+	defer s.observe(op, time.Now())
+
+	rows, err := s.Db.Query(webhookColumns+`
+		WHERE
+			(workspace_id IS NULL AND user_id = ?)
+			OR workspace_id IN (
+				SELECT workspace_id FROM workspace_members WHERE user_id = ? AND role IN ('owner', 'admin')
+			)
+		ORDER BY
+			id`, userId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	hooks := make([]webhook.Webhook, 0)
+
+	for rows.Next() {
+		h, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		hooks = append(hooks, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return hooks, nil
+}
+
+// DeleteWebhook removes a webhook with its deliveries.
+func (s *Storage) DeleteWebhook(id int64) error {
+	const op = "storage.sqlite.DeleteWebhook"
+	defer s.observe(op, time.Now())
+
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM webhook_deliveries WHERE webhook_id = ?", id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := tx.Exec("DELETE FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	} else if affectedRows == 0 {
+		return storage.ErrWebhookNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SaveWebhookDeliveries queues payload for every webhook of owner subscribed
+// to event and returns how many deliveries were queued.
+func (s *Storage) SaveWebhookDeliveries(owner webhook.Owner, event webhook.Event, payload string) (int64, error) {
+	const op = "storage.sqlite.SaveWebhookDeliveries"
+	defer s.observe(op, time.Now())
+
+	// separate conditions let the user_id and workspace_id indexes be used
+	owned, ownerId := "workspace_id IS NULL AND user_id = ?", owner.UserId
+	if owner.WorkspaceId > 0 {
+		owned, ownerId = "workspace_id = ?", owner.WorkspaceId
+	}
+
+	res, err := s.Db.Exec(`
+		INSERT INTO webhook_deliveries(webhook_id, event, payload, next_attempt_at)
+		SELECT
+			id, ?, ?, ?
+		FROM
+			webhooks
+		WHERE
+			`+owned+`
+			AND ',' || events || ',' LIKE '%,' || ? || ',%'`,
+		event, payload, deliveryTime(time.Now()), ownerId, event)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// SaveWebhookDelivery stores a delivery to a single webhook as it is, for
+// deliveries that are sent right away instead of being queued.
+func (s *Storage) SaveWebhookDelivery(d *webhook.Delivery) (int64, error) {
+	const op = "storage.sqlite.SaveWebhookDelivery"
+	defer s.observe(op, time.Now())
+
+	res, err := s.Db.Exec(`
+		INSERT INTO webhook_deliveries(webhook_id, event, payload, status, attempts, status_code, error, delivered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, CASE WHEN ? = 'delivered' THEN CURRENT_TIMESTAMP END)`,
+		d.WebhookId, d.Event, d.Payload, d.Status, d.Attempts, d.StatusCode, d.Error, d.Status)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s, failed to get last insert id: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// CountPendingWebhookDeliveries is the number of deliveries waiting to be
+// sent or retried.
+func (s *Storage) CountPendingWebhookDeliveries() (int64, error) {
+	const op = "storage.sqlite.CountPendingWebhookDeliveries"
+	defer s.observe(op, time.Now())
+
+	var pending int64
+	err := s.Db.QueryRow("SELECT COUNT(*) FROM webhook_deliveries WHERE status = ?", webhook.StatusPending).Scan(&pending)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pending, nil
+}
+
+// GetDueWebhookDeliveries returns up to limit pending deliveries whose next
+// attempt is not after now, oldest first.
+func (s *Storage) GetDueWebhookDeliveries(now time.Time, limit int) ([]webhook.Pending, error) {
+	const op = "storage.sqlite.GetDueWebhookDeliveries"
+	defer s.observe(op, time.Now())
+
+	rows, err := s.Db.Query(`
+		SELECT
+			d.id,
+			d.webhook_id,
+			d.event,
+			d.payload,
+			d.attempts,
+			h.url,
+			h.secret
+		FROM
+			webhook_deliveries d
+			JOIN webhooks h ON h.id = d.webhook_id
+		WHERE
+			d.status = ? AND d.next_attempt_at <= ?
+		ORDER BY
+			d.next_attempt_at, d.id
+		LIMIT ?`, webhook.StatusPending, deliveryTime(now), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	due := make([]webhook.Pending, 0)
+
+	for rows.Next() {
+		p := webhook.Pending{Delivery: webhook.Delivery{Status: webhook.StatusPending}}
+		err := rows.Scan(&p.ID, &p.WebhookId, &p.Event, &p.Payload, &p.Attempts, &p.URL, &p.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		due = append(due, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return due, nil
+}
+
+// UpdateWebhookDelivery stores the outcome of an attempt: the status,
+// attempts, status code and error of d and when it is tried next, the zero
+// time for deliveries that are done.
+func (s *Storage) UpdateWebhookDelivery(d webhook.Delivery, nextAttempt time.Time) error {
+	const op = "storage.sqlite.UpdateWebhookDelivery"
+	defer s.observe(op, time.Now())
+
+	next := ""
+	if !nextAttempt.IsZero() {
+		next = deliveryTime(nextAttempt)
+	}
+
+	_, err := s.Db.Exec(`
+		UPDATE webhook_deliveries
+		SET
+			status = ?,
+			attempts = ?,
+			next_attempt_at = NULLIF(?, ''),
+			status_code = ?,
+			error = ?,
+			delivered_at = CASE WHEN ? = 'delivered' THEN CURRENT_TIMESTAMP END
+		WHERE id = ?`,
+		d.Status, d.Attempts, next, d.StatusCode, d.Error, d.Status, d.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveries returns the last limit deliveries of a webhook, newest
+// first.
+func (s *Storage) GetWebhookDeliveries(webhookId int64, limit int) ([]webhook.Delivery, error) {
+	const op = "storage.sqlite.GetWebhookDeliveries"
+	defer s.observe(op, time.Now())
+
+	rows, err := s.Db.Query(`
+		SELECT
+			id,
+			webhook_id,
+			event,
+			payload,
+			status,
+			attempts,
+			next_attempt_at,
+			status_code,
+			error,
+			created_at,
+			delivered_at
+		FROM
+			webhook_deliveries
+		WHERE
+			webhook_id = ?
+		ORDER BY
+			id DESC
+		LIMIT ?`, webhookId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]webhook.Delivery, 0)
+
+	for rows.Next() {
+		var (
+			d                      webhook.Delivery
+			nextAttempt, delivered sql.NullString
+		)
+		err := rows.Scan(&d.ID, &d.WebhookId, &d.Event, &d.Payload, &d.Status, &d.Attempts, &nextAttempt,
+			&d.StatusCode, &d.Error, &d.Created, &delivered)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		d.NextAttempt, d.Delivered = nextAttempt.String, delivered.String
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deliveries, nil
+}
+
+// deliveryTime formats t like CURRENT_TIMESTAMP, so the times of deliveries
+// compare as strings.
+func deliveryTime(t time.Time) string {
+	return t.UTC().Format(time.DateTime)
+}
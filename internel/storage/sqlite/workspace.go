@@ -0,0 +1,381 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"time"
+	"url-shortner/internel/domain/entities/workspace"
+	"url-shortner/internel/storage"
+)
+
+func (s *Storage) CreateWorkspace(name string, ownerId int64) (int64, error) {
+	const op = "storage.sqlite.CreateWorkspace"
+	defer s.observe(op, time.Now())
+
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO workspaces(name, created_by) VALUES (?, ?)", name, ownerId)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s, failed to get last insert id: %w", op, err)
+	}
+
+	_, err = tx.Exec("INSERT INTO workspace_members(workspace_id, user_id, role) VALUES (?, ?, ?)",
+		id, ownerId, workspace.RoleOwner)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetUserWorkspaces(userId int64) ([]workspace.Workspace, error) {
+	const op = "storage.sqlite.GetUserWorkspaces"
+	defer s.observe(op, time.Now())
+	query := `
+		SELECT
+			w.id,
+			w.name,
+			m.role,
+			w.created_at
+		FROM
+			workspaces w
+		INNER JOIN
+			workspace_members m
+		ON
+			m.workspace_id = w.id
+		WHERE
+			m.user_id = ?
+		ORDER BY
+			w.name`
+
+	rows, err := s.Db.Query(query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	workspaces := make([]workspace.Workspace, 0)
+
+	for rows.Next() {
+		var ws workspace.Workspace
+		if err := rows.Scan(&ws.ID, &ws.Name, &ws.Role, &ws.Created); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		workspaces = append(workspaces, ws)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return workspaces, nil
+}
+
+func (s *Storage) GetMemberRole(workspaceId, userId int64) (workspace.Role, error) {
+	const op = "storage.sqlite.GetMemberRole"
+	defer s.observe(op, time.Now())
+
+	var role workspace.Role
+	err := s.Db.QueryRow("SELECT role FROM workspace_members WHERE workspace_id = ? AND user_id = ?",
+		workspaceId, userId).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", storage.ErrMemberNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return role, nil
+}
+
+func (s *Storage) GetWorkspaceMembers(workspaceId int64) ([]workspace.Member, error) {
+	const op = "storage.sqlite.GetWorkspaceMembers"
+	defer s.observe(op, time.Now())
+	query := `
+		SELECT
+			us.id,
+			us.username,
+			m.role,
+			m.created_at
+		FROM
+			workspace_members m
+		INNER JOIN
+			users us
+		ON
+			m.user_id = us.id
+		WHERE
+			m.workspace_id = ?
+		ORDER BY
+			us.username`
+
+	rows, err := s.Db.Query(query, workspaceId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	members := make([]workspace.Member, 0)
+
+	for rows.Next() {
+		var member workspace.Member
+		if err := rows.Scan(&member.UserID, &member.Username, &member.Role, &member.Created); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return members, nil
+}
+
+func (s *Storage) UpdateMemberRole(workspaceId, userId int64, role workspace.Role) error {
+	const op = "storage.sqlite.UpdateMemberRole"
+	defer s.observe(op, time.Now())
+
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if role != workspace.RoleOwner {
+		if err := ensureAnotherOwner(tx, workspaceId, userId); err != nil {
+			return err
+		}
+	}
+
+	res, err := tx.Exec("UPDATE workspace_members SET role = ? WHERE workspace_id = ? AND user_id = ?",
+		role, workspaceId, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	} else if affectedRows == 0 {
+		return storage.ErrMemberNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteMember(workspaceId, userId int64) error {
+	const op = "storage.sqlite.DeleteMember"
+	defer s.observe(op, time.Now())
+
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := ensureAnotherOwner(tx, workspaceId, userId); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("DELETE FROM workspace_members WHERE workspace_id = ? AND user_id = ?", workspaceId, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	} else if affectedRows == 0 {
+		return storage.ErrMemberNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ensureAnotherOwner fails with storage.ErrLastOwner when userId is the only
+// owner of the workspace, so demoting or removing them would orphan its links.
+func ensureAnotherOwner(tx *sql.Tx, workspaceId, userId int64) error {
+	const op = "storage.sqlite.ensureAnotherOwner"
+
+	var owners, isOwner int
+	err := tx.QueryRow(`
+		SELECT
+			COUNT(*),
+			COALESCE(SUM(user_id = ?), 0)
+		FROM
+			workspace_members
+		WHERE
+			workspace_id = ? AND role = ?`,
+		userId, workspaceId, workspace.RoleOwner).Scan(&owners, &isOwner)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if isOwner > 0 && owners == 1 {
+		return storage.ErrLastOwner
+	}
+
+	return nil
+}
+
+func (s *Storage) SaveInvitation(invitation *workspace.Invitation) (int64, error) {
+	const op = "storage.sqlite.SaveInvitation"
+	defer s.observe(op, time.Now())
+
+	res, err := s.Db.Exec(`
+		INSERT INTO workspace_invitations(workspace_id, user_id, role, token, invited_by, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		invitation.WorkspaceID, invitation.UserID, invitation.Role, invitation.Token, invitation.InvitedBy, invitation.Expires)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s, failed to get last insert id: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// AcceptInvitation adds the invited user to the workspace and marks the
+// invitation as used. Expired, used or foreign invitations are reported as
+// storage.ErrInvitationNotFound.
+func (s *Storage) AcceptInvitation(token string, userId int64) (workspace.Invitation, error) {
+	const op = "storage.sqlite.AcceptInvitation"
+	defer s.observe(op, time.Now())
+
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return workspace.Invitation{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var invitation workspace.Invitation
+	err = tx.QueryRow(`
+		SELECT
+			id,
+			workspace_id,
+			user_id,
+			role,
+			invited_by,
+			created_at,
+			expires_at
+		FROM
+			workspace_invitations
+		WHERE
+			token = ? AND user_id = ? AND accepted_at IS NULL AND expires_at > ?`,
+		token, userId, time.Now().UTC().Format(time.DateTime)).
+		Scan(&invitation.ID, &invitation.WorkspaceID, &invitation.UserID, &invitation.Role,
+			&invitation.InvitedBy, &invitation.Created, &invitation.Expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return workspace.Invitation{}, storage.ErrInvitationNotFound
+	}
+	if err != nil {
+		return workspace.Invitation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec("INSERT INTO workspace_members(workspace_id, user_id, role) VALUES (?, ?, ?)",
+		invitation.WorkspaceID, invitation.UserID, invitation.Role)
+	if err != nil {
+		var liteErr *sqlite.Error
+		if errors.As(err, &liteErr) && liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
+			return workspace.Invitation{}, storage.ErrMemberExists
+		}
+		return workspace.Invitation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec("UPDATE workspace_invitations SET accepted_at = CURRENT_TIMESTAMP WHERE id = ?", invitation.ID)
+	if err != nil {
+		return workspace.Invitation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return workspace.Invitation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return invitation, nil
+}
+
+func (s *Storage) GetWorkspaceStats(workspaceId int64, top int64, includeBots bool) (workspace.Stats, error) {
+	const op = "storage.sqlite.GetWorkspaceStats"
+	defer s.observe(op, time.Now())
+
+	stats := workspace.Stats{WorkspaceID: workspaceId, TopLinks: make([]workspace.LinkStats, 0)}
+
+	err := s.Db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM url WHERE workspace_id = ?),
+			(SELECT COUNT(*) FROM url_redirection_info ri INNER JOIN url u ON ri.url_id = u.id
+				WHERE u.workspace_id = ? AND (? OR ri.is_bot = 0)),
+			(SELECT COUNT(DISTINCT NULLIF(ri.visitor_hash, '')) FROM url_redirection_info ri INNER JOIN url u ON ri.url_id = u.id
+				WHERE u.workspace_id = ? AND (? OR ri.is_bot = 0)),
+			(SELECT COUNT(*) FROM workspace_members WHERE workspace_id = ?)`,
+		workspaceId, workspaceId, includeBots, workspaceId, includeBots, workspaceId).
+		Scan(&stats.Links, &stats.Clicks, &stats.UniqueClicks, &stats.Members)
+	if err != nil {
+		return workspace.Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.Db.Query(`
+		SELECT
+			u.alias,
+			u.url,
+			COUNT(ri.id) AS clicks,
+			COUNT(DISTINCT NULLIF(ri.visitor_hash, ''))
+		FROM
+			url u
+		LEFT JOIN
+			url_redirection_info ri
+		ON
+			ri.url_id = u.id AND (? OR ri.is_bot = 0)
+		WHERE
+			u.workspace_id = ?
+		GROUP BY
+			u.id
+		ORDER BY
+			clicks DESC, u.alias
+		LIMIT ?`, includeBots, workspaceId, top)
+	if err != nil {
+		return workspace.Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var link workspace.LinkStats
+		if err := rows.Scan(&link.Alias, &link.Url, &link.Clicks, &link.UniqueClicks); err != nil {
+			return workspace.Stats{}, fmt.Errorf("%s: %w", op, err)
+		}
+		stats.TopLinks = append(stats.TopLinks, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return workspace.Stats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return stats, nil
+}
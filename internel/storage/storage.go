@@ -1,6 +1,11 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"url-shortner/internel/domain/entities/domain"
+	"url-shortner/internel/domain/entities/urlInfo"
+	"url-shortner/internel/domain/entities/user"
+)
 
 var (
 	ErrURLNotFound = errors.New("url not found")
@@ -8,4 +13,29 @@ var (
 	ErrIdNotFound  = errors.New("id not found")
 
 	UserNotFound = errors.New("user not found")
+
+	ErrWorkspaceNotFound  = errors.New("workspace not found")
+	ErrMemberNotFound     = errors.New("member not found")
+	ErrMemberExists       = errors.New("member exists")
+	ErrLastOwner          = errors.New("workspace must keep at least one owner")
+	ErrInvitationNotFound = errors.New("invitation not found")
+
+	ErrDomainNotFound = errors.New("domain not found")
+	ErrDomainExists   = errors.New("domain exists")
+	ErrDomainInUse    = errors.New("domain has links")
+
+	ErrWebhookNotFound = errors.New("webhook not found")
 )
+
+// URLTx saves links in a single transaction, ids for generated aliases and
+// lookups the links depend on are done inside it too.
+type URLTx interface {
+	GetUser(userName string) (user.User, error)
+	GetDomainByHost(host string) (domain.Domain, error)
+	GetURL(domainId int64, alias string) (urlInfo.UrlInfo, error)
+	SaveURL(urlInfo *urlInfo.UrlInfo) (int64, error)
+	UpdateURL(urlInfo *urlInfo.UrlInfo) error
+	NextAliasSequence() (int64, error)
+	Commit() error
+	Rollback() error
+}
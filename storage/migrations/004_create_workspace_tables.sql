@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS workspaces
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    created_by INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT foreign_workspace_user_id FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members
+(
+    workspace_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, user_id),
+    CONSTRAINT foreign_member_workspace_id FOREIGN KEY (workspace_id) REFERENCES workspaces(id),
+    CONSTRAINT foreign_member_user_id FOREIGN KEY (user_id) REFERENCES users(id)
+);
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON workspace_members (user_id);
+
+CREATE TABLE IF NOT EXISTS workspace_invitations
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    invited_by INTEGER NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+    accepted_at DATETIME,
+    CONSTRAINT foreign_invitation_workspace_id FOREIGN KEY (workspace_id) REFERENCES workspaces(id),
+    CONSTRAINT foreign_invitation_user_id FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+ALTER TABLE url ADD COLUMN workspace_id INTEGER REFERENCES workspaces(id);
+CREATE INDEX IF NOT EXISTS idx_url_workspace_id ON url (workspace_id);
+
+ALTER TABLE url_redirection_info ADD COLUMN url_id INTEGER REFERENCES url(id);
+CREATE INDEX IF NOT EXISTS idx_url_redirection_info_url_id ON url_redirection_info (url_id);
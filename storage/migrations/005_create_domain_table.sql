@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS domains
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    host VARCHAR(255) NOT NULL UNIQUE,
+    user_id INTEGER NOT NULL,
+    workspace_id INTEGER,
+    fallback_url TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT foreign_domain_user_id FOREIGN KEY (user_id) REFERENCES users(id),
+    CONSTRAINT foreign_domain_workspace_id FOREIGN KEY (workspace_id) REFERENCES workspaces(id)
+);
+
+-- aliases become unique per domain, so the table is rebuilt without the column level UNIQUE
+CREATE TABLE url_new
+(
+    id    INTEGER PRIMARY KEY,
+    alias TEXT NOT NULL,
+    url   TEXT NOT NULL,
+    user_id INTEGER NOT NULL,
+    workspace_id INTEGER,
+    domain_id INTEGER,
+    CONSTRAINT foreign_url_user_id FOREIGN KEY (user_id) REFERENCES users(id),
+    CONSTRAINT foreign_url_workspace_id FOREIGN KEY (workspace_id) REFERENCES workspaces(id),
+    CONSTRAINT foreign_url_domain_id FOREIGN KEY (domain_id) REFERENCES domains(id)
+);
+INSERT INTO url_new (id, alias, url, user_id, workspace_id)
+SELECT id, alias, url, user_id, workspace_id FROM url;
+DROP TABLE url;
+ALTER TABLE url_new RENAME TO url;
+
+-- links without a domain live on the default base url, NULL can't be compared in UNIQUE
+CREATE UNIQUE INDEX IF NOT EXISTS idx_url_domain_alias ON url (COALESCE(domain_id, 0), alias);
+CREATE INDEX IF NOT EXISTS idx_url_workspace_id ON url (workspace_id);
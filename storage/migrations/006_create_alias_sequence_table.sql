@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS alias_sequence
+(
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    value INTEGER NOT NULL
+);
+-- counter based aliases start after the existing links
+INSERT OR IGNORE INTO alias_sequence (id, value) SELECT 1, COALESCE(MAX(id), 0) FROM url;
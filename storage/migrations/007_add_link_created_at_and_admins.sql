@@ -0,0 +1,9 @@
+-- SQLite can't add a column with a CURRENT_TIMESTAMP default, so inserts set it
+-- and existing links get their first click time, or the migration time.
+ALTER TABLE url ADD COLUMN created_at DATETIME;
+UPDATE url SET created_at = COALESCE(
+    (SELECT MIN(ri.created_at) FROM url_redirection_info ri WHERE ri.url_id = url.id),
+    CURRENT_TIMESTAMP
+);
+
+ALTER TABLE users ADD COLUMN is_admin INTEGER NOT NULL DEFAULT 0;
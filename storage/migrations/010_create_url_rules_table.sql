@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS url_rules
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    os VARCHAR(20) NOT NULL DEFAULT '',
+    device VARCHAR(20) NOT NULL DEFAULT '',
+    browser VARCHAR(20) NOT NULL DEFAULT '',
+    url TEXT NOT NULL,
+    CONSTRAINT foreign_url_rules_url_id FOREIGN KEY (url_id) REFERENCES url(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_url_rules_url_id ON url_rules (url_id, position);
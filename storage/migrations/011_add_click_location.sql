@@ -0,0 +1,4 @@
+-- locations are resolved once when the click is recorded, older clicks stay unknown
+ALTER TABLE url_redirection_info ADD COLUMN country_code VARCHAR(2) NOT NULL DEFAULT '';
+ALTER TABLE url_redirection_info ADD COLUMN country VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE url_redirection_info ADD COLUMN city VARCHAR(100) NOT NULL DEFAULT '';
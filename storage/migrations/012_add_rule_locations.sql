@@ -0,0 +1,6 @@
+-- comma separated codes, empty matches any location
+ALTER TABLE url_rules ADD COLUMN countries TEXT NOT NULL DEFAULT '';
+ALTER TABLE url_rules ADD COLUMN continents TEXT NOT NULL DEFAULT '';
+
+-- the rule that picked the destination of a click, NULL for the default url
+ALTER TABLE url_redirection_info ADD COLUMN rule_id INTEGER;
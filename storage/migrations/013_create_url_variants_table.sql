@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS url_variants
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    weight INTEGER NOT NULL,
+    CONSTRAINT foreign_url_variants_url_id FOREIGN KEY (url_id) REFERENCES url(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_url_variants_url_id ON url_variants (url_id, position);
+
+ALTER TABLE url ADD COLUMN sticky_variants INTEGER NOT NULL DEFAULT 0;
+
+-- the variant a click was sent to, NULL when the link wasn't split
+ALTER TABLE url_redirection_info ADD COLUMN variant_id INTEGER;
@@ -0,0 +1,6 @@
+ALTER TABLE url ADD COLUMN query_mode VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE url ADD COLUMN utm_source TEXT NOT NULL DEFAULT '';
+ALTER TABLE url ADD COLUMN utm_medium TEXT NOT NULL DEFAULT '';
+ALTER TABLE url ADD COLUMN utm_campaign TEXT NOT NULL DEFAULT '';
+ALTER TABLE url ADD COLUMN utm_term TEXT NOT NULL DEFAULT '';
+ALTER TABLE url ADD COLUMN utm_content TEXT NOT NULL DEFAULT '';
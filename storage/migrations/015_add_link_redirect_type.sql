@@ -0,0 +1,2 @@
+-- 0 uses the redirect status configured for the service
+ALTER TABLE url ADD COLUMN redirect_type INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- links whose destination the url policy rejects stop redirecting
+ALTER TABLE url ADD COLUMN disabled_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE url ADD COLUMN disabled_at TIMESTAMP;
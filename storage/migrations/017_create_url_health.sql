@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS url_health
+(
+    url_id INTEGER PRIMARY KEY,
+    status INTEGER NOT NULL DEFAULT 0,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    redirect_chain TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    -- failed checks in a row, reset by the first successful one
+    failures INTEGER NOT NULL DEFAULT 0,
+    checked_at TIMESTAMP NOT NULL,
+    CONSTRAINT foreign_url_health_url_id FOREIGN KEY (url_id) REFERENCES url(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_url_health_failures ON url_health (failures);
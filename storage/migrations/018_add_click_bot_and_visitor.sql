@@ -0,0 +1,7 @@
+ALTER TABLE url_redirection_info ADD COLUMN is_bot INTEGER NOT NULL DEFAULT 0;
+-- daily salted hash of the visitor's ip and user agent, for unique counts
+ALTER TABLE url_redirection_info ADD COLUMN visitor_hash TEXT NOT NULL DEFAULT '';
+
+-- replaces the url_id index of 004, which this one covers
+DROP INDEX IF EXISTS idx_url_redirection_info_url_id;
+CREATE INDEX IF NOT EXISTS idx_url_redirection_info_url_id_bot ON url_redirection_info (url_id, is_bot);
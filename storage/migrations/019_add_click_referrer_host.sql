@@ -0,0 +1,4 @@
+-- host of the page the visitor came from, empty for direct visits
+ALTER TABLE url_redirection_info ADD COLUMN referrer_host VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_url_redirection_info_created_at ON url_redirection_info (url_id, created_at);
@@ -0,0 +1,10 @@
+ALTER TABLE url_redirection_info ADD COLUMN referrer_path VARCHAR(255) NOT NULL DEFAULT '';
+-- primary subtag of the preferred language from Accept-Language
+ALTER TABLE url_redirection_info ADD COLUMN language VARCHAR(8) NOT NULL DEFAULT '';
+ALTER TABLE url_redirection_info ADD COLUMN via_qr INTEGER NOT NULL DEFAULT 0;
+-- utm parameters of the short link the visitor opened
+ALTER TABLE url_redirection_info ADD COLUMN utm_source TEXT NOT NULL DEFAULT '';
+ALTER TABLE url_redirection_info ADD COLUMN utm_medium TEXT NOT NULL DEFAULT '';
+ALTER TABLE url_redirection_info ADD COLUMN utm_campaign TEXT NOT NULL DEFAULT '';
+ALTER TABLE url_redirection_info ADD COLUMN utm_term TEXT NOT NULL DEFAULT '';
+ALTER TABLE url_redirection_info ADD COLUMN utm_content TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    -- webhooks of a workspace get the events of its links instead of the
+    -- personal links of the user
+    workspace_id INTEGER,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    -- comma separated event names
+    events TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT foreign_webhooks_user_id FOREIGN KEY (user_id) REFERENCES users(id),
+    CONSTRAINT foreign_webhooks_workspace_id FOREIGN KEY (workspace_id) REFERENCES workspaces(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks (user_id);
+CREATE INDEX IF NOT EXISTS idx_webhooks_workspace_id ON webhooks (workspace_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id INTEGER PRIMARY KEY,
+    webhook_id INTEGER NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    payload TEXT NOT NULL,
+    -- pending, delivered or failed
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP,
+    CONSTRAINT foreign_webhook_deliveries_webhook_id FOREIGN KEY (webhook_id) REFERENCES webhooks(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, id);
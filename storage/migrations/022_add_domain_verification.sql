@@ -0,0 +1,24 @@
+-- domains only serve links once a TXT record proves control of them, until
+-- then several users may claim the same host and the first to verify gets it,
+-- so the table is rebuilt without the column level UNIQUE
+CREATE TABLE domains_new
+(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    host VARCHAR(255) NOT NULL,
+    user_id INTEGER NOT NULL,
+    workspace_id INTEGER,
+    fallback_url TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    verify_token VARCHAR(64) NOT NULL DEFAULT '',
+    verified_at TIMESTAMP,
+    CONSTRAINT foreign_domain_user_id FOREIGN KEY (user_id) REFERENCES users(id),
+    CONSTRAINT foreign_domain_workspace_id FOREIGN KEY (workspace_id) REFERENCES workspaces(id)
+);
+-- domains added before have to be verified too, they get a token to do so
+INSERT INTO domains_new (id, host, user_id, workspace_id, fallback_url, created_at, verify_token)
+SELECT id, host, user_id, workspace_id, fallback_url, created_at, lower(hex(randomblob(32))) FROM domains;
+DROP TABLE domains;
+ALTER TABLE domains_new RENAME TO domains;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domains_verified_host ON domains (host) WHERE verified_at IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domains_owner_host ON domains (host, user_id, COALESCE(workspace_id, 0));